@@ -1,105 +1,915 @@
 // Package alog provides a simple asynchronous logger that will write to provided io.Writers without blocking calling
-// goroutines.
+// goroutines. Use the WithBufferSize option to let senders keep going while the destination is busy; anything still
+// buffered is written before Stop returns.
 package alog
 
 import (
-	"fmt"
+	"context"
 	"io"
 	"os"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// lifecycle states of a logger, stored in Alog.state.
+const (
+	stateNew int32 = iota
+	stateRunning
+	stateStopping // Stop has been called and the loop is writing what's left
+	stateStopped
+)
+
+const defaultTimestampFormat = "2006-01-02 15:04:05"
+
+const defaultErrorBufferSize = 16
+
 // Alog is a type that defines a logger. It can be used to write log messages synchronously (via the Write method)
-// or asynchronously via the channel returned by the MessageChannel accessor.
+// or asynchronously via the level methods and the channel returned by the MessageChannel accessor.
+//
+// Loggers derived from an Alog, with WithFields or Named, share its destination, channels and Start loop.
+//
+// Messages logged after Stop are handled according to the logger's LatePolicy. By default they're dropped, and
+// Write and the level methods return ErrLoggerStopped.
 type Alog struct {
+	*core
+	fields []Field      // attached to every message logged through this Alog
+	prefix atomic.Value // string
+	name   string
+}
+
+// core is the state shared by an Alog and every logger derived from it.
+type core struct {
+	written            uint64 // updated atomically, kept first for 64-bit alignment
+	seq                uint64 // the last sequence number handed out, updated atomically
+	dropped            uint64 // messages dropped because they were logged after Stop or didn't fit, updated atomically
+	unreported         uint64 // messages dropped because they didn't fit since the last drop report, updated atomically
+	suppressed         uint64 // errors that were left out of errorCh, updated atomically
+	reported           uint64 // errors reported, see ErrorCount, updated atomically
+	bytesWritten       uint64 // see Stats, updated atomically
+	writeErrors        uint64 // messages that failed to write, see Stats, updated atomically
+	fallbacks          uint64 // messages the fallback writer took instead, see Stats, updated atomically
+	highWater          int64  // see HighWaterMark, updated atomically
 	dest               io.Writer
 	m                  *sync.Mutex
 	msgCh              chan string
+	sentCh             chan Entry // entries sent on EntryChannel
+	entryCh            chan Entry
 	errorCh            chan error
-	shutdownCh         chan struct{}
-	shutdownCompleteCh chan struct{}
+	shutdownCh         chan struct{} // recreated for every run of Start, guarded by runM
+	shutdownCompleteCh chan struct{} // recreated for every run of Start, guarded by runM
+	lateStopCh         chan struct{} // closed to stop drainLate when the logger is restarted, guarded by runM
+	lateDoneCh         chan struct{} // closed by drainLate when it returns, guarded by runM
+	runM               sync.RWMutex
+	state              int32
+	panicTimeout       time.Duration
+	busy               int32      // the number of messages being written to dest, accessed atomically
+	batching           int32      // messages in the batch being put together, accessed atomically
+	workCh             chan Entry // feeds the workers while the Start loop runs with more than one
+
+	bufferSize      int
+	errorBufferSize int
+	timestampFormat string
+	precision       time.Duration // set by WithPrecision
+	multiLine       MultiLinePolicy
+	lineEnding      string // set by WithLineEnding, "" for "\n"
+	color           ColorMode
+	location        *time.Location // set by WithLocation, nil to keep the time's own
+	now             func() time.Time
+	clock           Clock // set by WithClock, along with now
+	formatter       Formatter
+	level           int32 // a Level, accessed atomically
+	verbosity       int32 // see SetVerbosity, accessed atomically
+	reportCaller    bool
+	callerSkip      int
+	stacktrace      bool
+	stackLevel      Level
+	staticFields    []Field // attached to every message, after its own fields
+	extractors      []func(ctx context.Context) map[string]any
+	sequence        bool
+	latePolicy      LatePolicy
+	workers         int
+	spillDir        string // set by WithSpill, along with spillMaxBytes
+	spillMaxBytes   int64
+	spill           *spillFile // set up for the Spill overflow policy
+	wal             *walFile   // set by WithPersistence
+	overflow        OverflowPolicy
+	queueSize       int // capacity of entryCh set with WithOverflowPolicy, or -1 to use bufferSize
+	dropReport      bool
+	maxAge          time.Duration // set by WithMaxAge, along with maxAgeExempt and expiryReport
+	expired         uint64        // accessed atomically
+	expiredUnseen   int           // expired messages not reported yet, only used by the Start loop
+	maxAgeExempt    Level
+	expiryReport    bool
+	batchMessages   int // most messages per dest.Write set with WithBatching, 0 or 1 to write them one by one
+	batchBytes      int
+	buffered        bool // set by WithBuffering, along with writeBufferSize and flushInterval
+	writeBufferSize int
+	flushInterval   time.Duration
+	buffer          *bufferedWriter // wraps the destination if buffered is set
+	ringSize        int
+	ring            *ring // replaces entryCh for logged messages if ringSize is set; Flush still uses entryCh
+	prioritySize    int
+	priorityCh      chan Entry // set up by WithPriorityLane
+	extraDests      []io.Writer
+	levelWriters    []levelWriter
+	dests           []io.Writer // every destination as it was given, before any wrapping
+	output          *output     // holds the writer passed to New, which SetOutput replaces
+	entryDest       EntryWriter // output, if the writer passed to New is an EntryWriter
+	sinks           []Sink
+	fallbackDest    io.Writer // set by WithFallbackWriter, along with fallbackRetry
+	fallbackRetry   time.Duration
+	retryAttempts   int // set by WithRetry, along with retryDelay
+	retryDelay      time.Duration
+	retry           *retryWriter
+	breakerFailures int // set by WithCircuitBreaker, along with breakerCooldown
+	breakerCooldown time.Duration
+	breakerOnChange func(from, to CircuitState)
+	breaker         *breakerWriter
+	limiter         *rateLimiter // set by WithRateLimit
+	sampler         *sampler     // set by WithSampling and WithSamplingRate
+	repeats         *repeats     // set by WithRepeatSuppression
+	heartbeat       *heartbeat   // set by WithHeartbeat
+	banner          func() string
+	closeMarker     func() string
+	filters         []func(e *Entry) bool
+	filtered        uint64 // accessed atomically
+	redactors       redactors
+	maxMessageSize  int    // set by WithMaxMessageSize, 0 for no maximum
+	truncated       uint64 // accessed atomically
+	beforeWrite     []func(e *Entry) bool
+	afterWrite      []func(p []byte, n int, err error)
+	middlewares     middlewares  // set up with Use
+	deadLetters     *deadLetters // set by WithDeadLetters and WithDeadLetterFunc
+	errorHandler    func(err error)
+	errM            sync.Mutex
+	recentErrors    []error   // see RecentErrors, guarded by errM
+	errorChUsed     bool      // set once ErrorChannel has been called, guarded by errM
+	lastErrorTime   time.Time // guarded by errM
+	stderr          io.Writer // where panics in the error handler are printed
+	exit            func(int) // called by Fatal, os.Exit outside tests
+
+	closeWriter bool // set by WithCloseWriter
+	closeM      sync.Mutex
+	closed      bool // set by the first Close, along with closeErr, guarded by closeM
+	closeErr    error
+}
+
+// Entry is a message on its way through the logger, along with the metadata needed to format it. Messages that
+// were logged without a level, via Write or MessageChannel, have LevelInfo. Formatters leave out a zero Time.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Prefix  string
+	Name    string // the name of the logger, see Alog.Named
+	Caller  string // file:line of the code that logged the message, see WithCaller
+	Fields  []Field
+	Stack   string // stack trace of the goroutine that logged the message, see WithStacktrace
+	Seq     uint64 // sequence number of the message, or 0, see WithSequence
+
+	implicit bool          // the level wasn't chosen by the caller and isn't rendered by TextFormatter
+	noTime   bool          // the message deliberately has no timestamp, so a zero Time isn't replaced
+	flushed  chan error    // if set, the entry isn't a message but a Flush waiting for everything before it
+	fb       *formatBuffer // holds the entry while it's passed through the middleware chain, see Alog.Use
+	walEnd   int64         // identifies the entry's record in the write-ahead file, or 0, see WithPersistence
+	priority bool          // logged with ErrorNow, see WithPriorityLane
+	msg      *[]byte       // the message, instead of Message, if WriteBytes or LogBytes copied it to a pooled buffer
 }
 
 // New creates a new Alog object that writes to the provided io.Writer.
 // If nil is provided the output will be directed to os.Stdout.
-func New(w io.Writer) *Alog {
+func New(w io.Writer, opts ...Option) *Alog {
 	if w == nil {
 		w = os.Stdout
 	}
-	return &Alog{
-		dest:               w,
-		m:                  &sync.Mutex{},
-		msgCh:              make(chan string),
-		errorCh:            make(chan error),
-		shutdownCh:         make(chan struct{}),
-		shutdownCompleteCh: make(chan struct{}),
+	al := &Alog{core: &core{
+		dest:            w,
+		m:               &sync.Mutex{},
+		timestampFormat: defaultTimestampFormat,
+		now:             time.Now,
+		clock:           systemClock{},
+		level:           int32(LevelInfo),
+		queueSize:       -1,
+		errorBufferSize: defaultErrorBufferSize,
+		stderr:          os.Stderr,
+		exit:            os.Exit,
+		panicTimeout:    defaultPanicTimeout,
+		sampler:         newSampler(),
+		maxAgeExempt:    LevelError,
+	}}
+	for _, opt := range opts {
+		opt(al)
+	}
+	if al.formatter == nil {
+		layout := withPrecision(al.timestampFormat, al.precision)
+		al.formatter = TextFormatter{
+			Layout:     layout,
+			MultiLine:  al.multiLine,
+			Color:      al.colored(),
+			timestamps: newTimestampCache(layout),
+		}
+	}
+	al.output = &output{w: al.dest}
+	al.dests = append([]io.Writer{al.output}, al.extraDests...)
+	for _, lw := range al.levelWriters {
+		al.dests = append(al.dests, lw.w)
+	}
+	if ew, ok := al.dest.(EntryWriter); ok {
+		// Keep destination 0 for the error positions of the other destinations.
+		al.output.ew = ew
+		al.entryDest = al.output
+		al.dest = io.Discard
+	} else {
+		al.dest = al.output
+	}
+	if al.retryAttempts > 1 {
+		rw := al.newRetryWriter()
+		al.retry = rw
+		if al.entryDest != nil {
+			rw.ew = al.entryDest
+			al.entryDest = rw
+		} else {
+			rw.w = al.dest
+			al.dest = rw
+		}
+	}
+	if al.breakerFailures > 0 {
+		bw := al.newBreakerWriter()
+		al.breaker = bw
+		if al.entryDest != nil {
+			bw.ew = al.entryDest
+			al.entryDest = bw
+		} else {
+			bw.w = al.dest
+			al.dest = bw
+		}
+	}
+	if al.fallbackDest != nil && al.entryDest == nil {
+		al.dests = append(al.dests, al.fallbackDest)
+		al.dest = &fallbackWriter{
+			primary:  al.dest,
+			fallback: al.fallbackDest,
+			retry:    al.fallbackRetry,
+			now:      func() time.Time { return al.now() },
+		}
+	}
+	if len(al.extraDests) > 0 {
+		al.dest = append(multiWriter{al.dest}, al.extraDests...)
+	}
+	if al.buffered {
+		al.buffer = newBufferedWriter(al.dest, al.writeBufferSize)
+		al.dest = al.buffer
+	}
+	if al.overflow == Spill {
+		al.spill = newSpillFile(al.spillDir, al.spillMaxBytes)
 	}
+	if al.ringSize > 0 {
+		al.ring = newRing(al.ringSize)
+	}
+	if al.prioritySize > 0 {
+		al.priorityCh = make(chan Entry, al.prioritySize)
+	}
+	al.msgCh = make(chan string, nonNegative(al.bufferSize))
+	al.sentCh = make(chan Entry, nonNegative(al.bufferSize))
+	al.entryCh = make(chan Entry, al.entryCapacity())
+	al.errorCh = make(chan error, nonNegative(al.errorBufferSize))
+	al.shutdownCh = make(chan struct{})
+	al.shutdownCompleteCh = make(chan struct{})
+	return al
+}
+
+func nonNegative(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
 }
 
 // Start begins the message loop for the asynchronous logger. It should be initiated as a goroutine to prevent
-// the caller from being blocked.
-func (al Alog) Start() {
+// the caller from being blocked. Messages are written one at a time, in the order they were received, unless
+// WithWorkers is used, and Start returns nil once the logger has been stopped. Only one loop runs per logger: Start
+// returns ErrAlreadyStarted straight away if it's already running and ErrLoggerStopped if it has been stopped and not
+// restarted.
+func (al *Alog) Start() error {
+	return al.StartContext(context.Background())
+}
+
+// StartContext runs the message loop like Start, and also stops the logger when ctx is done, writing everything
+// that's pending first, as if Stop had been called. Calling Stop as well is safe.
+func (al *Alog) StartContext(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&al.state, stateNew, stateRunning) {
+		if atomic.LoadInt32(&al.state) >= stateStopping {
+			return ErrLoggerStopped
+		}
+		return ErrAlreadyStarted
+	}
+	shutdownCh, _ := al.runChannels()
 	wg := &sync.WaitGroup{}
+	al.startWorkers(wg)
+	al.writeMarker(al.banner, wg)
+	al.replayPersisted(wg)
+	var tickCh <-chan time.Time
+	if al.buffer != nil && al.flushInterval > 0 {
+		ticker := al.clock.NewTicker(al.flushInterval)
+		defer ticker.Stop()
+		tickCh = ticker.C()
+	}
+	var heartbeatCh <-chan time.Time
+	if ticker := al.startHeartbeat(); ticker != nil {
+		defer ticker.Stop()
+		heartbeatCh = ticker.C()
+	}
 loop: // label is required because break can target for OR case
 	for { // this is an infinite for loop
+		priorityCh := al.priorityLane(al.takePriority(wg))
 		select {
 		case msg := <-al.msgCh:
 			wg.Add(1) // 'we are waiting for 1 function'
-			go al.write(msg, wg)
-		case <-al.shutdownCh: // case doesn't need a defined variable
-			wg.Wait() // this waits for a "wg.Done()" from elsewhere
+			al.write(msg, wg)
+		case e := <-al.sentCh:
+			wg.Add(1)
+			al.writeSent(e, wg)
+		case e := <-al.entryCh:
+			al.handleEntry(e, wg)
+			al.reportDrops(wg)
+			al.reportExpired(wg)
+		case e := <-priorityCh:
+			al.process(e, wg)
+		case <-al.ringWake():
+			al.drainRing(wg)
+			al.reportDrops(wg)
+			al.reportExpired(wg)
+		case <-al.spillWake():
+			al.replaySpill(wg)
+			al.reportDrops(wg)
+			al.reportExpired(wg)
+		case <-tickCh:
+			al.flushBuffer()
+		case <-al.repeatTimer():
+			al.repeatTimerFired(wg)
+		case <-heartbeatCh:
+			al.beat(wg)
+		case <-shutdownCh: // case doesn't need a defined variable
+			al.drain(wg)
+			al.writeMarker(al.closeMarker, wg)
+			al.stopWorkers(wg)
+			al.shutdown()
+			break loop
+		case <-ctx.Done():
+			atomic.CompareAndSwapInt32(&al.state, stateRunning, stateStopping)
+			al.drain(wg)
+			al.writeMarker(al.closeMarker, wg)
+			al.stopWorkers(wg)
 			al.shutdown()
 			break loop
 		}
 	}
+	return nil
 }
 
-func (al Alog) formatMessage(msg string) string {
-	if !strings.HasSuffix(msg, "\n") {
-		msg += "\n"
+// drain writes every message that is already buffered in msgCh, sentCh, entryCh, the priority queue and the ring.
+// It doesn't wait for new messages.
+func (al *Alog) drain(wg *sync.WaitGroup) {
+	for {
+		al.takePriority(wg)
+		select {
+		case msg := <-al.msgCh:
+			wg.Add(1)
+			al.write(msg, wg)
+		case e := <-al.sentCh:
+			wg.Add(1)
+			al.writeSent(e, wg)
+		case e := <-al.entryCh:
+			al.handleEntry(e, wg)
+		default:
+			if len(al.priorityCh) > 0 {
+				continue
+			}
+			if al.ring != nil && al.ring.len() > 0 {
+				al.drainRing(wg)
+				continue
+			}
+			if al.spillLen() > 0 {
+				al.replaySpill(wg)
+				continue
+			}
+			al.reportDrops(wg)
+			al.reportExpired(wg)
+			al.reportSuppressed(wg)
+			al.flushRepeats(wg)
+			return
+		}
 	}
-	return fmt.Sprintf("[%v] - %v", time.Now().Format("2006-01-02 15:04:05"), msg)
 }
 
-func (al Alog) write(msg string, wg *sync.WaitGroup) {
-	al.m.Lock()         // this locks the mutex
-	defer al.m.Unlock() // a defer statement defers the execution of a fucntion until the surrounding function returns
-	_, err := al.dest.Write([]byte(al.formatMessage(msg)))
-	if err != nil { // if there's an error, create a goroutine to pipe that error into the errorCh, this prevents deadlocking
-		go func(err error) {
-			al.errorCh <- err
-		}(err)
+// stamp sets e's time, if it has none, adds the static fields, truncates the message, see WithMaxMessageSize, and
+// applies the redactors, see WithRedactor.
+func (al *Alog) stamp(e *Entry) {
+	if e.Time.IsZero() && !e.noTime {
+		e.Time = al.now()
+	}
+	if al.location != nil {
+		e.Time = e.Time.In(al.location)
+	}
+	if len(al.staticFields) > 0 {
+		e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], al.staticFields...)
 	}
-	wg.Done()
+	if al.maxMessageSize > 0 {
+		al.truncate(e) // before redacting, which would have to go through all of a huge message
+	}
+	if len(al.redactors) > 0 {
+		al.redactors.redact(e)
+	}
+}
+
+// formatMessage stamps the entry held by fb and appends it, formatted, to fb.buf. It returns false, without
+// formatting the entry, if a BeforeWrite hook vetoed it.
+func (al *Alog) formatMessage(fb *formatBuffer) bool {
+	if !al.prepare(&fb.e) {
+		return false
+	}
+	al.appendFormatted(fb)
+	return true
 }
 
-func (al Alog) shutdown() {
-	close(al.msgCh)
-	al.shutdownCompleteCh <- struct{}{}
+// prepare stamps e and runs the BeforeWrite hooks, reporting whether e should be written.
+func (al *Alog) prepare(e *Entry) bool {
+	al.stamp(e)
+	return len(al.beforeWrite) == 0 || al.runBeforeWrite(e)
 }
 
-// MessageChannel returns a channel that accepts messages that should be written to the log.
-func (al Alog) MessageChannel() chan<- string { // addded 'chan<-', since msgCh will never send messages to consumers
+// appendFormatted appends the entry held by fb, formatted, to fb.buf.
+func (al *Alog) appendFormatted(fb *formatBuffer) {
+	switch al.formatter.(type) {
+	case TextFormatter, JSONFormatter, LogfmtFormatter:
+	default:
+		fb.e.materialize() // only the built-in formatters know about messages in pooled buffers
+	}
+	fb.buf = al.formatter.Format(fb.buf, &fb.e)
+	if n := len(fb.buf); al.lineEnding != "" && n > 0 && fb.buf[n-1] == '\n' {
+		fb.buf = append(fb.buf[:n-1], al.lineEnding...)
+	}
+	if len(al.levelWriters) > 0 || al.deadLetters != nil {
+		fb.spans = append(fb.spans, span{end: len(fb.buf), level: fb.e.Level, message: fb.e.Message})
+	}
+}
+
+// writeMessage formats e and writes it to dest, and the level writers it qualifies for, in a single call each,
+// which is all the serialization it does; callers take the mutex if they need it. It returns errVetoed if a
+// BeforeWrite hook or a middleware dropped e.
+func (al *Alog) writeMessage(e Entry) (int, error) {
+	fb := getFormatBuffer()
+	defer putFormatBuffer(fb)
+	fb.e = e
+	if !al.prepare(&fb.e) {
+		return 0, errVetoed
+	}
+	if h := al.handler(); h != nil {
+		fb.e.materialize() // the middleware may have been added after the message was copied
+		handled, err := al.handle(h, fb)
+		if handled == 0 && err == nil {
+			err = errVetoed
+		}
+		return fb.n, err
+	}
+	return al.writePrepared(fb)
+}
+
+// writePrepared writes the entry held by fb, which has been through prepare, to the destinations and passes it to
+// the AfterWrite hooks.
+func (al *Alog) writePrepared(fb *formatBuffer) (int, error) {
+	var n int
+	var err error
+	if al.entryDest != nil {
+		n, err = al.writeEntryDest(fb)
+	} else {
+		al.appendFormatted(fb)
+		n, err = al.writeFormatted(fb)
+	}
+	if len(al.sinks) > 0 {
+		err = joinErrors([]error{err, al.writeSinks(&fb.e)})
+	}
+	if err != nil && al.deadLetters != nil {
+		al.deadLetter(fb, err)
+	}
+	if len(al.afterWrite) > 0 {
+		al.runAfterWrite(fb.buf, n, err)
+	}
+	if err != nil && err != ErrCircuitOpen {
+		if len(fb.buf) == 0 {
+			al.appendFormatted(fb) // the destination is an EntryWriter and nothing else needed the formatted message
+		}
+		err = newWriteError(fb.e.text(), fb.e.Time, fb.buf, err)
+	}
+	return n, err
+}
+
+// newWriteError wraps err, the error from writing the message msg, which was formatted as formatted.
+func newWriteError(msg string, t time.Time, formatted []byte, err error) *WriteError {
+	return &WriteError{Msg: msg, Formatted: append([]byte(nil), formatted...), Time: t, Err: err}
+}
+
+// write writes a message received on msgCh. Those messages are logged at LevelInfo.
+func (al *Alog) write(msg string, wg *sync.WaitGroup) {
+	al.writeSent(Entry{Level: LevelInfo, Message: msg, implicit: true}, wg)
+}
+
+// writeSent writes an entry received on msgCh or sentCh.
+func (al *Alog) writeSent(e Entry, wg *sync.WaitGroup) {
+	defer wg.Done()
+	al.markHighWater() // messages sent on the channels can only be seen once they're received
+	if !al.accept(&e) {
+		return
+	}
+	al.process(e, wg)
+}
+
+// accept prepares e, an entry received on msgCh or sentCh, to be written: it stamps e with the time it was
+// received, unless it has one, and numbers it. It returns false if e's level isn't enabled or it's filtered out.
+func (al *Alog) accept(e *Entry) bool {
+	if !al.enabled(e.Level) {
+		return false
+	}
+	e.flushed, e.fb = nil, nil // in case e was copied from one that went through the logger
+	if e.Time.IsZero() {
+		e.Time = al.now()
+	}
+	if len(al.filters) > 0 && al.filteredOut(*e) {
+		return false
+	}
+	e.Seq = al.nextSeq()
+	return true
+}
+
+func (al *Alog) writeEntry(e Entry) {
+	atomic.AddInt32(&al.busy, 1) // before waiting for the mutex, so Pending still counts e
+	al.writeBusy(e)
+}
+
+// writeBusy writes e, which is already counted in busy, and stops counting it once it's been written.
+func (al *Alog) writeBusy(e Entry) {
+	n, err := al.writeLocked(e)
+	releaseMessage(&e)
+	atomic.AddInt32(&al.busy, -1)
+	if err == errVetoed {
+		al.consumed(e.walEnd)
+		return
+	}
+	if err == ErrCircuitOpen {
+		atomic.AddUint64(&al.dropped, 1)
+		return
+	}
+	if err != nil {
+		al.countFailed(1, err)
+		al.reportError(err)
+	} else {
+		al.countWritten(1, n)
+		al.consumed(e.walEnd)
+	}
+}
+
+// writeLocked writes e holding the mutex, unless it's written by one of several workers that don't share a buffer,
+// so that writes from the Start loop, the workers and Write never interleave in the destination. The error is
+// reported by the caller once the mutex is released, in case the error handler logs.
+func (al *Alog) writeLocked(e Entry) (int, error) {
+	if al.workers <= 1 || al.buffer != nil {
+		al.m.Lock()         // this locks the mutex
+		defer al.m.Unlock() // a defer statement defers the execution of a fucntion until the surrounding function returns
+	}
+	n, err := al.writeMessage(e)
+	if err == nil && al.buffer != nil && atomic.LoadInt32(&al.state) == stateStopped {
+		err = al.buffer.Flush() // nothing else flushes the buffer once the logger is stopped
+	}
+	return n, err
+}
+
+// Restart returns a stopped logger to the state it was in before it was first started, with the same destination
+// and options, so Start can be called again. Messages logged while the logger was stopped are not replayed.
+// Restart does nothing if the logger has never been started and returns ErrAlreadyStarted if it's running or still
+// being stopped.
+//
+// Restarting is a separate step so that a Start that runs late, as in "go al.Start(); al.Stop()", doesn't bring a
+// stopped logger back to life.
+func (al *Alog) Restart() error {
+	al.runM.Lock()
+	defer al.runM.Unlock()
+	if !atomic.CompareAndSwapInt32(&al.state, stateStopped, stateNew) {
+		if atomic.LoadInt32(&al.state) == stateNew {
+			return nil
+		}
+		return ErrAlreadyStarted
+	}
+	close(al.lateStopCh)
+	<-al.lateDoneCh
+	if al.retry != nil {
+		al.retry.reset()
+	}
+	al.shutdownCh = make(chan struct{})
+	al.shutdownCompleteCh = make(chan struct{})
+	return nil
+}
+
+// runChannels returns the shutdown channels of the current run.
+func (al *Alog) runChannels() (shutdownCh, shutdownCompleteCh chan struct{}) {
+	al.runM.RLock()
+	defer al.runM.RUnlock()
+	return al.shutdownCh, al.shutdownCompleteCh
+}
+
+// shutdown closes shutdownCompleteCh to release every goroutine waiting in Stop. The message channel stays open so
+// late senders don't panic; a goroutine keeps receiving from it until the logger is restarted and handles what it
+// receives according to the LatePolicy.
+func (al *Alog) shutdown() {
+	al.runM.Lock()
+	defer al.runM.Unlock()
+	atomic.StoreInt32(&al.state, stateStopped)
+	al.flushBuffer() // after the state changes, so late writes know they have to flush themselves
+	al.closeSinks()
+	al.closeSpill()
+	al.closePersistence()
+	al.waitDestinations()
+	close(al.shutdownCompleteCh)
+	al.lateStopCh = make(chan struct{})
+	al.lateDoneCh = make(chan struct{})
+	go al.drainLate(al.lateStopCh, al.lateDoneCh)
+}
+
+// drainLate handles messages sent on msgCh and sentCh after the logger was stopped, until stopCh is closed.
+func (al *Alog) drainLate(stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+	for {
+		select {
+		case msg := <-al.msgCh:
+			if al.enabled(LevelInfo) {
+				_ = al.late(Entry{Level: LevelInfo, Message: msg, Seq: al.nextSeq(), implicit: true})
+			}
+		case e := <-al.sentCh:
+			if al.accept(&e) {
+				_ = al.late(e)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// late handles an entry that was logged after Stop. With LateWriteSync it's written on the calling goroutine and
+// the write error is returned, otherwise it's counted as dropped and ErrLoggerStopped is returned.
+func (al *Alog) late(e Entry) error {
+	if al.latePolicy != LateWriteSync {
+		atomic.AddUint64(&al.dropped, 1)
+		return ErrLoggerStopped
+	}
+	al.m.Lock()
+	defer al.m.Unlock()
+	n, err := al.writeMessage(e)
+	if err == errVetoed {
+		al.consumed(e.walEnd)
+		return nil
+	}
+	if err == nil && al.buffer != nil {
+		err = al.buffer.Flush()
+	}
+	if err == nil {
+		al.countWritten(1, n)
+		al.consumed(e.walEnd)
+	} else if err != ErrCircuitOpen {
+		al.countFailed(1, err)
+	}
+	return err
+}
+
+// Dropped returns the number of messages that were dropped, because they were logged after Stop, because they
+// didn't fit in the queue, see WithOverflowPolicy, because of WithRateLimit, or because the circuit breaker set up
+// with WithCircuitBreaker was open.
+func (al *Alog) Dropped() uint64 {
+	return atomic.LoadUint64(&al.dropped)
+}
+
+// MessageChannel returns a channel that accepts messages that should be written to the log at LevelInfo. They're
+// stamped with the time they're received, which is when they're sent unless the channel is buffered, see
+// WithBufferSize, and the logger is falling behind. Use EntryChannel to set the time, or anything else about a
+// message. Messages sent from a single goroutine are written in the order they were sent, but there's no ordering
+// between this channel and the level methods. Sending on the channel after Stop has been called doesn't block or
+// panic, the message is handled according to the LatePolicy, but there's no way to report that it was dropped. Use
+// the level methods or AsWriter where that matters.
+func (al *Alog) MessageChannel() chan<- string { // addded 'chan<-', since msgCh will never send messages to consumers
 	return al.msgCh
 }
 
+// EntryChannel returns a channel that accepts entries to be written to the log as they are, apart from their
+// sequence number, see WithSequence, and their time, if it's zero, which is set like MessageChannel's. Entries
+// below the logger's level are discarded and filters apply, see WithFilter, but the logger's prefix, name and
+// fields aren't added. It's buffered like MessageChannel, handles entries sent after Stop the same way, and
+// entries sent from a single goroutine are written in order.
+func (al *Alog) EntryChannel() chan<- Entry {
+	return al.sentCh
+}
+
 // ErrorChannel returns a channel that will be populated when an error is raised during a write operation.
-// This channel should always be monitored in some way to prevent deadlock goroutines from being generated
-// when errors occur.
-func (al Alog) ErrorChannel() <-chan error { // added '<-chan', since errorCh will only receive messages on this channel
+// Errors are delivered without blocking the logger: when the channel's buffer is full the oldest error is discarded
+// to make room, so nothing goes wrong if the channel isn't monitored. SuppressedErrors counts the discarded errors.
+// Until ErrorChannel is first called errors are only kept for RecentErrors, and the first call sends those on the
+// channel, as far as it has room, so they aren't missed.
+func (al *Alog) ErrorChannel() <-chan error { // added '<-chan', since errorCh will only receive messages on this channel
+	al.errM.Lock()
+	defer al.errM.Unlock()
+	if !al.errorChUsed {
+		al.errorChUsed = true
+		for _, err := range al.recentErrors {
+			al.sendError(err)
+		}
+	}
 	return al.errorCh
 }
 
 // Stop shuts down the logger. It will wait for all pending messages to be written and then return.
-// The logger will no longer function after this method has been called.
-func (al Alog) Stop() {
-	al.shutdownCh <- struct{}{}
-	<-al.shutdownCompleteCh
+// The logger doesn't write anything asynchronously after this method has been called, unless it's restarted with
+// Restart and Start. It is safe to call Stop more than once and to call it on a logger that was never started.
+// Messages logged with the level methods once Stop has been called are dropped. Use StopContext or StopTimeout if the
+// destination might hang.
+func (al *Alog) Stop() {
+	_ = al.StopContext(context.Background())
+}
+
+// StopTimeout is like StopContext with a context that's cancelled after d.
+func (al *Alog) StopTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return al.StopContext(ctx)
+}
+
+// StopContext shuts down the logger like Stop, but gives up waiting for the pending messages to be written when ctx
+// is done. It then returns a *StopError with the number of messages that were still pending. Those messages are
+// still written, and the logger finishes shutting down, if the destination recovers. Either way the logger counts
+// as stopped once StopContext returns.
+func (al *Alog) StopContext(ctx context.Context) error {
+	shutdownCh, shutdownCompleteCh := al.runChannels()
+	if atomic.CompareAndSwapInt32(&al.state, stateNew, stateStopping) {
+		// Start was never run, so there's no loop to wait for. Hand the signal over if something is listening on
+		// shutdownCh, otherwise write whatever is buffered and shut down on a goroutine of our own.
+		select {
+		case shutdownCh <- struct{}{}:
+		default:
+			go func() {
+				wg := &sync.WaitGroup{}
+				al.writeMarker(al.banner, wg)
+				al.replayPersisted(wg)
+				al.drain(wg)
+				al.writeMarker(al.closeMarker, wg)
+				al.shutdown()
+			}()
+		}
+		return al.waitShutdown(ctx, shutdownCompleteCh)
+	}
+	atomic.CompareAndSwapInt32(&al.state, stateRunning, stateStopping)
+	select {
+	case shutdownCh <- struct{}{}:
+	case <-shutdownCompleteCh: // the loop has already shut down
+	case <-ctx.Done():
+		return al.stopError(ctx.Err())
+	}
+	return al.waitShutdown(ctx, shutdownCompleteCh)
+}
+
+// waitShutdown waits for shutdownCompleteCh to be closed or for ctx to be done, whichever comes first.
+func (al *Alog) waitShutdown(ctx context.Context, shutdownCompleteCh chan struct{}) error {
+	select {
+	case <-shutdownCompleteCh:
+		return nil
+	case <-ctx.Done():
+		return al.stopError(ctx.Err())
+	}
+}
+
+func (al *Alog) stopError(err error) *StopError {
+	return &StopError{Pending: al.Pending(), Err: err}
+}
+
+// Writef formats the message like fmt.Sprintf and writes it synchronously like Write. Nothing is formatted if the
+// logger's level is above LevelInfo.
+func (al *Alog) Writef(format string, args ...any) (int, error) {
+	if !al.enabled(LevelInfo) {
+		return 0, nil
+	}
+	return al.writeSync(sprintf(format, args...))
+}
+
+// Write synchronously sends the message to the log output at LevelInfo. It takes turns with the Start loop, so its
+// message is never mixed up with one that's being written asynchronously, but it doesn't wait for the queue. Write
+// returns the number of bytes written for the message once it's been formatted, with its timestamp, level and
+// fields, rather than len(msg); see WriteString for a method with io semantics. If the logger's level is above
+// LevelInfo nothing is written and Write returns 0 and a nil error. A failed write returns a *WriteError. Once Stop
+// has been called Write returns 0 and ErrLoggerStopped unless the LatePolicy is LateWriteSync.
+func (al *Alog) Write(msg string) (int, error) {
+	if !al.enabled(LevelInfo) {
+		return 0, nil
+	}
+	return al.writeSync(msg)
+}
+
+// WriteString writes s like Write, but with the semantics of io.StringWriter: it returns len(s) and a nil error once
+// s has been dealt with, whether it was written or deliberately not, such as when the logger's level is above
+// LevelInfo, and 0 and the error otherwise.
+func (al *Alog) WriteString(s string) (int, error) {
+	if !al.enabled(LevelInfo) {
+		return len(s), nil
+	}
+	if _, err := al.writeSync(s); err != nil {
+		return 0, err
+	}
+	return len(s), nil
+}
+
+// writeSync writes msg on the calling goroutine. It must only be called by Write, Writef and the package-level
+// Write, so the caller is always the same number of frames up.
+func (al *Alog) writeSync(msg string) (int, error) {
+	if al.refusesSync() {
+		return 0, ErrLoggerStopped
+	}
+	e := al.newEntry(LevelInfo, msg)
+	e.Caller = al.caller(2)
+	return al.writeSyncEntry(e)
+}
+
+// refusesSync reports whether a synchronous write has to be refused because Stop has been called, and counts it as
+// dropped if so.
+func (al *Alog) refusesSync() bool {
+	if atomic.LoadInt32(&al.state) >= stateStopping && al.latePolicy != LateWriteSync {
+		atomic.AddUint64(&al.dropped, 1)
+		return true
+	}
+	return false
+}
+
+// writeSyncEntry writes e, a message logged without a level by writeSync or WriteBytes, on the calling goroutine.
+func (al *Alog) writeSyncEntry(e Entry) (int, error) {
+	defer releaseMessage(&e)
+	e.implicit = true
+	e.Stack = al.stack(LevelInfo)
+	if len(al.filters) > 0 && al.filteredOut(e) {
+		return 0, nil
+	}
+	e.Seq = al.nextSeq()
+	n, err := al.writeLocked(e)
+	if err == errVetoed {
+		return 0, nil
+	}
+	if err == nil {
+		al.countWritten(1, n)
+	} else if err != ErrCircuitOpen {
+		al.countFailed(1, err)
+	}
+	return n, err
+}
+
+// Sequence returns the sequence number of the last message that was logged, or 0 if WithSequence wasn't used or
+// nothing has been logged yet. Comparing it with the number of messages that were written shows how many were lost.
+func (al *Alog) Sequence() uint64 {
+	return atomic.LoadUint64(&al.seq)
+}
+
+// nextSeq returns the next sequence number, or 0 if WithSequence wasn't used.
+func (al *Alog) nextSeq() uint64 {
+	if !al.sequence {
+		return 0
+	}
+	return atomic.AddUint64(&al.seq, 1)
+}
+
+// reportError keeps err for RecentErrors and hands it to the error handler, see WithErrorHandler, and to errorCh
+// once ErrorChannel has been called.
+func (al *Alog) reportError(err error) {
+	used := al.recordError(err)
+	if al.errorHandler != nil {
+		al.handleError(err)
+	}
+	if used {
+		al.sendError(err)
+	}
+}
+
+// sendError hands err to errorCh without blocking, discarding the oldest buffered error if the channel is full.
+func (al *Alog) sendError(err error) {
+	for {
+		select {
+		case al.errorCh <- err:
+			return
+		default:
+		}
+		select {
+		case <-al.errorCh:
+			atomic.AddUint64(&al.suppressed, 1)
+		default: // unbuffered and nobody is receiving
+			atomic.AddUint64(&al.suppressed, 1)
+			return
+		}
+	}
 }
 
-// Write synchronously sends the message to the log output
-func (al Alog) Write(msg string) (int, error) {
-	return al.dest.Write([]byte(al.formatMessage(msg)))
+// SuppressedErrors returns the number of write errors that were discarded because the channel returned by
+// ErrorChannel was full.
+func (al *Alog) SuppressedErrors() uint64 {
+	return atomic.LoadUint64(&al.suppressed)
 }
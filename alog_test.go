@@ -0,0 +1,606 @@
+package alog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStopDrainsPendingMessages(t *testing.T) {
+	d := &Discard{}
+	alog := New(d)
+	go alog.Start()
+	for i := 0; i < 1000; i++ {
+		alog.MessageChannel() <- "message"
+	}
+	alog.Stop()
+	if lines := d.Count(); lines != 1000 {
+		t.Errorf("Expected 1000 lines to be written before Stop returned, got %d", lines)
+	}
+}
+
+func TestStopWithoutStart(t *testing.T) {
+	alog := New(nil)
+	doneCh := make(chan struct{})
+	go func() {
+		alog.Stop()
+		alog.Stop()
+		doneCh <- struct{}{}
+	}()
+	select {
+	case <-time.After(1 * time.Second):
+		t.Fatal("Stop blocked on a logger that was never started")
+	case <-doneCh:
+	}
+}
+
+func TestStopTwice(t *testing.T) {
+	alog := New(bytes.NewBuffer([]byte{}))
+	go alog.Start()
+	alog.MessageChannel() <- "message"
+	doneCh := make(chan struct{})
+	go func() {
+		alog.Stop()
+		alog.Stop()
+		doneCh <- struct{}{}
+	}()
+	select {
+	case <-time.After(1 * time.Second):
+		t.Fatal("Second call to Stop blocked")
+	case <-doneCh:
+	}
+}
+
+func TestSendAfterStopIsDropped(t *testing.T) {
+	d := &Discard{}
+	alog := New(nil, WithDiscard(d))
+	go alog.Start()
+	alog.Stop()
+	alog.MessageChannel() <- "late"
+	alog.MessageChannel() <- "late"
+	if _, err := alog.Write("late"); err != ErrLoggerStopped {
+		t.Errorf("Expected Write to return ErrLoggerStopped, got %v", err)
+	}
+	if err := alog.Info("late"); err != ErrLoggerStopped {
+		t.Errorf("Expected Info to return ErrLoggerStopped, got %v", err)
+	}
+	for alog.Dropped() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	if n := d.Count(); n != 0 {
+		t.Errorf("%d late messages were written", n)
+	}
+}
+
+func TestSendAfterStopWriteSync(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithLatePolicy(LateWriteSync))
+	go alog.Start()
+	alog.Stop()
+	if err := alog.Warn("late"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "[WARN] - late\n") {
+		t.Errorf("Late message wasn't written, got %q", b.String())
+	}
+	if alog.Dropped() != 0 {
+		t.Errorf("Expected no dropped messages, got %d", alog.Dropped())
+	}
+}
+
+func TestStopRacesSenders(t *testing.T) {
+	d := &Discard{}
+	alog := New(d, WithBufferSize(10))
+	go alog.Start()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if i%2 == 0 {
+					alog.MessageChannel() <- "channel"
+				} else if err := alog.Info("method"); err != nil && err != ErrLoggerStopped {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	alog.Stop()
+	wg.Wait()
+	for alog.Dropped()+d.Count() < 1000 {
+		time.Sleep(time.Millisecond) // late channel sends are counted as they're drained
+	}
+	if n := alog.Dropped() + d.Count(); n != 1000 {
+		t.Errorf("Expected every message to be written or dropped, got %d", n)
+	}
+}
+
+func TestMethodsUsePointerReceivers(t *testing.T) {
+	if n := reflect.TypeOf(Alog{}).NumMethod(); n != 0 {
+		t.Errorf("Alog has %d value receiver methods, all methods should use *Alog", n)
+	}
+}
+
+func TestSharedStateAcrossGoroutines(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithLatePolicy(LateWriteSync))
+	go alog.Start()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				alog.MessageChannel() <- "async"
+			}
+		}()
+	}
+	wg.Wait()
+	alog.Stop()
+	if _, err := alog.Write("sync"); err != nil {
+		t.Fatal(err)
+	}
+	if written := atomic.LoadUint64(&alog.written); written != 101 {
+		t.Errorf("Expected the message counter to be 101, got %d", written)
+	}
+}
+
+func TestMessagesWrittenInOrder(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	for i := 0; i < 10000; i++ {
+		alog.MessageChannel() <- strconv.Itoa(i)
+	}
+	alog.Stop()
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 10000 {
+		t.Fatalf("Expected 10000 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, " - "+strconv.Itoa(i)) {
+			t.Fatalf("Line %d out of order: %q", i, line)
+		}
+	}
+}
+
+type slowWriter struct {
+	m *sync.Mutex
+	b *bytes.Buffer
+}
+
+func (sw slowWriter) Write(data []byte) (int, error) {
+	time.Sleep(10 * time.Millisecond)
+	sw.m.Lock()
+	defer sw.m.Unlock()
+	return sw.b.Write(data)
+}
+
+func TestBufferedSendsDoNotBlockOnSlowWriter(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(slowWriter{&sync.Mutex{}, b}, WithBufferSize(100))
+	go alog.Start()
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		alog.MessageChannel() <- "message"
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("100 buffered sends took %v, expected them to complete immediately", elapsed)
+	}
+	alog.Stop()
+	if lines := strings.Count(b.String(), "\n"); lines != 100 {
+		t.Errorf("Expected all 100 buffered messages to be flushed by Stop, got %d", lines)
+	}
+}
+
+func TestSequenceNumbers(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithSequence(), WithBufferSize(100))
+	go alog.Start()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				alog.Info("message")
+			}
+		}()
+	}
+	wg.Wait()
+	alog.Stop()
+
+	seen := map[uint64]bool{}
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		_, seq, ok := strings.Cut(line, " seq=")
+		n, err := strconv.ParseUint(seq, 10, 64)
+		if !ok || err != nil || n == 0 || n > 10000 {
+			t.Fatalf("Line has no valid sequence number: %q", line)
+		}
+		seen[n] = true
+	}
+	if len(seen) != 10000 {
+		t.Errorf("Expected 10000 unique sequence numbers, got %d", len(seen))
+	}
+	if seq := alog.Sequence(); seq != 10000 {
+		t.Errorf("Expected Sequence to return 10000, got %d", seq)
+	}
+}
+
+func TestSequenceOff(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	if _, err := alog.Write("test"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "seq=") || alog.Sequence() != 0 {
+		t.Errorf("Message numbered without WithSequence: %q", b.String())
+	}
+}
+
+// blockingWriter blocks every Write until release is closed.
+type blockingWriter struct {
+	release chan struct{}
+}
+
+func (bw blockingWriter) Write(data []byte) (int, error) {
+	<-bw.release
+	return len(data), nil
+}
+
+func TestStopContextGivesUpOnHungWriter(t *testing.T) {
+	bw := blockingWriter{make(chan struct{})}
+	defer close(bw.release)
+	alog := New(bw, WithBufferSize(10))
+	go alog.Start()
+	for i := 0; i < 5; i++ {
+		alog.Info("message")
+	}
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	err := alog.StopTimeout(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("StopTimeout took %v", elapsed)
+	}
+	var stopErr *StopError
+	if !errors.As(err, &stopErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a *StopError wrapping context.DeadlineExceeded, got %v", err)
+	}
+	if stopErr.Pending != 5 {
+		t.Errorf("Expected 5 pending messages, got %d", stopErr.Pending)
+	}
+
+	alog.Info("late")
+	alog.MessageChannel() <- "late"
+}
+
+func TestStopContextAfterDrain(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(10))
+	go alog.Start()
+	alog.Info("message")
+	if err := alog.StopContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), " - message\n") {
+		t.Errorf("Pending message wasn't written, got %q", b.String())
+	}
+	if err := New(nil).StopTimeout(time.Second); err != nil {
+		t.Errorf("StopTimeout on a logger that was never started returned %v", err)
+	}
+}
+
+func TestConcurrentStart(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	errCh := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			errCh <- alog.Start()
+		}()
+	}
+	for i := 0; i < 9; i++ {
+		if err := <-errCh; err != ErrAlreadyStarted {
+			t.Fatalf("Expected ErrAlreadyStarted, got %v", err)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		alog.MessageChannel() <- strconv.Itoa(i)
+	}
+	alog.Stop()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected the running loop to return nil, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 100 {
+		t.Fatalf("Expected 100 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, " - "+strconv.Itoa(i)) {
+			t.Fatalf("Line %d duplicated or out of order: %q", i, line)
+		}
+	}
+	if err := alog.Start(); err != ErrLoggerStopped {
+		t.Errorf("Expected Start after Stop to return ErrLoggerStopped, got %v", err)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(10))
+	before := runtime.NumGoroutine()
+	for i := 0; i < 5; i++ {
+		if err := alog.Restart(); err != nil {
+			t.Fatal(err)
+		}
+		doneCh := make(chan error)
+		go func() {
+			doneCh <- alog.Start()
+		}()
+		for atomic.LoadInt32(&alog.state) != stateRunning {
+			time.Sleep(time.Millisecond)
+		}
+		alog.Info("run " + strconv.Itoa(i))
+		alog.Stop()
+		if err := <-doneCh; err != nil {
+			t.Fatalf("Run %d: Start returned %v", i, err)
+		}
+		if err := alog.Start(); err != ErrLoggerStopped {
+			t.Fatalf("Run %d: expected Start without Restart to return ErrLoggerStopped, got %v", i, err)
+		}
+	}
+	// One goroutine keeps handling late messages while the logger is stopped. Give the goroutines that ran Start
+	// a moment to exit.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before+1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before+1 {
+		t.Errorf("Expected at most %d goroutines after 5 runs, got %d", before+1, after)
+	}
+	for i := 0; i < 5; i++ {
+		if !strings.Contains(b.String(), " - run "+strconv.Itoa(i)+"\n") {
+			t.Errorf("Message from run %d missing in %q", i, b.String())
+		}
+	}
+}
+
+func TestRestartRunningLogger(t *testing.T) {
+	alog := New(nil)
+	go alog.Start()
+	defer alog.Stop()
+	for atomic.LoadInt32(&alog.state) != stateRunning {
+		time.Sleep(time.Millisecond)
+	}
+	if err := alog.Restart(); err != ErrAlreadyStarted {
+		t.Errorf("Expected ErrAlreadyStarted, got %v", err)
+	}
+}
+
+func TestStartContextCancel(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(10))
+	ctx, cancel := context.WithCancel(context.Background())
+	doneCh := make(chan error)
+	go func() {
+		doneCh <- alog.StartContext(ctx)
+	}()
+	for i := 0; i < 100; i++ {
+		alog.Info("message")
+		alog.MessageChannel() <- "channel"
+	}
+	cancel()
+	select {
+	case err := <-doneCh:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("StartContext didn't return after the context was cancelled")
+	}
+	if lines := strings.Count(b.String(), "\n"); lines != 200 {
+		t.Errorf("Expected 200 lines, got %d", lines)
+	}
+	if err := alog.Info("late"); err != ErrLoggerStopped {
+		t.Errorf("Expected ErrLoggerStopped after cancellation, got %v", err)
+	}
+}
+
+func TestStartContextCancelAndStop(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		alog := New(bytes.NewBuffer([]byte{}))
+		ctx, cancel := context.WithCancel(context.Background())
+		doneCh := make(chan error)
+		go func() {
+			doneCh <- alog.StartContext(ctx)
+		}()
+		alog.Info("message")
+		go cancel()
+		alog.Stop()
+		if err := <-doneCh; err != nil && err != ErrLoggerStopped {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEntryChannel(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBufferSize(10), WithLevel(LevelWarn), WithTimestampFormat(time.RFC3339),
+		WithFilter(func(e *Entry) bool { return e.Message != "secret" }))
+	go alog.Start()
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alog.EntryChannel() <- Entry{Time: ts, Level: LevelError, Message: "failed", Fields: []Field{{"id", 7}}}
+	alog.EntryChannel() <- Entry{Level: LevelInfo, Message: "below the level"}
+	alog.EntryChannel() <- Entry{Level: LevelWarn, Message: "secret"}
+	alog.Stop()
+	alog.EntryChannel() <- Entry{Level: LevelError, Message: "late"}
+	for alog.Dropped() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if got, want := lb.String(), "[2024-05-01T12:00:00Z] [ERROR] - failed id=7\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestChannelTimestampsUnderQueueDelay(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithTimestampFormat("15:04:05"))
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog.now = clock.Now
+	go alog.Start()
+	alog.MessageChannel() <- "sent at noon"
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond) // the message is stuck in the writer
+	}
+	clock.advance(time.Minute)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		alog.EntryChannel() <- Entry{Time: time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC), Message: "timed"}
+	}()
+	close(gw.open)
+	<-done
+	alog.Stop()
+
+	want := "[12:00:00] - sent at noon\n[12:00:30] [INFO] - timed\n"
+	if got := gw.b.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestTimestampsUnderQueueDelay(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithTimestampFormat("15:04:05"), WithBufferSize(10))
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog.now = clock.Now
+	go alog.Start()
+	alog.Info("first")
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond) // the first message is stuck in the writer
+	}
+	alog.Info("second")
+	alog.Warnf("third")
+	clock.advance(2 * time.Second) // the writer is paused while time passes
+	close(gw.open)
+	alog.Stop()
+
+	want := "[12:00:00] [INFO] - first\n[12:00:00] [INFO] - second\n[12:00:00] [WARN] - third\n"
+	if got := gw.b.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestMixedProducers(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBufferSize(100))
+	go alog.Start()
+	var wg sync.WaitGroup
+	for p := 0; p < 9; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				msg := strconv.Itoa(p) + "/" + strconv.Itoa(i)
+				switch p % 3 {
+				case 0:
+					alog.Info(msg)
+				case 1:
+					alog.MessageChannel() <- msg
+				default:
+					alog.EntryChannel() <- Entry{Level: LevelInfo, Message: msg}
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	alog.Stop()
+
+	msgs := writtenMessages(lb)
+	if len(msgs) != 9*200 {
+		t.Fatalf("Expected %d messages, got %d", 9*200, len(msgs))
+	}
+	next := make(map[string]int)
+	for _, msg := range msgs {
+		p, i, _ := strings.Cut(msg, "/")
+		if n, _ := strconv.Atoi(i); n != next[p] {
+			t.Fatalf("Producer %s: expected message %d, got %d", p, next[p], n)
+		}
+		next[p]++
+	}
+}
+
+func TestWriteTakesTurnsWithStart(t *testing.T) {
+	b := bytes.NewBuffer([]byte{}) // not safe for concurrent use, so the race detector catches overlapping writes
+	alog := New(b, WithTimestampFormat(""))
+	go alog.Start()
+	wg := &sync.WaitGroup{}
+	for p := 0; p < 8; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				msg := strconv.Itoa(p) + "/" + strconv.Itoa(i)
+				if p%2 == 0 {
+					alog.MessageChannel() <- msg
+				} else if _, err := alog.Write(msg); err != nil {
+					t.Error(err)
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+	alog.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 8*200 {
+		t.Fatalf("Expected %d lines, got %d", 8*200, len(lines))
+	}
+	for _, line := range lines {
+		p, i, ok := strings.Cut(line, "/")
+		if _, err := strconv.Atoi(i); !ok || err != nil || len(p) != 1 || p[0] < '0' || p[0] > '7' {
+			t.Fatalf("Torn line %q", line)
+		}
+	}
+}
+
+func TestWriteByteCounts(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithLevel(LevelWarn))
+	alog.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Now
+	if n, err := alog.WriteString("below the level"); n != 15 || err != nil {
+		t.Errorf("Expected WriteString to report the message as dealt with, got %d, %v", n, err)
+	}
+	alog.SetLevel(LevelInfo)
+	n, err := alog.Write("hello")
+	if want := len("[2024-05-01 12:00:00] - hello\n"); n != want || err != nil {
+		t.Errorf("Expected Write to return the formatted size %d, got %d, %v", want, n, err)
+	}
+	if n, err := alog.WriteString("hello"); n != 5 || err != nil {
+		t.Errorf("Expected WriteString to return len(s), got %d, %v", n, err)
+	}
+	var _ io.StringWriter = alog
+	if got := b.String(); got != "[2024-05-01 12:00:00] - hello\n[2024-05-01 12:00:00] - hello\n" {
+		t.Errorf("Got %q", got)
+	}
+
+	fw := failingWriter{make(chan struct{})}
+	close(fw.open)
+	alog = New(fw)
+	if n, err := alog.WriteString("fails"); n != 0 || err == nil {
+		t.Errorf("Expected WriteString to fail with 0 bytes, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,27 @@
+// Package alogotel attaches OpenTelemetry trace and span IDs to messages logged through alog's Ctx methods, such as
+// Alog.InfoCtx, so they can be correlated with traces. It's a module of its own so that alog itself doesn't depend
+// on OpenTelemetry.
+package alogotel
+
+import (
+	"alog"
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTraceIDs attaches the trace_id and span_id fields to messages logged with a context that carries a valid span
+// context, see Extract.
+func WithTraceIDs() alog.Option {
+	return alog.WithContextExtractor(Extract)
+}
+
+// Extract returns the trace_id and span_id fields of the span context in ctx, as hex strings, or nil if ctx has no
+// valid span context. It's the extractor WithTraceIDs registers, for using with alog.WithContextExtractor directly.
+func Extract(ctx context.Context) map[string]any {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return map[string]any{"trace_id": sc.TraceID().String(), "span_id": sc.SpanID().String()}
+}
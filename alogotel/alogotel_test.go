@@ -0,0 +1,62 @@
+package alogotel
+
+import (
+	"alog"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanContext() context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{
+			0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36,
+		},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: 1,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestTraceIDs(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		formatter alog.Formatter
+		ids       string
+	}{
+		{"text", alog.TextFormatter{}, "span_id=00f067aa0ba902b7 trace_id=4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"json", alog.JSONFormatter{}, `"span_id":"00f067aa0ba902b7","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+			al := alog.New(b, WithTraceIDs(), alog.WithFormatter(tt.formatter), alog.WithBufferSize(10))
+			go al.Start()
+			al.InfoCtx(spanContext(), "in a span")
+			al.InfoCtx(context.Background(), "outside")
+			al.Stop()
+
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("Expected 2 lines, got %q", lines)
+			}
+			if !strings.Contains(lines[0], tt.ids) {
+				t.Errorf("Expected %s in %q", tt.ids, lines[0])
+			}
+			if strings.Contains(lines[1], "trace_id") || strings.Contains(lines[1], "span_id") {
+				t.Errorf("Expected no IDs outside a span, got %q", lines[1])
+			}
+		})
+	}
+}
+
+func TestExtractWithoutSpan(t *testing.T) {
+	if fields := Extract(context.Background()); fields != nil {
+		t.Errorf("Got %v", fields)
+	}
+	if n := testing.AllocsPerRun(100, func() { Extract(context.Background()) }); n != 0 {
+		t.Errorf("Expected no allocations without a span, got %v", n)
+	}
+}
@@ -0,0 +1,12 @@
+module alog/alogotel
+
+go 1.21
+
+require (
+	alog v0.0.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require go.opentelemetry.io/otel v1.28.0 // indirect
+
+replace alog => ../
@@ -0,0 +1,59 @@
+// Package alogprom provides a prometheus.Collector for the counters of alog loggers, for programs that register
+// their metrics with the Prometheus client library. It's a module of its own so that alog itself doesn't depend on
+// the client library; alog.PrometheusHandler serves the same metrics without it.
+package alogprom
+
+import (
+	"alog"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewCollector returns a prometheus.Collector for loggers, collecting each one's alog.Alog.Metrics with prefix
+// added to their names and the logger's key in the map as a "logger" label, like alog.PrometheusHandler. The map is
+// copied, so loggers added to it later aren't collected.
+func NewCollector(prefix string, loggers map[string]*alog.Alog) prometheus.Collector {
+	c := &collector{descs: map[string]*prometheus.Desc{}}
+	for name, al := range loggers {
+		c.loggers = append(c.loggers, named{name, al})
+	}
+	sort.Slice(c.loggers, func(i, j int) bool {
+		return c.loggers[i].name < c.loggers[j].name
+	})
+	if len(c.loggers) > 0 {
+		// Every logger has the same metrics, so any one of them describes them all.
+		for _, m := range c.loggers[0].al.Metrics() {
+			c.descs[m.Name] = prometheus.NewDesc(prefix+m.Name, m.Help, []string{"logger"}, nil)
+		}
+	}
+	return c
+}
+
+type named struct {
+	name string
+	al   *alog.Alog
+}
+
+type collector struct {
+	loggers []named
+	descs   map[string]*prometheus.Desc // by the names of the metrics, without the prefix
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descs {
+		ch <- d
+	}
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, l := range c.loggers {
+		for _, m := range l.al.Metrics() {
+			t := prometheus.GaugeValue
+			if m.Counter {
+				t = prometheus.CounterValue
+			}
+			ch <- prometheus.MustNewConstMetric(c.descs[m.Name], t, m.Value, l.name)
+		}
+	}
+}
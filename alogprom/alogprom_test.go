@@ -0,0 +1,48 @@
+package alogprom
+
+import (
+	"alog"
+	"bytes"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollector(t *testing.T) {
+	audit, app := alog.New(bytes.NewBuffer(nil)), alog.New(bytes.NewBuffer(nil))
+	audit.Write("one")
+	app.Write("two")
+	app.Write("three")
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(NewCollector("myapp_log_", map[string]*alog.Alog{"audit": audit, "app": app})); err != nil {
+		t.Fatal(err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]map[string]float64{} // by metric, then logger
+	for _, mf := range families {
+		got[mf.GetName()] = map[string]float64{}
+		for _, m := range mf.GetMetric() {
+			v := m.GetGauge().GetValue()
+			if m.Counter != nil {
+				v = m.GetCounter().GetValue()
+			} else if mf.GetName() != "myapp_log_queue_depth" {
+				t.Errorf("Expected %s to be a counter", mf.GetName())
+			}
+			got[mf.GetName()][m.GetLabel()[0].GetValue()] = v
+		}
+	}
+	if len(got) != len(audit.Metrics()) {
+		t.Errorf("Expected every metric, got %v", got)
+	}
+	written := got["myapp_log_messages_written_total"]
+	if written["audit"] != 1 || written["app"] != 2 {
+		t.Errorf("Got messages written %v", written)
+	}
+	if depth, ok := got["myapp_log_queue_depth"]; !ok || len(depth) != 2 || depth["app"] != 0 {
+		t.Errorf("Got queue depth %v", depth)
+	}
+}
@@ -0,0 +1,60 @@
+// Package alogr provides a logr.LogSink backed by an alog.Alog, for code such as Kubernetes controllers that takes a
+// logr.Logger. It's a module of its own so that alog itself doesn't depend on logr.
+package alogr
+
+import (
+	"alog"
+
+	"github.com/go-logr/logr"
+)
+
+// NewLogSink returns a logr.LogSink that logs through al. logr's V-levels are checked against al's verbosity, see
+// alog.Alog.V, and the messages are logged at alog.LevelInfo, with their key/value pairs as fields. Errors are
+// logged at alog.LevelError with the error in an "error" field, without ever blocking: if al's queue is full the
+// message is dropped and counted by al.Dropped, like alog.Alog.TryErrorKV. WithName names the sink's messages with
+// al.Named, so names are joined with dots. Every sink derived from the one NewLogSink returns shares al's queue and
+// destination.
+//
+// WithCaller reports the line in this package that called al, not the caller of the logr.Logger.
+func NewLogSink(al *alog.Alog) logr.LogSink {
+	return &sink{al: al}
+}
+
+// New returns a logr.Logger that logs through al, see NewLogSink.
+func New(al *alog.Alog) logr.Logger {
+	return logr.New(NewLogSink(al))
+}
+
+type sink struct {
+	al     *alog.Alog
+	values []any // added with WithValues, before every message's own
+}
+
+func (s *sink) Init(logr.RuntimeInfo) {}
+
+func (s *sink) Enabled(level int) bool {
+	return s.al.V(level).Enabled()
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...any) {
+	_ = s.al.V(level).InfoKV(msg, s.withValues(keysAndValues)...)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...any) {
+	_ = s.al.TryErrorKV(msg, append(s.withValues(keysAndValues), "error", err)...)
+}
+
+func (s *sink) WithValues(keysAndValues ...any) logr.LogSink {
+	return &sink{al: s.al, values: s.withValues(keysAndValues)}
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	return &sink{al: s.al.Named(name), values: s.values}
+}
+
+// withValues returns the sink's values followed by keysAndValues, in a slice of their own.
+func (s *sink) withValues(keysAndValues []any) []any {
+	kv := make([]any, 0, len(s.values)+len(keysAndValues)+2) // room for Error's "error" field
+	kv = append(kv, s.values...)
+	return append(kv, keysAndValues...)
+}
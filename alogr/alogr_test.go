@@ -0,0 +1,57 @@
+package alogr
+
+import (
+	"alog"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestLogSink(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	al := alog.New(b, alog.WithVerbosity(1), alog.WithBufferSize(10), alog.WithTimestampFormat(""))
+	go al.Start()
+	log := New(al)
+	log.Info("reconciling", "pod", "web-0")
+	log.V(1).Info("details", "attempt", 2)
+	log.V(2).Info("hidden")
+	child := log.WithName("controller").WithValues("namespace", "prod")
+	child.Error(errors.New("conflict"), "update failed", "pod", "web-1")
+	child.WithName("status").Info("done")
+	log.Info("unchanged by the child")
+	al.Stop()
+
+	want := []string{
+		"[INFO] - reconciling pod=web-0",
+		"[INFO] - details attempt=2",
+		"[ERROR] [controller] - update failed namespace=prod pod=web-1 error=conflict",
+		"[INFO] [controller.status] - done namespace=prod",
+		"[INFO] - unchanged by the child",
+	}
+	if got := strings.TrimSuffix(b.String(), "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("Got\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestLogSinkEnabled(t *testing.T) {
+	al := alog.New(bytes.NewBuffer(nil), alog.WithVerbosity(2))
+	var s logr.LogSink = NewLogSink(al)
+	if !s.Enabled(0) || !s.Enabled(2) || s.Enabled(3) {
+		t.Error("Expected V-levels up to the verbosity to be enabled")
+	}
+	al.SetVerbosity(3)
+	if !s.Enabled(3) {
+		t.Error("Expected a raised verbosity to enable V(3)")
+	}
+}
+
+func TestErrorDoesNotBlock(t *testing.T) {
+	al := alog.New(bytes.NewBuffer(nil)) // unbuffered and never started, so nothing can be queued
+	New(al).Error(errors.New("boom"), "lost")
+	if n := al.Dropped(); n != 1 {
+		t.Errorf("Expected the error to be dropped, got %d dropped", n)
+	}
+}
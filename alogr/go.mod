@@ -0,0 +1,10 @@
+module alog/alogr
+
+go 1.21
+
+require (
+	alog v0.0.0
+	github.com/go-logr/logr v1.4.2
+)
+
+replace alog => ../
@@ -0,0 +1,133 @@
+// Package alogtest helps testing code that logs through alog. A Recorder keeps every message a logger writes, both
+// as the entry and as the line the logger's formatter renders it as, and lets tests wait for a message instead of
+// sleeping until the asynchronous logger has caught up.
+package alogtest
+
+import (
+	"alog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is a message kept by a Recorder.
+type Record struct {
+	alog.Entry
+	Line string // the message as formatted by the logger, including the trailing newline
+}
+
+// Recorder keeps the messages written by the logger NewRecorder returns. Its methods are safe to call while the
+// logger is writing.
+type Recorder struct {
+	formatter alog.Formatter
+
+	m       sync.Mutex
+	records []Record
+	changed chan struct{} // closed and replaced whenever a message is kept, guarded by m
+}
+
+// NewRecorder returns a started logger configured with opts that writes to a Recorder, and the Recorder. Stop the
+// logger when the test is done with it.
+func NewRecorder(opts ...alog.Option) (*alog.Alog, *Recorder) {
+	r := &Recorder{changed: make(chan struct{})}
+	al := alog.New(r, opts...)
+	r.formatter = al.Formatter()
+	go al.Start()
+	return al, r
+}
+
+// WriteEntry keeps a copy of e, see alog.EntryWriter.
+func (r *Recorder) WriteEntry(e *alog.Entry) error {
+	rec := Record{Entry: *e, Line: string(r.formatter.Format(nil, e))}
+	rec.Fields = append([]alog.Field(nil), e.Fields...)
+	r.keep(rec)
+	return nil
+}
+
+// Write keeps p as a message of its own, with p as both its Line and Message. The logger doesn't call it, since it
+// gives the Recorder entries, but it makes the Recorder an io.Writer for alog.New.
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.keep(Record{Entry: alog.Entry{Message: string(p)}, Line: string(p)})
+	return len(p), nil
+}
+
+func (r *Recorder) keep(rec Record) {
+	r.m.Lock()
+	r.records = append(r.records, rec)
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.m.Unlock()
+}
+
+// Records returns the messages written so far, oldest first.
+func (r *Recorder) Records() []Record {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return append([]Record(nil), r.records...)
+}
+
+// Entries returns the entries of the messages written so far, oldest first.
+func (r *Recorder) Entries() []alog.Entry {
+	r.m.Lock()
+	defer r.m.Unlock()
+	entries := make([]alog.Entry, len(r.records))
+	for i, rec := range r.records {
+		entries[i] = rec.Entry
+	}
+	return entries
+}
+
+// Lines returns the formatted messages written so far, oldest first.
+func (r *Recorder) Lines() []string {
+	r.m.Lock()
+	defer r.m.Unlock()
+	lines := make([]string, len(r.records))
+	for i, rec := range r.records {
+		lines[i] = rec.Line
+	}
+	return lines
+}
+
+// Len returns the number of messages written so far.
+func (r *Recorder) Len() int {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return len(r.records)
+}
+
+// Contains reports whether a formatted message written so far contains substr.
+func (r *Recorder) Contains(substr string) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for _, rec := range r.records {
+		if strings.Contains(rec.Line, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitFor waits until a message for which match returns true has been written, or timeout has passed, and returns
+// the first such message. Messages written before WaitFor was called count too. It returns false if the timeout
+// passed first.
+func (r *Recorder) WaitFor(match func(rec Record) bool, timeout time.Duration) (Record, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	seen := 0
+	for {
+		r.m.Lock()
+		records, changed := r.records[seen:], r.changed
+		seen = len(r.records)
+		r.m.Unlock()
+		for _, rec := range records {
+			if match(rec) {
+				return rec, true
+			}
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return Record{}, false
+		}
+	}
+}
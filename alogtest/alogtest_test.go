@@ -0,0 +1,102 @@
+package alogtest
+
+import (
+	"alog"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// worker stands in for a component under test that logs from a goroutine of its own.
+type worker struct {
+	log  *alog.Alog
+	jobs chan int
+}
+
+func (w *worker) run() {
+	for job := range w.jobs {
+		w.log.InfoKV("job done", "job", job)
+	}
+}
+
+func ExampleRecorder_WaitFor() {
+	log, rec := NewRecorder(alog.WithTimestampFormat(""))
+	defer log.Stop()
+	w := &worker{log: log, jobs: make(chan int)}
+	go w.run()
+	w.jobs <- 7
+	close(w.jobs)
+
+	r, ok := rec.WaitFor(func(r Record) bool { return r.Message == "job done" }, time.Second)
+	fmt.Println(ok, r.Fields[0].Value)
+	fmt.Print(r.Line)
+	// Output:
+	// true 7
+	// [INFO] - job done job=7
+}
+
+func ExampleRecorder_Contains() {
+	log, rec := NewRecorder(alog.WithTimestampFormat(""))
+	log.Warn("disk almost full")
+	log.Stop() // everything logged has been written once Stop returns
+	fmt.Println(rec.Len(), rec.Contains("[WARN] - disk almost full"))
+	// Output: 1 true
+}
+
+func TestRecorder(t *testing.T) {
+	log, rec := NewRecorder(alog.WithJSONFormat(), alog.WithBufferSize(10))
+	log.Named("db").ErrorKV("query failed", "table", "users")
+	log.Debug("filtered")
+	log.Stop()
+
+	entries := rec.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Level != alog.LevelError || e.Name != "db" || len(e.Fields) != 1 || e.Fields[0].Value != "users" {
+		t.Errorf("Got %+v", e)
+	}
+	if lines := rec.Lines(); !rec.Contains(`"msg":"query failed"`) || lines[0][len(lines[0])-1] != '\n' {
+		t.Errorf("Got %q", lines)
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	log, rec := NewRecorder()
+	defer log.Stop()
+	log.Info("other")
+	start := time.Now()
+	if _, ok := rec.WaitFor(func(r Record) bool { return r.Message == "missing" }, 20*time.Millisecond); ok {
+		t.Error("WaitFor matched a message that wasn't logged")
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("WaitFor gave up after %v", elapsed)
+	}
+}
+
+func TestRecorderConcurrent(t *testing.T) {
+	log, rec := NewRecorder(alog.WithBufferSize(100))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				log.Info("m" + strconv.Itoa(j))
+			}
+		}()
+	}
+	if _, ok := rec.WaitFor(func(r Record) bool { return r.Message == "m49" }, time.Second); !ok {
+		t.Error("WaitFor timed out")
+	}
+	rec.Contains("m0")
+	rec.Records()
+	wg.Wait()
+	log.Stop()
+	if n := rec.Len(); n != 200 {
+		t.Errorf("Expected 200 messages, got %d", n)
+	}
+}
@@ -0,0 +1,141 @@
+package alog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// defaultBatchBytes is the size WithBatching uses for batches when it isn't given one. It matches the largest
+// pooled buffer so that full batches are formatted into reusable memory.
+const defaultBatchBytes = maxPooledBufferSize
+
+// writeBatch formats e and the messages queued behind it, up to the WithBatching limits, into one buffer and writes
+// it to dest in a single call. A Flush that's found in the queue ends the batch and is handled once the batch has
+// been written, so it still covers every message logged before it.
+func (al *Alog) writeBatch(e Entry, wg *sync.WaitGroup) {
+	fb := getFormatBuffer()
+	defer putFormatBuffer(fb)
+	fb.e = e
+	sinkErrs, n := al.addToBatch(fb, nil, 0)
+	var flush *Entry
+collect:
+	for n < al.batchMessages && len(fb.buf) < al.batchBytes {
+		if al.ring != nil {
+			if e, ok := al.ring.pop(); ok {
+				if al.maxAge > 0 && al.expire(&e) {
+					continue
+				}
+				fb.e = e
+				sinkErrs, n = al.addToBatch(fb, sinkErrs, n)
+				continue
+			}
+		}
+		select {
+		case e := <-al.entryCh:
+			if e.flushed != nil {
+				flush = &e
+				break collect
+			}
+			if al.maxAge > 0 && al.expire(&e) {
+				continue
+			}
+			fb.e = e
+		case msg := <-al.msgCh:
+			fb.e = Entry{Level: LevelInfo, Message: msg, implicit: true}
+			if !al.accept(&fb.e) {
+				continue
+			}
+		case e := <-al.sentCh:
+			fb.e = e
+			if !al.accept(&fb.e) {
+				continue
+			}
+		default:
+			break collect
+		}
+		sinkErrs, n = al.addToBatch(fb, sinkErrs, n)
+	}
+	if n == 0 { // everything was dropped
+		atomic.AddInt32(&al.batching, -int32(fb.taken))
+		al.consumed(fb.walEnds...)
+		if len(sinkErrs) > 0 {
+			al.reportError(joinErrors(sinkErrs))
+		}
+		if flush != nil {
+			al.handleEntry(*flush, wg)
+		}
+		return
+	}
+
+	al.m.Lock()
+	atomic.AddInt32(&al.busy, int32(n))
+	atomic.AddInt32(&al.batching, -int32(fb.taken))
+	written, err := al.writeFormatted(fb)
+	if len(sinkErrs) > 0 {
+		err = joinErrors(append(sinkErrs, err))
+	}
+	if err != nil && al.deadLetters != nil {
+		al.deadLetter(fb, err)
+	}
+	if len(al.afterWrite) > 0 {
+		al.runAfterWrite(fb.buf, written, err)
+	}
+	atomic.AddInt32(&al.busy, -int32(n))
+	al.m.Unlock()
+	switch {
+	case err == nil:
+		al.countWritten(n, written)
+		al.consumed(fb.walEnds...)
+	case err == ErrCircuitOpen:
+		atomic.AddUint64(&al.dropped, uint64(n))
+	case n == 1:
+		al.countFailed(1, err)
+		al.reportError(newWriteError(fb.firstMsg, fb.firstTime, fb.buf, err))
+	default:
+		al.countFailed(n, err)
+		al.reportError(&BatchError{Messages: n, Err: err})
+	}
+	if flush != nil {
+		al.handleEntry(*flush, wg)
+	}
+}
+
+// addToBatch formats the entry held by fb into the batch and writes it to the sinks, which get entries one by one.
+// It returns errs with the sinks' and the middlewares' errors added and n, the number of messages in the batch,
+// counting this one unless a BeforeWrite hook or a middleware dropped it.
+func (al *Alog) addToBatch(fb *formatBuffer, errs []error, n int) ([]error, int) {
+	var err error
+	atomic.AddInt32(&al.batching, 1)
+	fb.taken++
+	if al.wal != nil {
+		fb.walEnds = append(fb.walEnds, fb.e.walEnd)
+	}
+	if n == 0 {
+		defer func() {
+			if n > 0 {
+				fb.firstMsg, fb.firstTime = fb.e.Message, fb.e.Time
+			}
+		}()
+	}
+	if h := al.handler(); h != nil {
+		if !al.prepare(&fb.e) {
+			return errs, n
+		}
+		fb.batch = true
+		var handled int
+		handled, err = al.handle(h, fb)
+		n += handled
+	} else {
+		if !al.formatMessage(fb) {
+			return errs, n
+		}
+		if len(al.sinks) > 0 {
+			err = al.writeSinks(&fb.e)
+		}
+		n++
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return errs, n
+}
@@ -0,0 +1,158 @@
+package alog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// callCounter counts the calls to Write and passes them on to w.
+type callCounter struct {
+	w     io.Writer
+	calls int64
+}
+
+func (cc *callCounter) Write(data []byte) (int, error) {
+	atomic.AddInt64(&cc.calls, 1)
+	return cc.w.Write(data)
+}
+
+// queueBehind logs "m0", waits until the Start loop is stuck writing it and then logs m1 to mn.
+func queueBehind(t *testing.T, alog *Alog, n int) {
+	t.Helper()
+	alog.Info("m0")
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 1; i <= n; i++ {
+		if err := alog.Info("m" + strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBatching(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	cc := &callCounter{w: gw}
+	alog := New(cc, WithBufferSize(100), WithBatching(50, 0))
+	go alog.Start()
+	queueBehind(t, alog, 100)
+	close(gw.open)
+	alog.Stop()
+
+	if calls := atomic.LoadInt64(&cc.calls); calls != 3 {
+		t.Errorf("Expected m0 and two batches of 50, got %d writes", calls)
+	}
+	msgs := writtenMessages(gw.b)
+	if len(msgs) != 101 {
+		t.Fatalf("Expected 101 messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if want := "m" + strconv.Itoa(i); msg != want {
+			t.Fatalf("Message %d is %q, want %q", i, msg, want)
+		}
+	}
+	if atomic.LoadUint64(&alog.written) != 101 {
+		t.Errorf("Expected 101 messages written, got %d", atomic.LoadUint64(&alog.written))
+	}
+}
+
+func TestBatchingByteLimit(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	cc := &callCounter{w: gw}
+	alog := New(cc, WithBufferSize(10), WithBatching(50, 1))
+	go alog.Start()
+	queueBehind(t, alog, 10)
+	close(gw.open)
+	alog.Stop()
+
+	if calls := atomic.LoadInt64(&cc.calls); calls != 11 {
+		t.Errorf("Expected a write per message, got %d writes for 11", calls)
+	}
+}
+
+// failingWriter blocks every Write until open is closed and then fails it.
+type failingWriter struct {
+	open chan struct{}
+}
+
+func (fw failingWriter) Write(data []byte) (int, error) {
+	<-fw.open
+	return 0, errors.New("disk full")
+}
+
+func TestBatchingError(t *testing.T) {
+	fw := failingWriter{make(chan struct{})}
+	alog := New(fw, WithBufferSize(10), WithBatching(50, 0))
+	go alog.Start()
+	queueBehind(t, alog, 5)
+	close(fw.open)
+	alog.Stop()
+
+	if err := <-alog.ErrorChannel(); err.Error() != "disk full" {
+		t.Errorf("Expected m0's own error, got %v", err)
+	}
+	var be *BatchError
+	if err := <-alog.ErrorChannel(); !errors.As(err, &be) || be.Messages != 5 || be.Err.Error() != "disk full" {
+		t.Errorf("Expected a BatchError for 5 messages, got %v", err)
+	}
+	select {
+	case err := <-alog.ErrorChannel():
+		t.Errorf("Expected one error per write, also got %v", err)
+	default:
+	}
+	if atomic.LoadUint64(&alog.written) != 0 {
+		t.Errorf("Expected no messages written, got %d", atomic.LoadUint64(&alog.written))
+	}
+}
+
+func TestBatchingFlush(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithBufferSize(10), WithBatching(50, 0))
+	go alog.Start()
+	defer alog.Stop()
+	queueBehind(t, alog, 3)
+	flushed := make(chan error)
+	go func() {
+		flushed <- alog.Flush(context.Background())
+	}()
+	for len(alog.entryCh) < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	alog.Info("after flush")
+	close(gw.open)
+	if err := <-flushed; err != nil {
+		t.Fatal(err)
+	}
+	msgs := writtenMessages(gw.b)
+	if len(msgs) < 4 || msgs[3] != "m3" {
+		t.Errorf("Flush returned before m0 to m3 were written, got %q", msgs)
+	}
+}
+
+func BenchmarkBatching(b *testing.B) {
+	for _, batch := range []int{0, 64} {
+		b.Run("batch="+strconv.Itoa(batch), func(b *testing.B) {
+			f, err := os.Create(filepath.Join(b.TempDir(), "log"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer f.Close()
+			cc := &callCounter{w: f}
+			alog := New(cc, WithBufferSize(1000), WithBatching(batch, 0))
+			go alog.Start()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				alog.Info("message")
+			}
+			alog.Stop()
+			b.ReportMetric(float64(atomic.LoadInt64(&cc.calls))/float64(b.N), "writes/op")
+		})
+	}
+}
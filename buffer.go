@@ -0,0 +1,53 @@
+package alog
+
+import (
+	"sync"
+	"time"
+)
+
+// maxPooledBufferSize is the largest formatting buffer that's returned to the pool. Buffers that grew past it for
+// an unusually long message are left to the garbage collector so they don't pin memory.
+const maxPooledBufferSize = 16 << 10
+
+// formatBuffer holds an entry and the buffer it's formatted into. Both are pooled: the entry because formatters get
+// a pointer to it, which would otherwise move every entry to the heap, and the buffer because destinations must not
+// retain the slices passed to Write, so it can be reused as soon as Write returns.
+type formatBuffer struct {
+	e     Entry
+	buf   []byte
+	spans []span // the messages in buf, if there are level writers or dead letters
+
+	// Used by the end of the middleware chain, see writeHandled.
+	batch   bool // the entry is being added to a batch, not written
+	handled int  // times an entry reached the end of the chain
+	n       int  // bytes written
+
+	// The first message of a batch, for the WriteError if the batch fails with only that message in it.
+	firstMsg  string
+	firstTime time.Time
+	taken     int     // messages taken off the queue for the batch, counted by batching until it's written
+	walEnds   []int64 // the records of the entries in the batch, if WithPersistence is used
+}
+
+var formatBuffers = sync.Pool{
+	New: func() any {
+		return &formatBuffer{buf: make([]byte, 0, 256)}
+	},
+}
+
+func getFormatBuffer() *formatBuffer {
+	return formatBuffers.Get().(*formatBuffer)
+}
+
+func putFormatBuffer(fb *formatBuffer) {
+	if cap(fb.buf) > maxPooledBufferSize {
+		return
+	}
+	fb.e = Entry{} // don't keep the message and its fields alive
+	fb.buf = fb.buf[:0]
+	fb.spans = fb.spans[:0]
+	fb.batch, fb.handled, fb.n = false, 0, 0
+	fb.firstMsg, fb.taken = "", 0
+	fb.walEnds = fb.walEnds[:0]
+	formatBuffers.Put(fb)
+}
@@ -0,0 +1,51 @@
+package alog
+
+import (
+	"bufio"
+	"io"
+)
+
+// bufferedWriter is the buffer set up by WithBuffering. Unlike a plain *bufio.Writer it recovers from errors: the
+// buffered data that failed to be written is dropped and later writes try the destination again.
+type bufferedWriter struct {
+	*bufio.Writer
+	dest io.Writer
+}
+
+func newBufferedWriter(dest io.Writer, size int) *bufferedWriter {
+	return &bufferedWriter{Writer: bufio.NewWriterSize(dest, size), dest: dest}
+}
+
+func (bw *bufferedWriter) Write(data []byte) (int, error) {
+	n, err := bw.Writer.Write(data)
+	if err != nil {
+		bw.Reset(bw.dest)
+	}
+	return n, err
+}
+
+// Flush writes the buffered data to the destination and flushes that too if it has a Flush method.
+func (bw *bufferedWriter) Flush() error {
+	err := bw.Writer.Flush()
+	if err != nil {
+		bw.Reset(bw.dest)
+		return err
+	}
+	if f, ok := bw.dest.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// flushBuffer flushes WithBuffering's buffer, if there is one, and sends the error on the error channel.
+func (al *Alog) flushBuffer() {
+	if al.buffer == nil {
+		return
+	}
+	al.m.Lock()
+	err := al.buffer.Flush()
+	al.m.Unlock()
+	if err != nil {
+		al.reportError(err)
+	}
+}
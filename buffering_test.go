@@ -0,0 +1,147 @@
+package alog
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitWritten waits until n messages have been written, which with WithBuffering means buffered.
+func waitWritten(t *testing.T, alog *Alog, n uint64) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); atomic.LoadUint64(&alog.written) < n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Only %d of %d messages were written", atomic.LoadUint64(&alog.written), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBufferingFlush(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBuffering(4096, time.Hour))
+	go alog.Start()
+	defer alog.Stop()
+	alog.Info("buffered")
+	waitWritten(t, alog, 1)
+	if lb.String() != "" {
+		t.Fatalf("Expected the message to be buffered, got %q", lb.String())
+	}
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(lb.String(), "- buffered\n") {
+		t.Errorf("Flush didn't write the buffer, got %q", lb.String())
+	}
+}
+
+func TestBufferingFlushInterval(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBuffering(4096, 10*time.Millisecond))
+	go alog.Start()
+	defer alog.Stop()
+	alog.Info("buffered")
+	for deadline := time.Now().Add(time.Second); lb.String() == ""; {
+		if time.Now().After(deadline) {
+			t.Fatal("The buffer wasn't flushed on the interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBufferingStop(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBuffering(4096, time.Hour), WithBufferSize(100), WithLatePolicy(LateWriteSync))
+	go alog.Start()
+	for i := 0; i < 100; i++ {
+		alog.Info("m" + strconv.Itoa(i))
+	}
+	alog.Stop()
+	if msgs := writtenMessages(lb); len(msgs) != 100 || msgs[99] != "m99" {
+		t.Fatalf("Stop lost buffered messages, got %d", len(msgs))
+	}
+	if _, err := alog.Write("late"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(lb.String(), "- late\n") {
+		t.Errorf("Late write was left in the buffer, got %q", lb.String())
+	}
+}
+
+// flakyWriter fails every Write while fail is set.
+type flakyWriter struct {
+	fail int32
+	b    *lockedBuffer
+}
+
+func (fw *flakyWriter) Write(data []byte) (int, error) {
+	if atomic.LoadInt32(&fw.fail) != 0 {
+		return 0, errors.New("disk full")
+	}
+	return fw.b.Write(data)
+}
+
+func TestBufferingFlushError(t *testing.T) {
+	fw := &flakyWriter{fail: 1, b: &lockedBuffer{}}
+	alog := New(fw, WithBuffering(4096, 10*time.Millisecond))
+	go alog.Start()
+	defer alog.Stop()
+	alog.Info("lost")
+	select {
+	case err := <-alog.ErrorChannel():
+		if err.Error() != "disk full" {
+			t.Errorf("Expected the destination's error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Flush error wasn't sent on the error channel")
+	}
+
+	atomic.StoreInt32(&fw.fail, 0)
+	alog.Info("kept")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatalf("The buffer didn't recover from the error: %v", err)
+	}
+	if got := fw.b.String(); strings.Contains(got, "lost") || !strings.HasSuffix(got, "- kept\n") {
+		t.Errorf("Expected only the message after the error, got %q", got)
+	}
+}
+
+func TestBufferingWriteDoesNotInterleave(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBuffering(64, time.Millisecond), WithBufferSize(100))
+	go alog.Start()
+	msg := strings.Repeat("x", 100)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				alog.Info(msg)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				alog.Write(msg)
+			}
+		}()
+	}
+	wg.Wait()
+	alog.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(lb.String(), "\n"), "\n")
+	if len(lines) != 400 {
+		t.Fatalf("Expected 400 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, "- "+msg) {
+			t.Fatalf("Line was interleaved with another: %q", line)
+		}
+	}
+}
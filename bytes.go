@@ -0,0 +1,96 @@
+package alog
+
+import "sync"
+
+// messageBuffers holds the copies WriteBytes and LogBytes make of their messages. Buffers that grew past
+// maxPooledBufferSize are left to the garbage collector, like formatting buffers.
+var messageBuffers = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
+// WriteBytes writes msg synchronously like Write, without converting it to a string. msg is copied once, so the
+// caller can reuse it as soon as WriteBytes returns. The copy is made into a pooled buffer that the built-in
+// formatters format it from, and that's reused once the message has been written. If the logger uses an option that
+// passes entries to other code, such as WithFilter, WithBeforeWrite, Use, a Sink, an EntryWriter, a formatter of its
+// own, WithDeadLetters or WithRepeatSuppression, msg is copied to a string instead, for the Entry's Message.
+func (al *Alog) WriteBytes(msg []byte) (int, error) {
+	if !al.enabled(LevelInfo) {
+		return 0, nil
+	}
+	if al.refusesSync() {
+		return 0, ErrLoggerStopped
+	}
+	e := al.newEntry(LevelInfo, "")
+	al.copyMessage(&e, msg)
+	e.Caller = al.caller(1)
+	return al.writeSyncEntry(e)
+}
+
+// LogBytes queues msg to be written at level l like the level methods, such as Info, without converting it to a
+// string. msg is copied once, as WriteBytes describes, so the caller can reuse it as soon as LogBytes returns even
+// though the message is written later.
+func (al *Alog) LogBytes(l Level, msg []byte) error {
+	if !al.enabled(l) || al.sampler.sampleOut(l) {
+		return nil
+	}
+	e := al.newEntry(l, "")
+	al.copyMessage(&e, msg)
+	e.Caller = al.caller(1)
+	e.Stack = al.stack(l)
+	return al.enqueue(e)
+}
+
+// copyMessage copies msg to e, into a pooled buffer, or into its Message if passesEntries says other code is going
+// to read it.
+func (al *Alog) copyMessage(e *Entry, msg []byte) {
+	if len(msg) == 0 {
+		return
+	}
+	if al.passesEntries() {
+		e.Message = string(msg)
+		return
+	}
+	buf := messageBuffers.Get().(*[]byte)
+	*buf = append((*buf)[:0], msg...)
+	e.msg = buf
+}
+
+// passesEntries reports whether the logger uses an option that passes entries to code that reads their Message, or
+// keeps them after they've been written. A formatter of the logger's own and a middleware added with Use later on
+// are dealt with as the message is written, see materialize.
+func (al *Alog) passesEntries() bool {
+	return len(al.filters) > 0 || len(al.beforeWrite) > 0 || al.handler() != nil || al.entryDest != nil ||
+		len(al.sinks) > 0 || al.deadLetters != nil || al.repeats != nil || al.batchMessages > 1 ||
+		al.maxMessageSize > 0 || len(al.redactors) > 0
+}
+
+// text returns e's message as a string, copying it if it's in a pooled buffer.
+func (e *Entry) text() string {
+	if e.msg != nil {
+		return string(*e.msg)
+	}
+	return e.Message
+}
+
+// materialize copies e's message from its pooled buffer to Message, for code that only knows about Message. The
+// buffer is still released by whoever writes the entry e was copied from, see releaseMessage.
+func (e *Entry) materialize() {
+	if e.msg != nil {
+		e.Message, e.msg = string(*e.msg), nil
+	}
+}
+
+// releaseMessage returns the buffer holding e's message, if it's in one, to the pool. It must only be called once
+// e has been written, by the code that wrote it.
+func releaseMessage(e *Entry) {
+	if e.msg == nil {
+		return
+	}
+	if cap(*e.msg) <= maxPooledBufferSize {
+		messageBuffers.Put(e.msg)
+	}
+	e.msg = nil
+}
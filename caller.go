@@ -0,0 +1,20 @@
+package alog
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// caller returns the file name and line of the code depth frames above the function calling caller, plus the skip
+// set with WithCaller. It returns "" without walking the stack if WithCaller wasn't used.
+func (al *Alog) caller(depth int) string {
+	if !al.reportCaller {
+		return ""
+	}
+	_, file, line, ok := runtime.Caller(depth + 1 + al.callerSkip)
+	if !ok {
+		return "???:0"
+	}
+	return filepath.Base(file) + ":" + strconv.Itoa(line)
+}
@@ -0,0 +1,82 @@
+package alog
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// here returns "caller_test.go:N", where N is the line after the call to here.
+func here() string {
+	_, _, line, _ := runtime.Caller(1)
+	return "caller_test.go:" + strconv.Itoa(line+1)
+}
+
+func TestCaller(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithCaller(0), WithLatePolicy(LateWriteSync))
+	go alog.Start()
+	var want []string
+	want = append(want, here())
+	alog.Info("info")
+	want = append(want, here())
+	alog.Warnf("warn %d", 1)
+	want = append(want, here())
+	alog.WithFields(map[string]any{"k": "v"}).ErrorKV("error", "n", 1)
+	want = append(want, here())
+	alog.ErrorNow("error now")
+	want = append(want, here())
+	alog.LogBytes(LevelWarn, []byte("log bytes"))
+	alog.Stop()
+	want = append(want, here())
+	alog.Write("write")
+	want = append(want, here())
+	alog.Writef("writef %d", 1)
+	want = append(want, here())
+	alog.WriteBytes([]byte("write bytes"))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d lines, got %q", len(want), b.String())
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, "] "+want[i]+" - ") {
+			t.Errorf("Got %q, want caller %s", line, want[i])
+		}
+	}
+}
+
+func logThroughWrappers(al *Alog, depth int) {
+	if depth > 1 {
+		logThroughWrappers(al, depth-1)
+		return
+	}
+	al.Info("wrapped")
+}
+
+func TestCallerSkip(t *testing.T) {
+	for _, depth := range []int{1, 3} {
+		b := bytes.NewBuffer([]byte{})
+		alog := New(b, WithCaller(depth))
+		go alog.Start()
+		want := here()
+		logThroughWrappers(alog, depth)
+		alog.Stop()
+		if !strings.Contains(b.String(), "] "+want+" - wrapped") {
+			t.Errorf("Depth %d: got %q, want caller %s", depth, b.String(), want)
+		}
+	}
+}
+
+func TestCallerOff(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat())
+	if _, err := alog.Write("test"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "caller") {
+		t.Errorf("Caller reported without WithCaller: %q", b.String())
+	}
+}
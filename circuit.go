@@ -0,0 +1,180 @@
+package alog
+
+import (
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker set up with WithCircuitBreaker.
+type CircuitState int32
+
+const (
+	// CircuitClosed is the normal state: messages are written to the destination.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means the destination failed too often. Messages aren't written to it until the cooldown ends.
+	CircuitOpen
+	// CircuitHalfOpen means the cooldown has ended and a single message is being written to the destination to see
+	// whether it has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "CircuitState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// CircuitStats describes the circuit breaker, see Alog.CircuitStats.
+type CircuitStats struct {
+	State               CircuitState
+	ConsecutiveFailures int    // failed writes since the last one that worked
+	LastError           error  // the error of the last failed write, nil if there hasn't been one
+	Rejected            uint64 // messages that weren't written to the destination because the circuit was open
+}
+
+// WithCircuitBreaker stops writing to the writer passed to New after failures consecutive writes have failed, after
+// any retries, see WithRetry. The circuit is then open for cooldown: messages go to the fallback writer, see
+// WithFallbackWriter, or are dropped and counted by Dropped, without an error for each. The first message after the
+// cooldown is written to the destination as a probe. If that works the circuit closes again, otherwise it's open
+// for another cooldown. The other destinations, such as those added with WithAdditionalWriter, aren't affected, and
+// a message they get isn't counted as dropped, only as rejected by CircuitStats. Write returns ErrCircuitOpen for
+// the messages it can't write anywhere.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(al *Alog) {
+		al.breakerFailures = failures
+		al.breakerCooldown = cooldown
+	}
+}
+
+// WithCircuitStateFunc calls fn whenever the circuit breaker set up with WithCircuitBreaker changes state. fn is
+// called on the goroutine that writes the message that caused the change, so it must be fast.
+func WithCircuitStateFunc(fn func(from, to CircuitState)) Option {
+	return func(al *Alog) {
+		al.breakerOnChange = fn
+	}
+}
+
+// CircuitStats returns the state of the circuit breaker set up with WithCircuitBreaker. An open circuit only
+// becomes half-open when the next message is written, so State stays CircuitOpen after the cooldown until then.
+// A logger without a circuit breaker reports a closed circuit.
+func (al *Alog) CircuitStats() CircuitStats {
+	if al.breaker == nil {
+		return CircuitStats{}
+	}
+	return al.breaker.stats()
+}
+
+// breakerWriter is the writer passed to New, or the EntryWriter in its place, when WithCircuitBreaker is used.
+type breakerWriter struct {
+	w        io.Writer
+	ew       EntryWriter
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+	onChange func(from, to CircuitState)
+
+	m           sync.Mutex // workers may write concurrently
+	state       CircuitState
+	consecutive int
+	lastErr     error
+	opened      time.Time // when the circuit last opened
+	rejected    uint64
+}
+
+func (al *Alog) newBreakerWriter() *breakerWriter {
+	return &breakerWriter{
+		failures: al.breakerFailures,
+		cooldown: al.breakerCooldown,
+		now:      func() time.Time { return al.now() },
+		onChange: al.breakerOnChange,
+	}
+}
+
+func (bw *breakerWriter) Write(p []byte) (int, error) {
+	if !bw.allow() {
+		return 0, ErrCircuitOpen
+	}
+	n, err := bw.w.Write(p)
+	bw.done(err)
+	return n, err
+}
+
+func (bw *breakerWriter) WriteEntry(e *Entry) error {
+	if !bw.allow() {
+		return ErrCircuitOpen
+	}
+	err := bw.ew.WriteEntry(e)
+	bw.done(err)
+	return err
+}
+
+// Flush flushes the writer if it buffers writes, see flusher.
+func (bw *breakerWriter) Flush() error {
+	if f, ok := bw.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// allow reports whether the next message should be written to the destination, making it the probe if the
+// cooldown has ended.
+func (bw *breakerWriter) allow() bool {
+	bw.m.Lock()
+	switch {
+	case bw.state == CircuitClosed:
+		bw.m.Unlock()
+		return true
+	case bw.state == CircuitOpen && bw.now().Sub(bw.opened) >= bw.cooldown:
+		bw.setState(CircuitHalfOpen)
+		return true
+	}
+	bw.rejected++ // open, or half-open with the probe still being written
+	bw.m.Unlock()
+	return false
+}
+
+// done records the outcome of a write that allow let through.
+func (bw *breakerWriter) done(err error) {
+	bw.m.Lock()
+	if err == nil {
+		bw.consecutive = 0
+		if bw.state != CircuitClosed {
+			bw.setState(CircuitClosed)
+			return
+		}
+		bw.m.Unlock()
+		return
+	}
+	bw.consecutive++
+	bw.lastErr = err
+	if bw.state == CircuitHalfOpen || bw.state == CircuitClosed && bw.consecutive >= bw.failures {
+		bw.opened = bw.now()
+		bw.setState(CircuitOpen)
+		return
+	}
+	bw.m.Unlock()
+}
+
+// setState changes the state and unlocks bw.m before calling onChange.
+func (bw *breakerWriter) setState(to CircuitState) {
+	from := bw.state
+	bw.state = to
+	bw.m.Unlock()
+	if bw.onChange != nil {
+		bw.onChange(from, to)
+	}
+}
+
+func (bw *breakerWriter) stats() CircuitStats {
+	bw.m.Lock()
+	defer bw.m.Unlock()
+	return CircuitStats{State: bw.state, ConsecutiveFailures: bw.consecutive, LastError: bw.lastErr, Rejected: bw.rejected}
+}
@@ -0,0 +1,147 @@
+package alog
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// switchWriter fails its writes while failing is set.
+type switchWriter struct {
+	m       sync.Mutex
+	failing bool
+	b       *lockedBuffer
+}
+
+func (sw *switchWriter) set(failing bool) {
+	sw.m.Lock()
+	sw.failing = failing
+	sw.m.Unlock()
+}
+
+func (sw *switchWriter) Write(data []byte) (int, error) {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+	if sw.failing {
+		return 0, errors.New("connection refused")
+	}
+	return sw.b.Write(data)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	sw := &switchWriter{failing: true, b: &lockedBuffer{}}
+	var changes []string
+	alog := New(sw, WithCircuitBreaker(2, time.Minute), WithErrorBuffer(10),
+		WithCircuitStateFunc(func(from, to CircuitState) {
+			changes = append(changes, from.String()+"->"+to.String())
+		}))
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog.now = clock.Now
+
+	for i := 0; i < 2; i++ {
+		if _, err := alog.Write("fails"); err == nil || err == ErrCircuitOpen {
+			t.Errorf("Write %d returned %v, expected the writer's error", i, err)
+		}
+	}
+	if _, err := alog.Write("rejected"); err != ErrCircuitOpen {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	stats := alog.CircuitStats()
+	if stats.State != CircuitOpen || stats.ConsecutiveFailures != 2 || stats.Rejected != 1 ||
+		stats.LastError == nil || stats.LastError.Error() != "connection refused" {
+		t.Errorf("Got %+v", stats)
+	}
+
+	// A failed probe opens the circuit for another cooldown.
+	clock.advance(time.Minute)
+	if _, err := alog.Write("probe"); err == nil || err == ErrCircuitOpen {
+		t.Errorf("Expected the probe to fail with the writer's error, got %v", err)
+	}
+	if _, err := alog.Write("rejected"); err != ErrCircuitOpen {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	sw.set(false)
+	clock.advance(time.Minute)
+	for _, msg := range []string{"probe", "after"} {
+		if _, err := alog.Write(msg); err != nil {
+			t.Errorf("Writing %q returned %v", msg, err)
+		}
+	}
+	if got := writtenMessages(sw.b); strings.Join(got, ",") != "probe,after" {
+		t.Errorf("Got %q", got)
+	}
+	want := "closed->open,open->half-open,half-open->open,open->half-open,half-open->closed"
+	if got := strings.Join(changes, ","); got != want {
+		t.Errorf("Got state changes %s, want %s", got, want)
+	}
+	if stats := alog.CircuitStats(); stats.State != CircuitClosed || stats.ConsecutiveFailures != 0 ||
+		stats.Rejected != 2 {
+		t.Errorf("Got %+v", stats)
+	}
+}
+
+func TestCircuitBreakerDrops(t *testing.T) {
+	sw := &switchWriter{failing: true, b: &lockedBuffer{}}
+	alog := New(sw, WithCircuitBreaker(1, time.Hour), WithBufferSize(10), WithErrorBuffer(10))
+	go alog.Start()
+	alog.Info("fails")
+	alog.Info("dropped")
+	alog.Info("dropped")
+	alog.Stop()
+
+	if n := alog.Dropped(); n != 2 {
+		t.Errorf("Expected 2 dropped messages, got %d", n)
+	}
+	if n := alog.ErrorCount(); n != 1 {
+		t.Errorf("Expected only the failed write to be reported, got %d errors", n)
+	}
+}
+
+func TestCircuitBreakerFallback(t *testing.T) {
+	sw := &switchWriter{failing: true, b: &lockedBuffer{}}
+	fallback := &lockedBuffer{}
+	alog := New(sw, WithCircuitBreaker(1, time.Hour), WithFallbackWriter(fallback), WithBufferSize(10),
+		WithErrorBuffer(10))
+	go alog.Start()
+	alog.Info("fails")
+	alog.Info("open")
+	alog.Stop()
+
+	if got := writtenMessages(fallback); strings.Join(got, ",") != "fails,open" {
+		t.Errorf("Got %q", got)
+	}
+	if n := alog.Dropped(); n != 0 {
+		t.Errorf("Expected nothing to be dropped, got %d", n)
+	}
+	if n := alog.ErrorCount(); n != 1 {
+		t.Errorf("Expected only the primary's failure to be reported, got %d errors", n)
+	}
+}
+
+func TestCircuitStatsWithoutBreaker(t *testing.T) {
+	alog := New(&lockedBuffer{})
+	if stats := alog.CircuitStats(); stats != (CircuitStats{}) || stats.State.String() != "closed" {
+		t.Errorf("Got %+v", stats)
+	}
+}
+
+func TestCircuitBreakerAdditionalWriter(t *testing.T) {
+	sw := &switchWriter{failing: true, b: &lockedBuffer{}}
+	extra := &lockedBuffer{}
+	alog := New(sw, WithCircuitBreaker(1, time.Hour), WithAdditionalWriter(extra), WithBufferSize(10),
+		WithErrorBuffer(10))
+	go alog.Start()
+	alog.Info("fails")
+	alog.Info("open")
+	alog.Stop()
+
+	if got := writtenMessages(extra); strings.Join(got, ",") != "fails,open" {
+		t.Errorf("Got %q", got)
+	}
+	if n, rejected := alog.ErrorCount(), alog.CircuitStats().Rejected; n != 1 || rejected != 1 {
+		t.Errorf("Expected 1 error and 1 rejected message, got %d and %d", n, rejected)
+	}
+}
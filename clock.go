@@ -0,0 +1,57 @@
+package alog
+
+import "time"
+
+// Clock is the source of time for a logger, see WithClock. The default is the system clock.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is a ticker made by a Clock, like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// WithClock makes the logger read the time from c: the timestamps of messages, the windows of WithRateLimit, the
+// cooldowns of WithCircuitBreaker and WithFallbackWriter and the other times the logger looks at come from c.Now, and
+// the flushes of WithBuffering are ticked by c.NewTicker. It's meant for tests that need exact output or control
+// over time. The timers that act when nothing is logged, such as the one that ends a rate limit window or a run of
+// repeats, still use the system clock. Use WithFileClock for a FileWriter's rotation.
+func WithClock(c Clock) Option {
+	return func(al *Alog) {
+		al.clock = c
+		al.now = c.Now
+	}
+}
+
+// WithFileClock makes the FileWriter read the time from c to decide when to rotate and to name rotated files.
+func WithFileClock(c Clock) FileOption {
+	return func(fw *FileWriter) {
+		fw.now = c.Now
+	}
+}
+
+// systemClock is the default Clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (st systemTicker) C() <-chan time.Time {
+	return st.t.C
+}
+
+func (st systemTicker) Stop() {
+	st.t.Stop()
+}
@@ -0,0 +1,113 @@
+package alog
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is the Clock of the tests. It stays at t until it's moved with advance, unless it's given later times,
+// which it moves to one each time it's read, or a step, which it moves on by each time it's read. Its tickers tick
+// when a time is sent on ticks.
+type fakeClock struct {
+	m     sync.Mutex
+	t     time.Time
+	later []time.Time
+	step  time.Duration
+	ticks chan time.Time
+}
+
+// newFakeClock returns a fakeClock at t, that moves to each of later in turn as it's read and then stays at the last.
+func newFakeClock(t time.Time, later ...time.Time) *fakeClock {
+	return &fakeClock{t: t, later: later, ticks: make(chan time.Time)}
+}
+
+// newTickingClock returns a fakeClock at t that moves on by a second each time it's read.
+func newTickingClock(t time.Time) *fakeClock {
+	fc := newFakeClock(t)
+	fc.step = time.Second
+	return fc
+}
+
+func (fc *fakeClock) Now() time.Time {
+	fc.m.Lock()
+	defer fc.m.Unlock()
+	t := fc.t
+	if len(fc.later) > 0 {
+		fc.t, fc.later = fc.later[0], fc.later[1:]
+	} else {
+		fc.t = fc.t.Add(fc.step)
+	}
+	return t
+}
+
+// advance moves the clock forward by d, or back if d is negative.
+func (fc *fakeClock) advance(d time.Duration) {
+	fc.m.Lock()
+	fc.t = fc.t.Add(d)
+	fc.m.Unlock()
+}
+
+func (fc *fakeClock) NewTicker(time.Duration) Ticker {
+	return fakeTicker{fc.ticks}
+}
+
+type fakeTicker struct {
+	c chan time.Time
+}
+
+func (ft fakeTicker) C() <-chan time.Time {
+	return ft.c
+}
+
+func (fakeTicker) Stop() {}
+
+func TestWithClock(t *testing.T) {
+	b := &lockedBuffer{}
+	alog := New(b, WithClock(newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local))), WithBufferSize(10),
+		WithLatePolicy(LateWriteSync))
+	go alog.Start()
+	alog.InfoKV("queued", "n", 1)
+	alog.Stop()
+	if _, err := alog.Write("sync"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "[2024-05-01 12:00:00] [INFO] - queued n=1\n" +
+		"[2024-05-01 12:00:00] - sync\n"
+	if got := b.String(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestClockTicksFlushes(t *testing.T) {
+	b := &lockedBuffer{}
+	fc := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local))
+	alog := New(b, WithClock(fc), WithBuffering(4096, time.Hour))
+	go alog.Start()
+	alog.Info("buffered")
+	for atomic.LoadUint64(&alog.written) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if got := b.String(); got != "" {
+		t.Fatalf("Expected the message to be held in the buffer, got %q", got)
+	}
+	fc.ticks <- fc.Now()
+	for b.String() == "" {
+		time.Sleep(time.Millisecond)
+	}
+	if got := b.String(); got != "[2024-05-01 12:00:00] [INFO] - buffered\n" {
+		t.Errorf("Got %q", got)
+	}
+	alog.Stop()
+}
+
+func TestWithFileClock(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	fw := NewFileWriter(filepath.Join(t.TempDir(), "app.log"), WithFileClock(newFakeClock(now)))
+	if got := fw.now(); !got.Equal(now) {
+		t.Errorf("Got %v", got)
+	}
+}
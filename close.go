@@ -0,0 +1,38 @@
+package alog
+
+import (
+	"io"
+	"os"
+)
+
+// WithCloseWriter makes Close close the writer passed to New, or the one that replaced it with SetOutput, if it's
+// an io.Closer. By default Close leaves it open, since it belongs to the caller. os.Stdout and os.Stderr are never
+// closed.
+func WithCloseWriter(close bool) Option {
+	return func(al *Alog) {
+		al.closeWriter = close
+	}
+}
+
+// Close stops the logger like Stop, writing every pending message, and then closes the writer if WithCloseWriter
+// was used, returning its error. It makes Alog an io.Closer, so "defer al.Close()" shuts it down. Calling Close
+// again does nothing and returns the first call's error. Don't restart a closed logger, its writer may be closed.
+func (al *Alog) Close() error {
+	al.closeM.Lock()
+	defer al.closeM.Unlock()
+	if al.closed {
+		return al.closeErr
+	}
+	al.closed = true
+	al.Stop()
+	if !al.closeWriter {
+		return nil
+	}
+	al.output.m.RLock()
+	w := al.output.w
+	al.output.m.RUnlock()
+	if c, ok := w.(io.Closer); ok && w != os.Stdout && w != os.Stderr {
+		al.closeErr = c.Close()
+	}
+	return al.closeErr
+}
@@ -0,0 +1,60 @@
+package alog
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// closeCounter counts the calls to Close.
+type closeCounter struct {
+	lockedBuffer
+	closes int
+	err    error
+}
+
+func (cc *closeCounter) Close() error {
+	cc.closes++
+	return cc.err
+}
+
+func TestCloseLeavesWriterOpen(t *testing.T) {
+	cc := &closeCounter{}
+	var alog io.Closer = New(cc)
+	if err := alog.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if cc.closes != 0 {
+		t.Errorf("Expected the caller's writer to be left open, it was closed %d times", cc.closes)
+	}
+}
+
+func TestCloseWriter(t *testing.T) {
+	cc := &closeCounter{err: errors.New("already closed")}
+	alog := New(cc, WithCloseWriter(true), WithBufferSize(10))
+	go alog.Start()
+	alog.Info("pending")
+	for i := 0; i < 2; i++ {
+		if err := alog.Close(); err == nil || err.Error() != "already closed" {
+			t.Errorf("Close %d returned %v", i, err)
+		}
+	}
+	if cc.closes != 1 {
+		t.Errorf("Expected the writer to be closed once, got %d", cc.closes)
+	}
+	if got := writtenMessages(&cc.lockedBuffer); len(got) != 1 || got[0] != "pending" {
+		t.Errorf("Expected the pending message to be written before closing, got %q", got)
+	}
+}
+
+func TestCloseWriterAfterSetOutput(t *testing.T) {
+	first, second := &closeCounter{}, &closeCounter{}
+	alog := New(first, WithCloseWriter(true))
+	alog.SetOutput(second)
+	if err := alog.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if first.closes != 0 || second.closes != 1 {
+		t.Errorf("Expected only the current writer to be closed, got %d and %d closes", first.closes, second.closes)
+	}
+}
@@ -14,6 +14,7 @@ import (
 func main() {
 	out := flag.String("out", "stdout", "File name to use for log output. If stdout is provided, then output is written directly to the console.")
 	async := flag.Bool("async", false, "This flag determines if the logger should write asynchronously.")
+	buffer := flag.Int("buffer", 0, "Number of messages that can be queued while the logger is busy writing.")
 	flag.Parse()
 
 	var w io.Writer
@@ -26,7 +27,7 @@ func main() {
 			log.Fatal("Unable to open log file", err)
 		}
 	}
-	l := alog.New(w)
+	l := alog.New(w, alog.WithBufferSize(*buffer))
 	go l.Start()
 
 	messageChan := l.MessageChannel()
@@ -0,0 +1,138 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// ColorMode is whether the default TextFormatter colors the level of each message, see WithColor.
+type ColorMode int
+
+const (
+	// ColorNever writes plain text. It's the default.
+	ColorNever ColorMode = iota
+	// ColorAuto colors the levels if the writer passed to New is a terminal and the NO_COLOR environment variable
+	// isn't set.
+	ColorAuto
+	// ColorAlways colors the levels wherever they're written.
+	ColorAlways
+)
+
+// WithColor makes the default TextFormatter color the level of each message with ANSI escape sequences, depending
+// on mode: debug is gray, info is cyan, warn is yellow and error is red. Only the level is colored, and JSON and
+// logfmt are never colored. Every destination gets the same text, so use StripANSI for a file that's written to
+// along with a terminal.
+func WithColor(mode ColorMode) Option {
+	return func(al *Alog) {
+		al.color = mode
+	}
+}
+
+// isTerminal reports whether w is a terminal. Tests replace it.
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colored reports whether the default TextFormatter should color levels. New calls it before it wraps dest.
+func (al *Alog) colored() bool {
+	switch al.color {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		_, noColor := os.LookupEnv("NO_COLOR")
+		return !noColor && isTerminal(al.dest)
+	}
+	return false
+}
+
+// levelColor returns the escape sequence that starts l's color.
+func levelColor(l Level) string {
+	switch {
+	case l < LevelInfo:
+		return "\x1b[90m"
+	case l < LevelWarn:
+		return "\x1b[36m"
+	case l < LevelError:
+		return "\x1b[33m"
+	default:
+		return "\x1b[31m"
+	}
+}
+
+// colorReset ends a color started with levelColor.
+const colorReset = "\x1b[0m"
+
+// StripANSI returns a writer that removes ANSI escape sequences, such as the colors added by WithColor, from what's
+// written to it before writing it to w. Flush and Close are passed on to w if it has those methods.
+func StripANSI(w io.Writer) io.Writer {
+	return &ansiStripper{w: w}
+}
+
+type ansiStripper struct {
+	w io.Writer
+}
+
+// Write implements io.Writer. data must hold whole escape sequences, as the logger's writes do.
+func (as *ansiStripper) Write(data []byte) (int, error) {
+	if bytes.IndexByte(data, 0x1b) < 0 {
+		return as.w.Write(data)
+	}
+	fb := getFormatBuffer()
+	defer putFormatBuffer(fb)
+	fb.buf = appendStripped(fb.buf, data)
+	if _, err := as.w.Write(fb.buf); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (as *ansiStripper) Flush() error {
+	if f, ok := as.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (as *ansiStripper) Close() error {
+	if c, ok := as.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (as *ansiStripper) waitBackground(timeout time.Duration) error {
+	if b, ok := as.w.(backgrounder); ok {
+		return b.waitBackground(timeout)
+	}
+	return nil
+}
+
+// appendStripped appends data to buf without its CSI escape sequences: ESC [, parameters, and a final byte from @
+// to ~.
+func appendStripped(buf, data []byte) []byte {
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, 0x1b)
+		if i < 0 || i+1 == len(data) || data[i+1] != '[' {
+			if i < 0 {
+				return append(buf, data...)
+			}
+			buf = append(buf, data[:i+1]...)
+			data = data[i+1:]
+			continue
+		}
+		buf = append(buf, data[:i]...)
+		j := i + 2
+		for j < len(data) && (data[j] < 0x40 || data[j] > 0x7e) {
+			j++
+		}
+		data = data[min(j+1, len(data)):]
+	}
+	return buf
+}
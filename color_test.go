@@ -0,0 +1,95 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestColorAlways(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithColor(ColorAlways), WithTimestampFormat(""), WithLevel(LevelDebug))
+	go alog.Start()
+	alog.Debug("d")
+	alog.Info("i")
+	alog.Warn("w")
+	alog.Error("e")
+	alog.Stop()
+	want := "[\x1b[90mDEBUG\x1b[0m] - d\n[\x1b[36mINFO\x1b[0m] - i\n[\x1b[33mWARN\x1b[0m] - w\n[\x1b[31mERROR\x1b[0m] - e\n"
+	if b.String() != want {
+		t.Errorf("Got %q, want %q", b.String(), want)
+	}
+}
+
+func TestColorNever(t *testing.T) {
+	for _, opt := range []Option{WithColor(ColorNever), WithColor(ColorAuto)} {
+		b := bytes.NewBuffer([]byte{})
+		alog := New(b, opt)
+		go alog.Start()
+		alog.Error("e")
+		alog.Stop()
+		if strings.Contains(b.String(), "\x1b") {
+			t.Errorf("Expected no escape sequences, got %q", b.String())
+		}
+	}
+}
+
+func TestColorAuto(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	defer func(f func(io.Writer) bool) { isTerminal = f }(isTerminal)
+	isTerminal = func(w io.Writer) bool { return w == b }
+	colored := func(al *Alog) bool {
+		return al.formatter.(TextFormatter).Color
+	}
+	if !colored(New(b, WithColor(ColorAuto))) {
+		t.Error("Expected colors on a terminal")
+	}
+	if colored(New(bytes.NewBuffer([]byte{}), WithColor(ColorAuto))) {
+		t.Error("Expected no colors on anything else")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colored(New(b, WithColor(ColorAuto))) {
+		t.Error("Expected NO_COLOR to turn colors off")
+	}
+}
+
+func TestColorNotInStructuredFormats(t *testing.T) {
+	for _, opt := range []Option{WithJSONFormat(), WithLogfmtFormat()} {
+		b := bytes.NewBuffer([]byte{})
+		alog := New(b, WithColor(ColorAlways), opt)
+		alog.Write("plain")
+		if strings.Contains(b.String(), "\x1b") {
+			t.Errorf("Expected no escape sequences, got %q", b.String())
+		}
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	term, file := bytes.NewBuffer([]byte{}), &lockedBuffer{}
+	alog := New(term, WithColor(ColorAlways), WithTimestampFormat(""), WithAdditionalWriter(StripANSI(file)))
+	go alog.Start()
+	alog.Warn("careful \x1b[1mbold\x1b[0m")
+	alog.Stop()
+	if !strings.Contains(term.String(), "\x1b[33m") {
+		t.Errorf("Expected colors on the terminal, got %q", term.String())
+	}
+	if got := file.String(); got != "[WARN] - careful bold\n" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestAppendStripped(t *testing.T) {
+	for in, want := range map[string]string{
+		"plain":              "plain",
+		"\x1b[31mred\x1b[0m": "red",
+		"\x1b[1;4;38;5;9mx":  "x",
+		"esc \x1b alone":     "esc \x1b alone",
+		"cut \x1b[3":         "cut ",
+		"end \x1b":           "end \x1b",
+	} {
+		if got := string(appendStripped(nil, []byte(in))); got != want {
+			t.Errorf("appendStripped(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package alog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// maxBackgroundWait is how long Stop waits for destinations to finish their work in the background, such as
+// FileWriter compressing rotated files.
+const maxBackgroundWait = 10 * time.Second
+
+// backgrounder is implemented by destinations that work on goroutines of their own. waitBackground waits for that
+// work to finish and returns its errors.
+type backgrounder interface {
+	waitBackground(timeout time.Duration) error
+}
+
+// waitDestinations waits for the destinations' background work and reports its errors.
+func (al *Alog) waitDestinations() {
+	for _, w := range al.dests {
+		if b, ok := w.(backgrounder); ok {
+			if err := b.waitBackground(maxBackgroundWait); err != nil {
+				al.reportError(err)
+			}
+		}
+	}
+}
+
+// WithCompression makes the FileWriter gzip rotated files on a goroutine of its own, so writing doesn't wait for
+// it. Each file is replaced by a copy with .gz added to its name; if compressing fails the original is kept. A
+// logger writing to the FileWriter waits for compressions in flight when it's stopped, for up to 10 seconds.
+func WithCompression() FileOption {
+	return func(fw *FileWriter) {
+		fw.compress = true
+	}
+}
+
+// addBackgroundErr records an error from work done in the background, for the next Write to return.
+func (fw *FileWriter) addBackgroundErr(err error) {
+	fw.bgM.Lock()
+	fw.bgErrs = append(fw.bgErrs, err)
+	fw.bgM.Unlock()
+}
+
+// backgroundErr returns the errors from the background since the last call, if there were any.
+func (fw *FileWriter) backgroundErr() error {
+	fw.bgM.Lock()
+	defer fw.bgM.Unlock()
+	err := joinErrors(fw.bgErrs)
+	fw.bgErrs = nil
+	return err
+}
+
+func (fw *FileWriter) waitBackground(timeout time.Duration) error {
+	fw.m.Lock()
+	if err := fw.sync(); err != nil {
+		fw.addBackgroundErr(err)
+	}
+	fw.m.Unlock()
+	done := make(chan struct{})
+	go func() {
+		fw.bg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return fw.backgroundErr()
+	case <-time.After(timeout):
+		return errors.New("alog: gave up waiting for rotated files to be compressed")
+	}
+}
+
+// compressFile writes name to name.gz and removes name. If anything fails name is left alone and the partial
+// name.gz is removed.
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(name+".gz", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(name + ".gz")
+		}
+	}()
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	// The retention options go by when the file was last written, not when it was compressed.
+	if err := os.Chtimes(name+".gz", info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return !os.IsNotExist(err)
+}
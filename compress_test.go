@@ -0,0 +1,172 @@
+package alog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gunzip(t *testing.T, name string) string {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFileWriterCompression(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	fw := NewFileWriter(path, WithMaxFileSize(100), WithCompression())
+	fw.now = newTickingClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)).Now
+	all := &lockedBuffer{}
+	alog := New(fw, WithAdditionalWriter(all), WithBufferSize(10))
+	go alog.Start()
+	for i := 0; i < 10; i++ {
+		alog.Info("message " + strconv.Itoa(i))
+	}
+	alog.Stop() // waits for the compression
+	select {
+	case err := <-alog.ErrorChannel():
+		t.Fatal(err)
+	default:
+	}
+
+	names, _ := filepath.Glob(path + ".*")
+	sort.Strings(names)
+	if len(names) < 2 {
+		t.Fatalf("Expected a couple of rotated files, got %q", names)
+	}
+	var got strings.Builder
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".gz") {
+			t.Fatalf("%s wasn't compressed", name)
+		}
+		got.WriteString(gunzip(t, name))
+	}
+	fw.Close()
+	current, _ := os.ReadFile(path)
+	got.Write(current)
+	if got.String() != all.String() {
+		t.Errorf("The files don't add up to the log:\n%s\nwant:\n%s", got.String(), all)
+	}
+}
+
+func TestFileWriterCompressionTimeRotation(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local))
+	fw := NewFileWriter(filepath.Join(dir, "app.log"), WithTimeRotation(Daily), WithCompression())
+	fw.now = clock.Now
+	fw.Write([]byte("a\n"))
+	clock.advance(2 * time.Minute)
+	fw.Write([]byte("b\n"))
+	if err := fw.Close(); err != nil { // waits for the compression
+		t.Fatal(err)
+	}
+
+	files := readFiles(t, dir)
+	if _, ok := files["app-2024-05-01.log"]; ok || len(files) != 2 || files["app-2024-05-02.log"] != "b\n" {
+		t.Fatalf("Expected the first day's file to be compressed, got %q", files)
+	}
+	if got := gunzip(t, filepath.Join(dir, "app-2024-05-01.log.gz")); got != "a\n" {
+		t.Errorf("Got %q from the compressed file", got)
+	}
+}
+
+func TestFileWriterCompressionRetentionConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	fw := NewFileWriter(filepath.Join(dir, "app.log"), WithMaxFileSize(4<<10), WithCompression(), WithMaxBackups(2))
+	fw.now = newTickingClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)).Now
+	var lines []string
+	for i := 0; i < 200; i++ {
+		line := fmt.Sprintf("%04d %s\n", i, strings.Repeat("x", 1018))
+		lines = append(lines, line)
+		if _, err := fw.Write([]byte(line)); err != nil { // doesn't wait for the files rotated before
+			t.Fatal(err)
+		}
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, _ := filepath.Glob(filepath.Join(dir, "*"))
+	sort.Strings(names)
+	if len(names) != 3 || !strings.HasSuffix(names[0], ".log") || !strings.HasSuffix(names[1], ".gz") ||
+		!strings.HasSuffix(names[2], ".gz") {
+		t.Fatalf("Expected the current file and the two newest backups, compressed, got %q", names)
+	}
+	got := gunzip(t, names[1]) + gunzip(t, names[2])
+	current, err := os.ReadFile(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got += string(current)
+	if want := strings.Join(lines[188:], ""); got != want {
+		t.Errorf("Expected the files to hold the last 12 lines, got the %d from %.4s", strings.Count(got, "\n"), got)
+	}
+}
+
+func TestCompressFileKeepsModTime(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "app.log.20240501-120000")
+	if err := os.WriteFile(name, []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rotated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	if err := os.Chtimes(name, rotated, rotated); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressFile(name); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(name + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(rotated) {
+		t.Errorf("Expected the compressed file to keep the time it was last written, %v, got %v", rotated,
+			info.ModTime())
+	}
+}
+
+func TestFileWriterCompressionFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	backup := path + ".20240501-120000"
+	fw := NewFileWriter(path, WithMaxFileSize(2), WithCompression())
+	fw.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)).Now
+	// Make the .gz impossible to create once the backup name has been chosen.
+	fw.rename = func(oldpath, newpath string) error {
+		if err := os.Mkdir(newpath+".gz", 0o755); err != nil {
+			return err
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	alog := New(fw)
+	go alog.Start()
+	alog.Info("a")
+	alog.Info("b")
+	alog.Stop()
+	fw.Close()
+
+	if err := <-alog.ErrorChannel(); err == nil || !strings.Contains(err.Error(), ".gz") {
+		t.Errorf("Expected the compression error, got %v", err)
+	}
+	if data, err := os.ReadFile(backup); err != nil || !strings.HasSuffix(string(data), "- a\n") {
+		t.Errorf("The rotated file was lost: %q, %v", data, err)
+	}
+}
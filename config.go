@@ -0,0 +1,147 @@
+package alog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config is a logger's configuration as it would be loaded from a configuration file, see NewWithConfig. The zero
+// value of every field means the default that New uses.
+type Config struct {
+	Level           string     `json:"level" yaml:"level"`                       // trace, debug, info, warn or error
+	Format          string     `json:"format" yaml:"format"`                     // text, json or logfmt
+	Output          string     `json:"output" yaml:"output"`                     // stdout, stderr or a file path
+	BufferSize      int        `json:"buffer_size" yaml:"buffer_size"`           // see WithBufferSize
+	TimestampLayout string     `json:"timestamp_layout" yaml:"timestamp_layout"` // see WithTimestampFormat, off for none
+	UTC             bool       `json:"utc" yaml:"utc"`                           // see WithUTC
+	File            FileConfig `json:"file" yaml:"file"`                         // used when Output is a file path
+}
+
+// FileConfig configures the FileWriter of a Config whose Output is a file path.
+type FileConfig struct {
+	MaxSize    int64  `json:"max_size" yaml:"max_size"`       // bytes, see WithMaxFileSize; less than 0 to not rotate
+	Rotation   string `json:"rotation" yaml:"rotation"`       // hourly or daily, see WithTimeRotation
+	MaxBackups int    `json:"max_backups" yaml:"max_backups"` // see WithMaxBackups
+	MaxAge     string `json:"max_age" yaml:"max_age"`         // a duration such as "72h", see WithMaxBackupAge
+	Compress   bool   `json:"compress" yaml:"compress"`       // see WithCompression
+}
+
+// levelNames are the level names Config and NewFromEnv accept.
+var levelNames = map[string]Level{
+	"trace": LevelTrace, "debug": LevelDebug, "info": LevelInfo, "warn": LevelWarn, "error": LevelError,
+}
+
+// formatNames are the format names Config and NewFromEnv accept. text is nil, for New's default TextFormatter.
+var formatNames = map[string]Formatter{"text": nil, "json": JSONFormatter{}, "logfmt": LogfmtFormatter{}}
+
+// Validate checks cfg and returns an error describing every invalid field, or nil. Names are not case sensitive.
+func (cfg Config) Validate() error {
+	var errs []error
+	if _, ok := levelNames[strings.ToLower(cfg.Level)]; cfg.Level != "" && !ok {
+		errs = append(errs, fmt.Errorf("alog: invalid Level %q, want trace, debug, info, warn or error", cfg.Level))
+	}
+	if _, ok := formatNames[strings.ToLower(cfg.Format)]; cfg.Format != "" && !ok {
+		errs = append(errs, fmt.Errorf("alog: invalid Format %q, want text, json or logfmt", cfg.Format))
+	}
+	if cfg.BufferSize < 0 {
+		errs = append(errs, fmt.Errorf("alog: invalid BufferSize %d, want 0 or more", cfg.BufferSize))
+	}
+	if r := strings.ToLower(cfg.File.Rotation); r != "" && r != "hourly" && r != "daily" {
+		errs = append(errs, fmt.Errorf("alog: invalid File.Rotation %q, want hourly or daily", cfg.File.Rotation))
+	}
+	if cfg.File.MaxBackups < 0 {
+		errs = append(errs, fmt.Errorf("alog: invalid File.MaxBackups %d, want 0 or more", cfg.File.MaxBackups))
+	}
+	if cfg.File.MaxAge != "" {
+		if d, err := time.ParseDuration(cfg.File.MaxAge); err != nil || d < 0 {
+			errs = append(errs, fmt.Errorf("alog: invalid File.MaxAge %q, want a duration such as 72h", cfg.File.MaxAge))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewWithConfig validates cfg and creates a logger configured by it, with opts applied after it. If cfg.Output is a
+// file path the file is opened, or created, straight away and written by a FileWriter configured by cfg.File, which
+// Close closes. Like New, the logger has to be started.
+func NewWithConfig(cfg Config, opts ...Option) (*Alog, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var cfgOpts []Option
+	if cfg.Level != "" {
+		cfgOpts = append(cfgOpts, WithLevel(levelNames[strings.ToLower(cfg.Level)]))
+	}
+	if cfg.Format != "" {
+		cfgOpts = append(cfgOpts, WithFormatter(formatNames[strings.ToLower(cfg.Format)]))
+	}
+	if cfg.BufferSize > 0 {
+		cfgOpts = append(cfgOpts, WithBufferSize(cfg.BufferSize))
+	}
+	switch {
+	case strings.EqualFold(cfg.TimestampLayout, "off"):
+		cfgOpts = append(cfgOpts, WithTimestampFormat(""))
+	case cfg.TimestampLayout != "":
+		cfgOpts = append(cfgOpts, WithTimestampFormat(cfg.TimestampLayout))
+	}
+	if cfg.UTC {
+		cfgOpts = append(cfgOpts, WithUTC())
+	}
+	var w *os.File
+	switch strings.ToLower(cfg.Output) {
+	case "", "stdout":
+		w = os.Stdout
+	case "stderr":
+		w = os.Stderr
+	default:
+		fw, err := openFileWriter(cfg.Output, cfg.File.options()...)
+		if err != nil {
+			return nil, fmt.Errorf("alog: invalid Output: %w", err)
+		}
+		return New(fw, append(append(cfgOpts, WithCloseWriter(true)), opts...)...), nil
+	}
+	return New(w, append(cfgOpts, opts...)...), nil
+}
+
+// options returns the FileOptions fc sets. fc must be valid.
+func (fc FileConfig) options() []FileOption {
+	var opts []FileOption
+	if fc.MaxSize != 0 {
+		opts = append(opts, WithMaxFileSize(fc.MaxSize))
+	}
+	switch strings.ToLower(fc.Rotation) {
+	case "hourly":
+		opts = append(opts, WithTimeRotation(Hourly))
+	case "daily":
+		opts = append(opts, WithTimeRotation(Daily))
+	}
+	if fc.MaxBackups > 0 {
+		opts = append(opts, WithMaxBackups(fc.MaxBackups))
+	}
+	if d, _ := time.ParseDuration(fc.MaxAge); d > 0 {
+		opts = append(opts, WithMaxBackupAge(d))
+	}
+	if fc.Compress {
+		opts = append(opts, WithCompression())
+	}
+	return opts
+}
+
+// openFileWriter returns a FileWriter for path that has already opened its file, the current period's if there's a
+// Rotation, so that a path that can't be written to is an error straight away rather than on the first write.
+func openFileWriter(path string, opts ...FileOption) (*FileWriter, error) {
+	fw := NewFileWriter(path, opts...)
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if fw.rotation != NoRotation {
+		if err := fw.roll(fw.now()); err != nil {
+			return nil, err
+		}
+	}
+	if err := fw.open(); err != nil {
+		return nil, err
+	}
+	return fw, nil
+}
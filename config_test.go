@@ -0,0 +1,100 @@
+package alog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWithConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	data := `{
+		"level": "warn",
+		"format": "JSON",
+		"output": "` + filepath.ToSlash(path) + `",
+		"buffer_size": 20,
+		"utc": true,
+		"file": {"max_size": 1048576, "rotation": "daily", "max_backups": 5, "max_age": "72h", "compress": true}
+	}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	alog, err := NewWithConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alog.Level() != LevelWarn || alog.bufferSize != 20 || alog.location != time.UTC || !alog.closeWriter {
+		t.Errorf("Got level %v, buffer size %d, location %v, closeWriter %t",
+			alog.Level(), alog.bufferSize, alog.location, alog.closeWriter)
+	}
+	fw, ok := alog.output.w.(*FileWriter)
+	if !ok {
+		t.Fatalf("Expected a FileWriter, got %T", alog.output.w)
+	}
+	if fw.maxSize != 1<<20 || fw.rotation != Daily || fw.maxBackups != 5 || fw.maxBackupAge != 72*time.Hour ||
+		!fw.compress {
+		t.Errorf("Got FileWriter %+v", fw)
+	}
+
+	alog.now = newFakeClock(time.Date(2024, 5, 1, 14, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))).Now
+	go alog.Start()
+	alog.Info("filtered")
+	alog.Warn("kept")
+	if err := alog.Close(); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(fw.name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(written), `{"time":"2024-05-01T12:00:00Z","level":"warn","msg":"kept"}`+"\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	start, _ := Daily.period(time.Now())
+	if files := readFiles(t, dir); len(files) != 1 || files[filepath.Base(Daily.fileName(path, start))] == "" {
+		t.Errorf("Expected only the day's file, got %q", files)
+	}
+}
+
+func TestNewWithConfigDefaults(t *testing.T) {
+	alog, err := NewWithConfig(Config{TimestampLayout: "off"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	def := New(nil)
+	if alog.Level() != def.Level() || alog.bufferSize != def.bufferSize || alog.output.w != os.Stdout ||
+		alog.timestampFormat != "" || alog.closeWriter {
+		t.Errorf("Expected New's defaults, got %+v", alog.core)
+	}
+	if _, ok := alog.Formatter().(TextFormatter); !ok {
+		t.Errorf("Expected a TextFormatter, got %T", alog.Formatter())
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	err := Config{
+		Level:      "verbose",
+		Format:     "xml",
+		BufferSize: -1,
+		File:       FileConfig{Rotation: "weekly", MaxBackups: -2, MaxAge: "3 days"},
+	}.Validate()
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, field := range []string{"Level", "Format", "BufferSize", "File.Rotation", "File.MaxBackups", "File.MaxAge"} {
+		if !strings.Contains(err.Error(), field+" ") {
+			t.Errorf("Expected an error about %s, got %v", field, err)
+		}
+	}
+	if alog, err := NewWithConfig(Config{Level: "loud"}); alog != nil || err == nil {
+		t.Errorf("Got %v, %v", alog, err)
+	}
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("Expected the zero Config to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package alog
+
+import (
+	"context"
+	"sort"
+)
+
+// WithContextExtractor registers fn to pull fields out of the context passed to the Ctx methods, such as InfoCtx,
+// for example a request ID that middleware stored with context.WithValue. fn is called on the logging goroutine,
+// when the message is logged, so the context never ends up in the queue, and only for messages that pass the
+// logger's level and sampling. It isn't called for a nil context, and it may return nil when the context has none of
+// its values. The fields come after the logger's own and before the message's key/value pairs, in key order, and
+// extractors registered later override the fields of earlier ones.
+func WithContextExtractor(fn func(ctx context.Context) map[string]any) Option {
+	return func(al *Alog) {
+		al.extractors = append(al.extractors, fn)
+	}
+}
+
+// logCtx is log for the Ctx methods, with the fields extracted from ctx ahead of kv.
+func (al *Alog) logCtx(ctx context.Context, l Level, msg string, kv []any) error {
+	if !al.enabled(l) || al.sampler.sampleOut(l) {
+		return nil
+	}
+	return al.logEntry(l, msg, al.contextKV(ctx, kv))
+}
+
+// contextKV returns the fields that the extractors pulled out of ctx as alternating keys and values, followed by kv.
+func (al *Alog) contextKV(ctx context.Context, kv []any) []any {
+	if ctx == nil || len(al.extractors) == 0 {
+		return kv
+	}
+	var ctxKV []any
+	for _, extract := range al.extractors {
+		fields := extract(ctx)
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ctxKV = append(ctxKV, k, fields[k])
+		}
+	}
+	if len(ctxKV) == 0 {
+		return kv
+	}
+	return append(ctxKV, kv...)
+}
+
+// TraceCtx queues msg to be written at LevelTrace with the fields extracted from ctx, see WithContextExtractor, and
+// the alternating keys and values in kv attached to it.
+func (al *Alog) TraceCtx(ctx context.Context, msg string, kv ...any) error {
+	return al.logCtx(ctx, LevelTrace, msg, kv)
+}
+
+// DebugCtx queues msg to be written at LevelDebug with the fields extracted from ctx, see WithContextExtractor, and
+// the alternating keys and values in kv attached to it.
+func (al *Alog) DebugCtx(ctx context.Context, msg string, kv ...any) error {
+	return al.logCtx(ctx, LevelDebug, msg, kv)
+}
+
+// InfoCtx queues msg to be written at LevelInfo with the fields extracted from ctx, see WithContextExtractor, and
+// the alternating keys and values in kv attached to it.
+func (al *Alog) InfoCtx(ctx context.Context, msg string, kv ...any) error {
+	return al.logCtx(ctx, LevelInfo, msg, kv)
+}
+
+// WarnCtx queues msg to be written at LevelWarn with the fields extracted from ctx, see WithContextExtractor, and
+// the alternating keys and values in kv attached to it.
+func (al *Alog) WarnCtx(ctx context.Context, msg string, kv ...any) error {
+	return al.logCtx(ctx, LevelWarn, msg, kv)
+}
+
+// ErrorCtx queues msg to be written at LevelError with the fields extracted from ctx, see WithContextExtractor, and
+// the alternating keys and values in kv attached to it.
+func (al *Alog) ErrorCtx(ctx context.Context, msg string, kv ...any) error {
+	return al.logCtx(ctx, LevelError, msg, kv)
+}
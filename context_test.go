@@ -0,0 +1,52 @@
+package alog
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type ctxKey string
+
+func requestFields(ctx context.Context) map[string]any {
+	id, ok := ctx.Value(ctxKey("request")).(string)
+	if !ok {
+		return nil
+	}
+	return map[string]any{"request_id": id}
+}
+
+func TestContextExtractor(t *testing.T) {
+	b := &lockedBuffer{}
+	var calls int
+	alog := New(b, WithBufferSize(10), WithContextExtractor(requestFields),
+		WithContextExtractor(func(ctx context.Context) map[string]any {
+			calls++
+			if tenant, ok := ctx.Value(ctxKey("tenant")).(string); ok {
+				return map[string]any{"tenant": tenant, "request_id": "overridden"}
+			}
+			return nil
+		}))
+	go alog.Start()
+	ctx := context.WithValue(context.Background(), ctxKey("request"), "r-1")
+	alog.InfoCtx(ctx, "handled", "status", 200)
+	alog.WarnCtx(context.WithValue(ctx, ctxKey("tenant"), "acme"), "slow")
+	alog.ErrorCtx(context.Background(), "no values")
+	alog.InfoCtx(nil, "no context") // a nil context works like the plain call
+	alog.DebugCtx(ctx, "filtered")
+	alog.Stop()
+
+	want := []string{
+		"handled request_id=r-1 status=200",
+		"slow request_id=overridden tenant=acme",
+		"no values",
+		"no context",
+	}
+	if got := writtenMessages(b); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Got %q", got)
+	}
+	if calls != 3 {
+		t.Errorf("Expected the extractor to be called for the 3 contexts that weren't nil or filtered out, got %d",
+			calls)
+	}
+}
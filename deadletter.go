@@ -0,0 +1,108 @@
+package alog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DeadLetter is a message that couldn't be written, see WithDeadLetters.
+type DeadLetter struct {
+	Time      time.Time // when the write failed
+	Message   string    // the message's text
+	Formatted []byte    // the message as it was formatted for the writer passed to New
+	Err       error     // the write's error
+}
+
+// WithDeadLetters keeps the last capacity messages that failed to write, after any retries, see WithRetry, for
+// DeadLetters to return. A message counts as failed if any of its destinations returned an error, and the messages
+// of a failed batch, see WithBatching, are kept one by one. Once capacity messages are kept the oldest one is
+// dropped for each new one, and counted by DroppedDeadLetters. The dead letters are kept when the logger is
+// stopped, so they can still be retrieved afterwards.
+func WithDeadLetters(capacity int) Option {
+	return func(al *Alog) {
+		al.deadLetterBox().capacity = capacity
+	}
+}
+
+// WithDeadLetterFunc calls fn with every message that failed to write, as WithDeadLetters would keep it, whether or
+// not WithDeadLetters is used. fn is called on the goroutine that wrote the message, usually the Start loop, so it
+// must be fast, and a panic in fn is recovered and sent on the error channel as a *HookPanicError.
+func WithDeadLetterFunc(fn func(dl DeadLetter)) Option {
+	return func(al *Alog) {
+		al.deadLetterBox().fn = fn
+	}
+}
+
+// deadLetters holds the messages kept by WithDeadLetters.
+type deadLetters struct {
+	dropped  uint64 // accessed atomically
+	capacity int
+	fn       func(dl DeadLetter)
+
+	m       sync.Mutex
+	letters []DeadLetter
+}
+
+func (al *Alog) deadLetterBox() *deadLetters {
+	if al.deadLetters == nil {
+		al.deadLetters = &deadLetters{}
+	}
+	return al.deadLetters
+}
+
+// DeadLetters returns the messages kept by WithDeadLetters, oldest first, and forgets them.
+func (al *Alog) DeadLetters() []DeadLetter {
+	if al.deadLetters == nil {
+		return nil
+	}
+	dls := al.deadLetters
+	dls.m.Lock()
+	defer dls.m.Unlock()
+	letters := dls.letters
+	dls.letters = nil
+	return letters
+}
+
+// DroppedDeadLetters returns the number of dead letters that were dropped to make room for newer ones.
+func (al *Alog) DroppedDeadLetters() uint64 {
+	if al.deadLetters == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&al.deadLetters.dropped)
+}
+
+// deadLetter keeps the messages formatted into fb, which failed to write with err.
+func (al *Alog) deadLetter(fb *formatBuffer, err error) {
+	if len(fb.buf) == 0 {
+		al.appendFormatted(fb) // the destination is an EntryWriter and nothing else needed the formatted message
+	}
+	now := al.now()
+	start := 0
+	for _, s := range fb.spans {
+		formatted := append([]byte(nil), fb.buf[start:s.end]...)
+		al.keepDeadLetter(DeadLetter{Time: now, Message: s.message, Formatted: formatted, Err: err})
+		start = s.end
+	}
+}
+
+func (al *Alog) keepDeadLetter(dl DeadLetter) {
+	dls := al.deadLetters
+	if dls.capacity > 0 {
+		dls.m.Lock()
+		if len(dls.letters) >= dls.capacity {
+			dls.letters = dls.letters[1:]
+			atomic.AddUint64(&dls.dropped, 1)
+		}
+		dls.letters = append(dls.letters, dl)
+		dls.m.Unlock()
+	}
+	if dls.fn != nil {
+		al.callDeadLetterFunc(dl)
+	}
+}
+
+func (al *Alog) callDeadLetterFunc(dl DeadLetter) {
+	defer al.recoverHook("DeadLetter")
+	al.deadLetters.fn(dl)
+}
@@ -0,0 +1,123 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeadLetters(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alog := New(errorWriter{bytes.NewBuffer(nil)}, WithDeadLetters(2), WithBufferSize(10), WithErrorBuffer(10))
+	alog.now = newFakeClock(now).Now
+	go alog.Start()
+	alog.Info("a")
+	alog.Info("b")
+	alog.InfoKV("c", "user", 7)
+	alog.Stop()
+
+	dls := alog.DeadLetters()
+	if len(dls) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %d", len(dls))
+	}
+	for i, want := range []string{"b", "c"} {
+		dl := dls[i]
+		if dl.Message != want || !dl.Time.Equal(now) || dl.Err == nil || dl.Err.Error() != "error" {
+			t.Errorf("Dead letter %d is %+v", i, dl)
+		}
+	}
+	if got := string(dls[1].Formatted); !strings.HasSuffix(got, "[INFO] - c user=7\n") {
+		t.Errorf("Got formatted message %q", got)
+	}
+	if n := alog.DroppedDeadLetters(); n != 1 {
+		t.Errorf("Expected 1 dropped dead letter, got %d", n)
+	}
+	if dls := alog.DeadLetters(); len(dls) != 0 {
+		t.Errorf("Expected DeadLetters to drain the buffer, got %d", len(dls))
+	}
+}
+
+// failMatchingWriter fails the writes that contain bad.
+type failMatchingWriter struct {
+	bad string
+	b   *lockedBuffer
+}
+
+func (fw failMatchingWriter) Write(data []byte) (int, error) {
+	if bytes.Contains(data, []byte(fw.bad)) {
+		return 0, errors.New("rejected")
+	}
+	return fw.b.Write(data)
+}
+
+func TestDeadLettersOnlyFailures(t *testing.T) {
+	fw := failMatchingWriter{"bad", &lockedBuffer{}}
+	alog := New(fw, WithDeadLetters(10), WithBufferSize(10), WithErrorBuffer(10))
+	go alog.Start()
+	alog.Info("ok")
+	alog.Info("bad")
+	alog.Info("fine")
+	alog.Stop()
+
+	if got := writtenMessages(fw.b); strings.Join(got, ",") != "ok,fine" {
+		t.Errorf("Got %q", got)
+	}
+	if dls := alog.DeadLetters(); len(dls) != 1 || dls[0].Message != "bad" {
+		t.Errorf("Got %+v", dls)
+	}
+}
+
+func TestDeadLetterFunc(t *testing.T) {
+	var got []string
+	alog := New(errorWriter{bytes.NewBuffer(nil)}, WithBufferSize(10), WithErrorBuffer(10),
+		WithDeadLetterFunc(func(dl DeadLetter) {
+			got = append(got, dl.Message)
+			if dl.Message == "boom" {
+				panic("dead letter")
+			}
+		}))
+	go alog.Start()
+	alog.Info("boom")
+	alog.Info("after")
+	alog.Stop()
+
+	if strings.Join(got, ",") != "boom,after" {
+		t.Errorf("Got %q", got)
+	}
+	if dls := alog.DeadLetters(); len(dls) != 0 {
+		t.Errorf("Expected no dead letters to be kept without WithDeadLetters, got %d", len(dls))
+	}
+	var panics int
+	for len(alog.ErrorChannel()) > 0 {
+		var hpe *HookPanicError
+		if errors.As(<-alog.ErrorChannel(), &hpe) && hpe.Hook == "DeadLetter" {
+			panics++
+		}
+	}
+	if panics != 1 {
+		t.Errorf("Expected 1 recovered panic, got %d", panics)
+	}
+}
+
+func TestDeadLettersBatch(t *testing.T) {
+	fw := failingWriter{make(chan struct{})}
+	alog := New(fw, WithBufferSize(100), WithBatching(50, 0), WithDeadLetters(10), WithErrorBuffer(10))
+	go alog.Start()
+	queueBehind(t, alog, 3)
+	close(fw.open)
+	alog.Stop()
+
+	dls := alog.DeadLetters()
+	if len(dls) != 4 {
+		t.Fatalf("Expected 4 dead letters, got %d", len(dls))
+	}
+	for i, dl := range dls {
+		want := "m" + strconv.Itoa(i)
+		if dl.Message != want || !strings.HasSuffix(string(dl.Formatted), "] - "+want+"\n") {
+			t.Errorf("Dead letter %d is %q formatted as %q", i, dl.Message, dl.Formatted)
+		}
+	}
+}
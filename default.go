@@ -0,0 +1,118 @@
+package alog
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	defaultLogger atomic.Pointer[Alog]
+	defaultM      sync.Mutex // serializes creating the default logger
+)
+
+// Default returns the logger the package-level functions, such as Info and Write, log through. Unless SetDefault
+// replaced it, it's created with New(os.Stdout) and started the first time it's needed, which may be from an init
+// function or from several goroutines at once. Once the default logger has been stopped it stays the default, and
+// handles messages according to its LatePolicy, like any stopped logger.
+func Default() *Alog {
+	if al := defaultLogger.Load(); al != nil {
+		return al
+	}
+	defaultM.Lock()
+	defer defaultM.Unlock()
+	if al := defaultLogger.Load(); al != nil {
+		return al
+	}
+	al := New(os.Stdout)
+	go al.Start()
+	defaultLogger.Store(al)
+	return al
+}
+
+// SetDefault makes al the logger the package-level functions log through. al isn't started, so start it first
+// unless it's been started already. SetDefault(nil) forgets the default logger, without stopping it, so that the
+// next package-level call creates a new one.
+func SetDefault(al *Alog) {
+	defaultM.Lock()
+	defer defaultM.Unlock()
+	defaultLogger.Store(al)
+}
+
+// SetOutput replaces the writer of the default logger, see Alog.SetOutput.
+func SetOutput(w io.Writer) io.Writer {
+	return Default().SetOutput(w)
+}
+
+// SetLevel changes the level of the default logger, see Alog.SetLevel.
+func SetLevel(l Level) {
+	Default().SetLevel(l)
+}
+
+// Stop stops the default logger, writing everything it has queued first, see Alog.Stop. It does nothing if the
+// default logger hasn't been used.
+func Stop() {
+	if al := defaultLogger.Load(); al != nil {
+		al.Stop()
+	}
+}
+
+// Flush waits until everything logged through the default logger has been written, see Alog.Flush. It does nothing
+// if the default logger hasn't been used.
+func Flush(ctx context.Context) error {
+	if al := defaultLogger.Load(); al != nil {
+		return al.Flush(ctx)
+	}
+	return nil
+}
+
+// Write writes msg synchronously with the default logger, see Alog.Write.
+func Write(msg string) (int, error) {
+	al := Default()
+	if !al.enabled(LevelInfo) {
+		return 0, nil
+	}
+	return al.writeSync(msg)
+}
+
+// Debug queues msg to be written by the default logger at LevelDebug.
+func Debug(msg string) error {
+	return Default().log(LevelDebug, msg, nil)
+}
+
+// Debugf formats the message like fmt.Sprintf and queues it to be written by the default logger at LevelDebug.
+func Debugf(format string, args ...any) error {
+	return Default().logf(LevelDebug, format, args...)
+}
+
+// Info queues msg to be written by the default logger at LevelInfo.
+func Info(msg string) error {
+	return Default().log(LevelInfo, msg, nil)
+}
+
+// Infof formats the message like fmt.Sprintf and queues it to be written by the default logger at LevelInfo.
+func Infof(format string, args ...any) error {
+	return Default().logf(LevelInfo, format, args...)
+}
+
+// Warn queues msg to be written by the default logger at LevelWarn.
+func Warn(msg string) error {
+	return Default().log(LevelWarn, msg, nil)
+}
+
+// Warnf formats the message like fmt.Sprintf and queues it to be written by the default logger at LevelWarn.
+func Warnf(format string, args ...any) error {
+	return Default().logf(LevelWarn, format, args...)
+}
+
+// Error queues msg to be written by the default logger at LevelError.
+func Error(msg string) error {
+	return Default().log(LevelError, msg, nil)
+}
+
+// Errorf formats the message like fmt.Sprintf and queues it to be written by the default logger at LevelError.
+func Errorf(format string, args ...any) error {
+	return Default().logf(LevelError, format, args...)
+}
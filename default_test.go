@@ -0,0 +1,73 @@
+package alog
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDefaultConcurrentFirstUse(t *testing.T) {
+	SetDefault(nil)
+	t.Cleanup(func() {
+		Stop()
+		SetDefault(nil)
+	})
+	const n = 20
+	loggers := make([]*Alog, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				SetLevel(LevelWarn)
+			}
+			loggers[i] = Default()
+		}(i)
+	}
+	wg.Wait()
+	for i, al := range loggers {
+		if al != loggers[0] {
+			t.Fatalf("Goroutine %d got a different default logger", i)
+		}
+	}
+
+	lb := &lockedBuffer{}
+	SetOutput(lb)
+	Info("below the level")
+	Warn("queued")
+	if err := Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := writtenMessages(lb); strings.Join(got, ",") != "queued" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	lb := &lockedBuffer{}
+	al := New(lb, WithBufferSize(10))
+	go al.Start()
+	SetDefault(al)
+	t.Cleanup(func() { SetDefault(nil) })
+	if Default() != al {
+		t.Fatal("Default didn't return the logger passed to SetDefault")
+	}
+	Infof("queued %d", 1)
+	Stop()
+	if _, err := Write("after stop"); err != ErrLoggerStopped {
+		t.Errorf("Got %v", err)
+	}
+	if got := writtenMessages(lb); strings.Join(got, ",") != "queued 1" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestDefaultUnused(t *testing.T) {
+	SetDefault(nil)
+	Stop()
+	if err := Flush(context.Background()); err != nil || defaultLogger.Load() != nil {
+		t.Errorf("Expected Stop and Flush to leave an unused default logger alone, got %v", err)
+	}
+}
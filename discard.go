@@ -0,0 +1,55 @@
+package alog
+
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// Discard is a destination that drops everything written to it but counts the messages and bytes, for running the
+// whole logger without output, in benchmarks or as a silent mode that still shows how much would have been logged.
+// The zero value is ready to use, see also WithDiscard, and it's safe for concurrent use.
+type Discard struct {
+	messages uint64
+	bytes    uint64
+}
+
+// Write implements io.Writer. Each line in data counts as a message, so a batch from WithBatching counts as all of
+// its messages.
+func (d *Discard) Write(data []byte) (int, error) {
+	atomic.AddUint64(&d.messages, uint64(countMessages(data)))
+	atomic.AddUint64(&d.bytes, uint64(len(data)))
+	return len(data), nil
+}
+
+// Count returns the number of messages written.
+func (d *Discard) Count() uint64 {
+	return atomic.LoadUint64(&d.messages)
+}
+
+// Bytes returns the number of bytes written.
+func (d *Discard) Bytes() uint64 {
+	return atomic.LoadUint64(&d.bytes)
+}
+
+// WithDiscard replaces the writer passed to New with d, or a Discard of the logger's own if d is nil, so messages
+// go through the whole logger and are dropped at the end.
+func WithDiscard(d *Discard) Option {
+	return func(al *Alog) {
+		if d == nil {
+			d = &Discard{}
+		}
+		al.dest = d
+	}
+}
+
+// countMessages returns the number of messages in data written by the logger: one per line, or one if it's a
+// single line without a newline.
+func countMessages(data []byte) int {
+	if len(data) == 0 {
+		return 0
+	}
+	if n := bytes.Count(data, []byte{'\n'}); n > 0 {
+		return n
+	}
+	return 1
+}
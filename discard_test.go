@@ -0,0 +1,73 @@
+package alog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiscard(t *testing.T) {
+	d := &Discard{}
+	alog := New(nil, WithDiscard(d), WithWorkers(4), WithBufferSize(100))
+	go alog.Start()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				alog.Info("message")
+				alog.Write("sync")
+			}
+		}()
+	}
+	wg.Wait()
+	alog.Stop()
+	if n := d.Count(); n != 2000 {
+		t.Errorf("Expected 2000 messages, got %d", n)
+	}
+}
+
+func TestDiscardBytes(t *testing.T) {
+	d, b := &Discard{}, bytes.NewBuffer([]byte{})
+	for _, w := range []io.Writer{d, b} {
+		alog := New(w)
+		alog.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Now
+		alog.Write("one")
+		alog.Write("two")
+	}
+	if d.Bytes() != uint64(b.Len()) || d.Count() != 2 {
+		t.Errorf("Expected 2 messages of %d bytes, got %d of %d", b.Len(), d.Count(), d.Bytes())
+	}
+}
+
+func TestDiscardBatch(t *testing.T) {
+	d := &Discard{}
+	alog := New(d, WithBatching(10, 0), WithBufferSize(10))
+	for i := 0; i < 10; i++ {
+		alog.Info("batched")
+	}
+	go alog.Start()
+	alog.Stop()
+	if n := d.Count(); n != 10 {
+		t.Errorf("Expected a batch to count as 10 messages, got %d", n)
+	}
+}
+
+// BenchmarkDiscardPipeline measures the whole logger, queue, formatting and write, with nothing but counting at the
+// end.
+func BenchmarkDiscardPipeline(b *testing.B) {
+	d := &Discard{}
+	alog := New(nil, WithDiscard(d), WithBufferSize(1000))
+	go alog.Start()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			alog.Info("a message of a typical length for a log")
+		}
+	})
+	alog.Stop()
+	b.ReportMetric(float64(d.Bytes())/float64(b.N), "B/msg")
+}
@@ -0,0 +1,83 @@
+package alog
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// WithSync makes the FileWriter sync the file to disk, so messages survive a crash or power loss once they're
+// written: after every message if every and interval are 0, or else once every messages have been written since
+// the last sync, or interval after the first message that hasn't been synced, whichever comes first. Either can be 0
+// or less to leave it out. A write of several messages, such as a batch from WithBatching, is synced as a whole.
+//
+// The file is also synced before it's closed or rotated and when the logger is flushed or stopped, so Flush and Stop
+// return only once the messages before them are on disk. Errors from syncing are returned by Write, or by the next
+// Write if the sync ran on a timer, so they reach the logger's error channel; SyncErrors counts them.
+func WithSync(every int, interval time.Duration) FileOption {
+	return func(fw *FileWriter) {
+		fw.durable = true
+		fw.syncEvery = every
+		fw.syncInterval = interval
+		if every <= 0 && interval <= 0 {
+			fw.syncEvery = 1
+		}
+	}
+}
+
+// SyncErrors returns the number of times syncing the file failed, see WithSync.
+func (fw *FileWriter) SyncErrors() uint64 {
+	return atomic.LoadUint64(&fw.syncErrors)
+}
+
+// Flush syncs the file if WithSync is used. A logger writing to the FileWriter calls it from its own Flush.
+func (fw *FileWriter) Flush() error {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	return fw.sync()
+}
+
+// wrote counts the messages in data towards the next sync and syncs the file if it's due. The caller holds m.
+func (fw *FileWriter) wrote(data []byte) error {
+	fw.unsynced += countMessages(data)
+	if fw.syncEvery > 0 && fw.unsynced >= fw.syncEvery {
+		return fw.sync()
+	}
+	if fw.syncInterval > 0 && fw.syncTimer == nil {
+		fw.syncTimer = time.AfterFunc(fw.syncInterval, fw.syncLater)
+	}
+	return nil
+}
+
+// sync syncs the file if anything was written to it since the last sync. The caller holds m.
+func (fw *FileWriter) sync() error {
+	if fw.syncTimer != nil {
+		fw.syncTimer.Stop()
+		fw.syncTimer = nil
+	}
+	if fw.f == nil || fw.unsynced == 0 {
+		return nil
+	}
+	// A failed fsync can't be retried usefully: the kernel may have dropped the dirty pages already.
+	fw.unsynced = 0
+	if err := fw.fsync(fw.f); err != nil {
+		atomic.AddUint64(&fw.syncErrors, 1)
+		return err
+	}
+	return nil
+}
+
+// syncLater runs on syncTimer.
+func (fw *FileWriter) syncLater() {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	fw.syncTimer = nil
+	if err := fw.sync(); err != nil {
+		fw.addBackgroundErr(err)
+	}
+}
+
+// syncFile is FileWriter.fsync unless a test replaces it.
+func syncFile(f *os.File) error {
+	return f.Sync()
+}
@@ -0,0 +1,128 @@
+package alog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countSyncs replaces fw's fsync with one that counts the calls and returns err.
+func countSyncs(fw *FileWriter, err error) *int32 {
+	var n int32
+	fw.fsync = func(*os.File) error {
+		atomic.AddInt32(&n, 1)
+		return err
+	}
+	return &n
+}
+
+func TestFileWriterSyncEveryMessage(t *testing.T) {
+	fw := NewFileWriter(filepath.Join(t.TempDir(), "app.log"), WithSync(0, 0))
+	syncs := countSyncs(fw, nil)
+	alog := New(fw, WithBufferSize(10))
+	go alog.Start()
+	for i := 0; i < 5; i++ {
+		alog.Info("audit")
+	}
+	alog.Stop()
+	fw.Close()
+	if n := atomic.LoadInt32(syncs); n != 5 {
+		t.Errorf("Expected 5 syncs, got %d", n)
+	}
+}
+
+func TestFileWriterSyncEveryN(t *testing.T) {
+	fw := NewFileWriter(filepath.Join(t.TempDir(), "app.log"), WithSync(3, 0))
+	syncs := countSyncs(fw, nil)
+	for i := 0; i < 7; i++ {
+		if _, err := fw.Write([]byte("audit\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(syncs); n != 2 {
+		t.Errorf("Expected 2 syncs after 7 messages, got %d", n)
+	}
+	if err := fw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(syncs); n != 3 {
+		t.Errorf("Expected Flush to sync the last message once, got %d syncs", n)
+	}
+}
+
+func TestFileWriterSyncBatch(t *testing.T) {
+	fw := NewFileWriter(filepath.Join(t.TempDir(), "app.log"), WithSync(5, 0))
+	syncs := countSyncs(fw, nil)
+	alog := New(fw, WithBatching(10, 0), WithBufferSize(10))
+	for i := 0; i < 10; i++ {
+		alog.Info("audit")
+	}
+	go alog.Start()
+	alog.Stop()
+	if n := atomic.LoadInt32(syncs); n != 1 {
+		t.Errorf("Expected one sync for the batch, got %d", n)
+	}
+}
+
+func TestFileWriterSyncInterval(t *testing.T) {
+	fw := NewFileWriter(filepath.Join(t.TempDir(), "app.log"), WithSync(0, 10*time.Millisecond))
+	syncs := countSyncs(fw, nil)
+	if _, err := fw.Write([]byte("audit\n")); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(syncs); n != 0 {
+		t.Errorf("Expected no sync before the interval, got %d", n)
+	}
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(syncs) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("File wasn't synced after the interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	fw.Close()
+	if n := atomic.LoadInt32(syncs); n != 1 {
+		t.Errorf("Expected Close not to sync again, got %d syncs", n)
+	}
+}
+
+func TestFileWriterSyncOnFlushAndStop(t *testing.T) {
+	fw := NewFileWriter(filepath.Join(t.TempDir(), "app.log"), WithSync(100, 0))
+	syncs := countSyncs(fw, nil)
+	alog := New(fw, WithBuffering(4096, 0))
+	go alog.Start()
+	alog.Info("one")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(syncs); n != 1 {
+		t.Errorf("Expected Flush to sync, got %d syncs", n)
+	}
+	alog.Info("two")
+	alog.Stop()
+	if n := atomic.LoadInt32(syncs); n != 2 {
+		t.Errorf("Expected Stop to sync, got %d syncs", n)
+	}
+}
+
+func TestFileWriterSyncError(t *testing.T) {
+	fw := NewFileWriter(filepath.Join(t.TempDir(), "app.log"), WithSync(0, 0))
+	countSyncs(fw, errors.New("input/output error"))
+	alog := New(fw)
+	go alog.Start()
+	alog.Info("audit")
+	alog.Stop()
+	if err := <-alog.ErrorChannel(); err == nil || err.Error() != "input/output error" {
+		t.Errorf("Expected the sync error, got %v", err)
+	}
+	if n := fw.SyncErrors(); n != 1 {
+		t.Errorf("Expected 1 sync error, got %d", n)
+	}
+}
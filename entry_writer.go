@@ -0,0 +1,26 @@
+package alog
+
+import "io"
+
+// EntryWriter is implemented by destinations that do their own formatting, such as SyslogWriter. If the writer
+// passed to New is an EntryWriter, it's given each entry, with its time and static fields set, instead of the
+// formatted line. The other destinations still get formatted lines. WriteEntry must not retain e, and batching
+// with WithBatching isn't used for such a logger.
+type EntryWriter interface {
+	WriteEntry(e *Entry) error
+}
+
+// writeEntryDest hands the entry held by fb to entryDest and writes it, formatted, to the other destinations if
+// there are any, or AfterWrite hooks to pass it to.
+func (al *Alog) writeEntryDest(fb *formatBuffer) (int, error) {
+	err := al.entryDest.WriteEntry(&fb.e)
+	if al.dest == io.Discard && len(al.levelWriters) == 0 {
+		if len(al.afterWrite) > 0 {
+			al.appendFormatted(fb)
+		}
+		return 0, err
+	}
+	al.appendFormatted(fb)
+	n, destErr := al.writeFormatted(fb)
+	return n, joinErrors([]error{err, destErr})
+}
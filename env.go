@@ -0,0 +1,59 @@
+package alog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// NewFromEnv creates a logger like New, with the configuration in these environment variables applied on top of
+// opts:
+//
+//   - ALOG_LEVEL: trace, debug, info, warn or error, see WithLevel.
+//   - ALOG_FORMAT: text, json or logfmt. text is the default TextFormatter, even if opts set another formatter.
+//   - ALOG_TIMESTAMP: the timestamp layout, see WithTimestampFormat, or off to leave timestamps out.
+//   - ALOG_OUTPUT: stdout, stderr or the path of a file to write to instead of w. The file is opened, or created,
+//     straight away and written by a FileWriter with its default rotation. Close closes it.
+//
+// Names and values aren't case sensitive, apart from layouts and paths, and an empty variable counts as unset. An
+// invalid value is an error that names the variable, and NewFromEnv returns no logger then.
+func NewFromEnv(w io.Writer, opts ...Option) (*Alog, error) {
+	opts = append([]Option(nil), opts...)
+	if v := os.Getenv("ALOG_LEVEL"); v != "" {
+		l, ok := levelNames[strings.ToLower(v)]
+		if !ok {
+			return nil, fmt.Errorf("alog: invalid ALOG_LEVEL %q, want trace, debug, info, warn or error", v)
+		}
+		opts = append(opts, WithLevel(l))
+	}
+	if v := os.Getenv("ALOG_FORMAT"); v != "" {
+		f, ok := formatNames[strings.ToLower(v)]
+		if !ok {
+			return nil, fmt.Errorf("alog: invalid ALOG_FORMAT %q, want text, json or logfmt", v)
+		}
+		opts = append(opts, WithFormatter(f)) // a nil formatter makes New use the default one
+	}
+	if v := os.Getenv("ALOG_TIMESTAMP"); v != "" {
+		if strings.EqualFold(v, "off") {
+			v = ""
+		}
+		opts = append(opts, WithTimestampFormat(v))
+	}
+	if v := os.Getenv("ALOG_OUTPUT"); v != "" {
+		switch strings.ToLower(v) {
+		case "stdout":
+			w = os.Stdout
+		case "stderr":
+			w = os.Stderr
+		default:
+			fw, err := openFileWriter(v)
+			if err != nil {
+				return nil, fmt.Errorf("alog: invalid ALOG_OUTPUT: %w", err)
+			}
+			w = fw
+			opts = append(opts, WithCloseWriter(true))
+		}
+	}
+	return New(w, opts...), nil
+}
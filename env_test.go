@@ -0,0 +1,105 @@
+package alog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("ALOG_LEVEL", "DEBUG")
+	t.Setenv("ALOG_FORMAT", "logfmt")
+	t.Setenv("ALOG_TIMESTAMP", "off")
+	lb := &lockedBuffer{}
+	alog, err := NewFromEnv(lb, WithLevel(LevelError), WithJSONFormat(), WithBufferSize(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alog.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Now
+	go alog.Start()
+	alog.Debug("env wins")
+	alog.Stop()
+	if got, want := lb.String(), "time=2024-05-01T12:00:00Z level=debug msg=\"env wins\"\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestNewFromEnvText(t *testing.T) {
+	t.Setenv("ALOG_FORMAT", "text")
+	t.Setenv("ALOG_TIMESTAMP", "15:04")
+	lb := &lockedBuffer{}
+	alog, err := NewFromEnv(lb, WithJSONFormat(), WithTimestampFormat(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alog.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Now
+	if _, err := alog.Write("text"); err != nil {
+		t.Fatal(err)
+	}
+	if got := lb.String(); got != "[12:00] - text\n" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestNewFromEnvUnset(t *testing.T) {
+	t.Setenv("ALOG_LEVEL", "")
+	lb := &lockedBuffer{}
+	alog, err := NewFromEnv(lb, WithLevel(LevelWarn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alog.Level() != LevelWarn || alog.output.w != lb {
+		t.Errorf("Expected the code's configuration without environment variables, got %v writing to %T",
+			alog.Level(), alog.output.w)
+	}
+}
+
+func TestNewFromEnvOutput(t *testing.T) {
+	t.Setenv("ALOG_OUTPUT", "Stderr")
+	alog, err := NewFromEnv(nil)
+	if err != nil || alog.output.w != os.Stderr {
+		t.Fatalf("Got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	t.Setenv("ALOG_OUTPUT", path)
+	t.Setenv("ALOG_TIMESTAMP", "off")
+	alog, err = NewFromEnv(&lockedBuffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the file to be created straight away: %v", err)
+	}
+	if _, err := alog.Write("to the file"); err != nil {
+		t.Fatal(err)
+	}
+	if err := alog.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "to the file\n" {
+		t.Errorf("Got %q, %v", data, err)
+	}
+}
+
+func TestNewFromEnvInvalid(t *testing.T) {
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ name, value string }{
+		{"ALOG_LEVEL", "verbose"},
+		{"ALOG_FORMAT", "xml"},
+		{"ALOG_OUTPUT", filepath.Join(notDir, "app.log")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			alog, err := NewFromEnv(nil)
+			if err == nil || alog != nil || !strings.Contains(err.Error(), tt.name) {
+				t.Errorf("Got %v, %v", alog, err)
+			}
+		})
+	}
+}
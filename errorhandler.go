@@ -0,0 +1,53 @@
+package alog
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// WithErrorHandler calls fn with every error that would be sent on the error channel, such as write errors, so
+// nothing has to receive from ErrorChannel. The errors are sent on the channel as well once ErrorChannel has been
+// called. fn is called on the goroutine that ran into the error, usually the Start loop or a worker, so it may
+// be called concurrently and it must be fast. A panic in fn is recovered and printed to os.Stderr.
+func WithErrorHandler(fn func(err error)) Option {
+	return func(al *Alog) {
+		al.errorHandler = fn
+	}
+}
+
+// handleError passes err to the error handler, recovering if it panics.
+func (al *Alog) handleError(err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			fmt.Fprintf(al.stderr, "alog: error handler panicked: %v, handling: %v\n", v, err)
+		}
+	}()
+	al.errorHandler(err)
+}
+
+// RecentErrors returns the last errors that were reported, oldest first, whether or not ErrorChannel has been
+// called. It keeps as many as the error channel's capacity, see WithErrorBuffer, but at least 16.
+func (al *Alog) RecentErrors() []error {
+	al.errM.Lock()
+	defer al.errM.Unlock()
+	return append([]error(nil), al.recentErrors...)
+}
+
+// ErrorCount returns the number of errors that were reported, including those that were discarded.
+func (al *Alog) ErrorCount() uint64 {
+	return atomic.LoadUint64(&al.reported)
+}
+
+// recordError counts err and keeps it for RecentErrors. It reports whether ErrorChannel has been called, so err
+// should be sent on the channel.
+func (al *Alog) recordError(err error) bool {
+	atomic.AddUint64(&al.reported, 1)
+	al.errM.Lock()
+	defer al.errM.Unlock()
+	if len(al.recentErrors) >= max(al.errorBufferSize, defaultErrorBufferSize) {
+		al.recentErrors = al.recentErrors[1:]
+	}
+	al.recentErrors = append(al.recentErrors, err)
+	al.lastErrorTime = al.now()
+	return al.errorChUsed
+}
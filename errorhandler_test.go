@@ -0,0 +1,111 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// echoErrWriter fails every write with an error holding the line.
+type echoErrWriter struct{}
+
+func (echoErrWriter) Write(data []byte) (int, error) {
+	return 0, errors.New(strings.TrimSpace(string(data)))
+}
+
+func TestErrorHandler(t *testing.T) {
+	var m sync.Mutex
+	seen := map[string]int{}
+	alog := New(echoErrWriter{}, WithWorkers(4), WithBufferSize(100),
+		WithErrorHandler(func(err error) {
+			m.Lock()
+			seen[err.Error()]++
+			m.Unlock()
+		}))
+	go alog.Start()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				alog.Infof("%d-%d", g, i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	alog.Stop()
+
+	if len(seen) != 1000 {
+		t.Errorf("Expected 1000 distinct errors, got %d", len(seen))
+	}
+	for err, n := range seen {
+		if n != 1 {
+			t.Errorf("Error %q handled %d times", err, n)
+		}
+	}
+	if n := len(alog.errorCh); n != 0 {
+		t.Errorf("Expected nothing on the error channel that was never asked for, got %d errors", n)
+	}
+}
+
+func TestErrorHandlerAndChannel(t *testing.T) {
+	var handled int
+	alog := New(errorWriter{bytes.NewBuffer(nil)}, WithErrorHandler(func(err error) { handled++ }))
+	errCh := alog.ErrorChannel()
+	go alog.Start()
+	alog.Info("fails")
+	alog.Stop()
+
+	if handled != 1 || len(errCh) != 1 {
+		t.Errorf("Expected the error to be handled and sent, got %d handled and %d sent", handled, len(errCh))
+	}
+}
+
+func TestErrorHandlerPanic(t *testing.T) {
+	stderr := &lockedBuffer{}
+	var handled int
+	alog := New(errorWriter{bytes.NewBuffer(nil)}, WithBufferSize(10), WithErrorHandler(func(err error) {
+		handled++
+		if handled == 1 {
+			panic("handler broke")
+		}
+	}))
+	alog.stderr = stderr
+	go alog.Start()
+	alog.Info("one")
+	alog.Info("two")
+	alog.Stop()
+
+	if handled != 2 {
+		t.Errorf("Expected both errors to be handled, got %d", handled)
+	}
+	if got := stderr.String(); got != "alog: error handler panicked: handler broke, handling: error\n" {
+		t.Errorf("Got %q on stderr", got)
+	}
+}
+
+func TestRecentErrors(t *testing.T) {
+	alog := New(nil, WithErrorBuffer(20))
+	for i := 0; i < 30; i++ {
+		alog.reportError(errors.New(strconv.Itoa(i)))
+	}
+	recent := alog.RecentErrors()
+	if len(recent) != 20 || recent[0].Error() != "10" || recent[19].Error() != "29" {
+		t.Fatalf("Expected errors 10 to 29, got %v", recent)
+	}
+	if len(alog.errorCh) != 0 {
+		t.Error("Expected nothing on the channel before it was asked for")
+	}
+	errCh := alog.ErrorChannel()
+	alog.reportError(errors.New("30"))
+	if len(errCh) != 20 || (<-errCh).Error() != "11" {
+		t.Errorf("Expected the kept errors, less the oldest, and the new one on the channel")
+	}
+	if n := alog.ErrorCount(); n != 31 {
+		t.Errorf("Expected 31 errors, got %d", n)
+	}
+}
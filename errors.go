@@ -0,0 +1,93 @@
+package alog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrLoggerStopped is returned by Write and the level methods for messages that were dropped because they were
+// logged after Stop.
+var ErrLoggerStopped = errors.New("alog: logger stopped")
+
+// ErrDropped is returned by the level methods for messages that were dropped because the queue was full, see
+// WithOverflowPolicy. Flush returns it if it lost its place in the queue to newer messages.
+var ErrDropped = errors.New("alog: message dropped, queue full")
+
+// ErrRateLimited is returned by the level methods when a message is dropped because of WithRateLimit.
+var ErrRateLimited = errors.New("alog: message dropped, rate limit exceeded")
+
+// ErrCircuitOpen is returned by Write when the message wasn't written because the circuit breaker set up with
+// WithCircuitBreaker is open.
+var ErrCircuitOpen = errors.New("alog: circuit open, message not written")
+
+// ErrAlreadyStarted is returned by Start if the logger's loop is already running.
+var ErrAlreadyStarted = errors.New("alog: logger already started")
+
+// StopError is returned by StopContext and StopTimeout when they give up before every pending message was written.
+type StopError struct {
+	Pending int   // messages that were queued or being written
+	Err     error // why StopContext gave up, usually the context's error
+}
+
+func (e *StopError) Error() string {
+	return fmt.Sprintf("alog: stopped with %d messages pending: %v", e.Pending, e.Err)
+}
+
+func (e *StopError) Unwrap() error {
+	return e.Err
+}
+
+// DestinationError is the error from one of the destinations set up with WithAdditionalWriter, WithLevelWriter or
+// WithSink.
+// If several destinations fail to write the same message their errors are sent together, joined with errors.Join.
+type DestinationError struct {
+	Dest   int       // position of the destination, 0 for the writer passed to New, see WithLevelWriter and WithSink
+	Writer io.Writer // the destination itself, unless it's a sink
+	Sink   Sink      // the sink, if it's one
+	Err    error
+}
+
+func (e *DestinationError) Error() string {
+	return fmt.Sprintf("alog: destination %d: %v", e.Dest, e.Err)
+}
+
+func (e *DestinationError) Unwrap() error {
+	return e.Err
+}
+
+// WriteError is returned by Write, and sent on the error channel, when a message couldn't be written to one of its
+// destinations. It carries the message, so it can be logged again or reported elsewhere. A batch of more than one
+// message that fails, see WithBatching, is reported as a *BatchError instead, and WithDeadLetters keeps its messages.
+type WriteError struct {
+	Msg       string    // the message's text
+	Formatted []byte    // the message as it was formatted for the writer passed to New
+	Time      time.Time // the message's timestamp
+	Err       error     // the destination's error
+}
+
+// Error returns the destination's error's text, which already says what went wrong, so wrapping it doesn't change
+// how it's reported.
+func (e *WriteError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is sent on the error channel when a batch of messages written with a single call to the destination,
+// see WithBatching, fails. It's sent once for the whole batch.
+type BatchError struct {
+	Messages int   // messages in the batch
+	Err      error // the destination's error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("alog: writing batch of %d messages: %v", e.Messages, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,74 @@
+package alog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errDiskFull = errors.New("disk full")
+
+// sentinelWriter fails every write with errDiskFull.
+type sentinelWriter struct{}
+
+func (sentinelWriter) Write(data []byte) (int, error) {
+	return 0, errDiskFull
+}
+
+func TestWriteErrorAsync(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alog := New(sentinelWriter{}, WithErrorBuffer(10))
+	alog.now = newFakeClock(now).Now
+	go alog.Start()
+	alog.InfoKV("order placed", "id", 42)
+	alog.Stop()
+
+	err := <-alog.ErrorChannel()
+	if !errors.Is(err, errDiskFull) {
+		t.Errorf("Expected errors.Is to find the writer's error in %v", err)
+	}
+	var we *WriteError
+	if !errors.As(err, &we) {
+		t.Fatalf("Expected a WriteError, got %T", err)
+	}
+	if we.Msg != "order placed" || !we.Time.Equal(now) || err.Error() != "disk full" {
+		t.Errorf("Got %+v", we)
+	}
+	if got := string(we.Formatted); !strings.HasSuffix(got, "[INFO] - order placed id=42\n") {
+		t.Errorf("Got formatted message %q", got)
+	}
+}
+
+func TestWriteErrorSync(t *testing.T) {
+	alog := New(sentinelWriter{})
+	_, err := alog.Write("sync")
+	var we *WriteError
+	if !errors.As(err, &we) || we.Msg != "sync" || !errors.Is(err, errDiskFull) {
+		t.Errorf("Got %v", err)
+	}
+}
+
+func TestWriteErrorEntryWriter(t *testing.T) {
+	alog := New(&recordingEntryWriter{recordingSink{err: errDiskFull}})
+	_, err := alog.Write("entry")
+	var we *WriteError
+	if !errors.As(err, &we) || !errors.Is(err, errDiskFull) {
+		t.Fatalf("Got %v", err)
+	}
+	if we.Msg != "entry" || !strings.HasSuffix(string(we.Formatted), "] - entry\n") {
+		t.Errorf("Got %q formatted as %q", we.Msg, we.Formatted)
+	}
+}
+
+func TestWriteErrorBatch(t *testing.T) {
+	alog := New(sentinelWriter{}, WithBatching(50, 0), WithErrorBuffer(10))
+	go alog.Start()
+	alog.Info("alone")
+	alog.Stop()
+
+	var we *WriteError
+	if err := <-alog.ErrorChannel(); !errors.As(err, &we) || we.Msg != "alone" || !errors.Is(err, errDiskFull) {
+		t.Errorf("Got %v", err)
+	}
+}
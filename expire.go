@@ -0,0 +1,64 @@
+package alog
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithMaxAge makes the Start loop drop messages that waited in the queue for longer than d before their turn came,
+// such as metrics that are worthless once they're stale, rather than write them behind fresher ones. A message's
+// age is taken from its Time, which the level methods set when it's logged; messages without a time, and those at
+// LevelError and above unless WithMaxAgeExempt says otherwise, never expire. Expired messages are counted by Expired
+// and, with WithExpiryReport, summarized. A d of 0 or less turns expiry off, which is the default.
+func WithMaxAge(d time.Duration) Option {
+	return func(al *Alog) {
+		al.maxAge = d
+	}
+}
+
+// WithMaxAgeExempt sets the level from which messages never expire under WithMaxAge. The default is LevelError;
+// pass a level above LevelFatal to let every message expire.
+func WithMaxAgeExempt(l Level) Option {
+	return func(al *Alog) {
+		al.maxAgeExempt = l
+	}
+}
+
+// WithExpiryReport makes the logger write a warning saying how many messages expired, see WithMaxAge, each time the
+// queue has been emptied after some did.
+func WithExpiryReport() Option {
+	return func(al *Alog) {
+		al.expiryReport = true
+	}
+}
+
+// Expired returns the number of messages that were dropped because they waited too long, see WithMaxAge.
+func (al *Alog) Expired() uint64 {
+	return atomic.LoadUint64(&al.expired)
+}
+
+// expire reports whether e has waited longer than WithMaxAge allows, and counts it if so. It's only called by the
+// Start loop, before e is written.
+func (al *Alog) expire(e *Entry) bool {
+	if al.maxAge <= 0 || e.Level >= al.maxAgeExempt || e.Time.IsZero() || al.now().Sub(e.Time) <= al.maxAge {
+		return false
+	}
+	atomic.AddUint64(&al.expired, 1)
+	al.expiredUnseen++
+	al.consumed(e.walEnd)
+	return true
+}
+
+// reportExpired writes a warning about the messages that expired, if WithExpiryReport was used, there are any and
+// the queue has been emptied.
+func (al *Alog) reportExpired(wg *sync.WaitGroup) {
+	if !al.expiryReport || al.expiredUnseen == 0 || len(al.entryCh) > 0 || len(al.priorityCh) > 0 ||
+		al.ringLen() > 0 || al.spillLen() > 0 {
+		return
+	}
+	n := al.expiredUnseen
+	al.expiredUnseen = 0
+	al.process(Entry{Level: LevelWarn, Message: strconv.Itoa(n) + " messages expired"}, wg)
+}
@@ -0,0 +1,78 @@
+package alog
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxAge(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 10), WithMaxAge(30*time.Second), WithExpiryReport(),
+		WithTimestampFormat(""))
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog.now = clock.Now
+	go alog.Start()
+	queueBehind(t, alog, 3)
+	alog.Error("still relevant")
+	clock.advance(31 * time.Second)
+	alog.Info("fresh")
+	close(gw.open)
+	alog.Stop()
+
+	want := "[INFO] - m0\n[ERROR] - still relevant\n[INFO] - fresh\n[WARN] - 3 messages expired\n"
+	if got := gw.b.String(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+	if n := alog.Expired(); n != 3 {
+		t.Errorf("Expected 3 expired messages, got %d", n)
+	}
+	if n := alog.Stats().MessagesDropped; n != 3 {
+		t.Errorf("Expected the expired messages to count as dropped, got %d", n)
+	}
+}
+
+func TestMaxAgeExempt(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 10), WithMaxAge(time.Second), WithMaxAgeExempt(LevelFatal+1))
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog.now = clock.Now
+	go alog.Start()
+	queueBehind(t, alog, 1)
+	alog.Error("stale error")
+	clock.advance(2 * time.Second)
+	close(gw.open)
+	alog.Stop()
+
+	if got := writtenMessages(gw.b); len(got) != 1 || got[0] != "m0" {
+		t.Errorf("Expected only m0 to be written, got %q", got)
+	}
+	if n := alog.Expired(); n != 2 {
+		t.Errorf("Expected 2 expired messages, got %d", n)
+	}
+}
+
+func TestMaxAgeBatching(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 10), WithBatching(10, 0), WithMaxAge(time.Second))
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog.now = clock.Now
+	go alog.Start()
+	alog.Info("m0")
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	alog.Error("kept") // starts the next batch, which the stale messages would join
+	alog.Info("stale 1")
+	alog.Info("stale 2")
+	clock.advance(2 * time.Second)
+	close(gw.open)
+	alog.Stop()
+
+	if got := writtenMessages(gw.b); len(got) != 2 || got[1] != "kept" {
+		t.Errorf("Expected m0 and kept, got %q", got)
+	}
+	if n := alog.Expired(); n != 2 {
+		t.Errorf("Expected 2 expired messages, got %d", n)
+	}
+}
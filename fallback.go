@@ -0,0 +1,108 @@
+package alog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FallbackError is sent on the error channel when a write to the writer passed to New failed and the messages were
+// written to the fallback writer set with WithFallbackWriter instead.
+type FallbackError struct {
+	Err         error // the error from the writer passed to New
+	FallbackErr error // the error from the fallback writer, nil if it took the messages
+}
+
+func (e *FallbackError) Error() string {
+	if e.FallbackErr != nil {
+		return fmt.Sprintf("alog: %v, and the fallback writer failed: %v", e.Err, e.FallbackErr)
+	}
+	return fmt.Sprintf("alog: %v, written to the fallback writer", e.Err)
+}
+
+func (e *FallbackError) Unwrap() error {
+	return e.Err
+}
+
+// fallbackWriter is the writer passed to New when WithFallbackWriter is used. After a failed write it skips the
+// primary until retry has passed, if set.
+type fallbackWriter struct {
+	primary  io.Writer
+	fallback io.Writer
+	retry    time.Duration
+	now      func() time.Time
+
+	m      sync.Mutex // writes are serialized by the logger, but Flush may run on another goroutine
+	failed time.Time  // when the primary last failed, zero if the last write worked
+}
+
+func (fw *fallbackWriter) Write(p []byte) (int, error) {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if !fw.failed.IsZero() && fw.now().Sub(fw.failed) < fw.retry {
+		if _, err := fw.fallback.Write(p); err != nil {
+			return 0, &FallbackError{Err: errPrimarySkipped, FallbackErr: err}
+		}
+		return len(p), nil
+	}
+	n, err := fw.primary.Write(p)
+	if err == nil {
+		fw.failed = time.Time{}
+		return n, nil
+	}
+	if err == ErrCircuitOpen {
+		// The failures that opened the circuit were reported already, so only the fallback's are news.
+		if _, fbErr := fw.fallback.Write(p); fbErr != nil {
+			return 0, &FallbackError{Err: err, FallbackErr: fbErr}
+		}
+		return len(p), nil
+	}
+	if fw.retry > 0 {
+		fw.failed = fw.now()
+	}
+	// The whole of p is written, partly written or not, so the fallback has complete lines.
+	if _, fbErr := fw.fallback.Write(p); fbErr != nil {
+		return n, &FallbackError{Err: err, FallbackErr: fbErr}
+	}
+	return len(p), &FallbackError{Err: err}
+}
+
+// countFailed counts n messages whose write returned err, as taken by the fallback writer if err says so, see
+// WithFallbackWriter, or else as write errors. An error that's joined with others, such as a Sink's, counts as a
+// write error even if the fallback took the messages.
+func (al *Alog) countFailed(n int, err error) {
+	for e := err; e != nil; {
+		if fe, ok := e.(*FallbackError); ok {
+			if fe.FallbackErr == nil {
+				atomic.AddUint64(&al.fallbacks, uint64(n))
+				return
+			}
+			break
+		}
+		u, ok := e.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		e = u.Unwrap()
+	}
+	atomic.AddUint64(&al.writeErrors, uint64(n))
+}
+
+// errPrimarySkipped is the primary's error in a FallbackError when the primary wasn't tried.
+var errPrimarySkipped = errors.New("skipped after an earlier error")
+
+func (fw *fallbackWriter) Flush() error {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	var errs []error
+	if f, ok := fw.primary.(flusher); ok {
+		errs = append(errs, f.Flush())
+	}
+	if f, ok := fw.fallback.(flusher); ok {
+		errs = append(errs, f.Flush())
+	}
+	return joinErrors(errs)
+}
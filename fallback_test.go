@@ -0,0 +1,100 @@
+package alog
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failFirstWriter fails the first n writes.
+type failFirstWriter struct {
+	n int32
+	b *lockedBuffer
+}
+
+func (fw *failFirstWriter) Write(data []byte) (int, error) {
+	if atomic.AddInt32(&fw.n, -1) >= 0 {
+		return 0, errors.New("broken pipe")
+	}
+	return fw.b.Write(data)
+}
+
+func TestFallbackWriter(t *testing.T) {
+	primary := &failFirstWriter{n: 2, b: &lockedBuffer{}}
+	fallback := &lockedBuffer{}
+	alog := New(primary, WithFallbackWriter(fallback), WithBufferSize(10))
+	go alog.Start()
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		alog.Info(msg)
+	}
+	alog.Stop()
+
+	if got := writtenMessages(fallback); strings.Join(got, ",") != "one,two" {
+		t.Errorf("Expected the fallback to get the failed lines, got %q", got)
+	}
+	if got := writtenMessages(primary.b); strings.Join(got, ",") != "three,four" {
+		t.Errorf("Expected the primary to get the rest, got %q", got)
+	}
+	for i := 0; i < 2; i++ {
+		var fe *FallbackError
+		err := <-alog.ErrorChannel()
+		if !errors.As(err, &fe) || fe.FallbackErr != nil {
+			t.Fatalf("Expected a FallbackError, got %v", err)
+		}
+		if err.Error() != "alog: broken pipe, written to the fallback writer" {
+			t.Errorf("Got %q", err)
+		}
+	}
+	if s := alog.Stats(); s.Fallbacks != 2 || s.WriteErrors != 0 {
+		t.Errorf("Expected 2 fallbacks and no write errors, got %+v", s)
+	}
+}
+
+func TestFallbackWriterFails(t *testing.T) {
+	primary := &failFirstWriter{n: 1, b: &lockedBuffer{}}
+	alog := New(primary, WithFallbackWriter(&flakyWriter{fail: 1, b: &lockedBuffer{}}))
+	_, err := alog.Write("lost")
+	var fe *FallbackError
+	if !errors.As(err, &fe) || fe.FallbackErr == nil {
+		t.Fatalf("Expected a FallbackError with the fallback's error, got %v", err)
+	}
+	if err.Error() != "alog: broken pipe, and the fallback writer failed: disk full" {
+		t.Errorf("Got %q", err)
+	}
+	if s := alog.Stats(); s.Fallbacks != 0 || s.WriteErrors != 1 {
+		t.Errorf("Expected a write error and no fallbacks, got %+v", s)
+	}
+}
+
+func TestFallbackRetry(t *testing.T) {
+	primary := &failFirstWriter{n: 2, b: &lockedBuffer{}}
+	fallback := &lockedBuffer{}
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog := New(primary, WithFallbackWriter(fallback), WithFallbackRetry(time.Minute))
+	alog.now = clock.Now
+
+	write := func(msg string, wantErr bool) {
+		t.Helper()
+		if _, err := alog.Write(msg); (err != nil) != wantErr {
+			t.Fatalf("Writing %q: got error %v", msg, err)
+		}
+	}
+	write("fails", true)
+	write("skipped", false)
+	clock.advance(time.Minute)
+	write("fails again", true)
+	clock.advance(30 * time.Second)
+	write("skipped again", false)
+	clock.advance(30 * time.Second)
+	write("back", false)
+	write("still back", false)
+
+	if got := writtenMessages(fallback); strings.Join(got, ",") != "fails,skipped,fails again,skipped again" {
+		t.Errorf("Got %q in the fallback", got)
+	}
+	if got := writtenMessages(primary.b); strings.Join(got, ",") != "back,still back" {
+		t.Errorf("Got %q in the primary", got)
+	}
+}
@@ -0,0 +1,107 @@
+package alog
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// defaultPanicTimeout is how long Panic waits for its message to be written before panicking anyway.
+const defaultPanicTimeout = 5 * time.Second
+
+// Fatal writes msg at LevelFatal and exits the program with status 1, like log.Fatal. The logger is stopped first,
+// so every message logged before Fatal is written, and msg is then written on the calling goroutine and flushed, so
+// it's the last message. Stopping and writing msg take no longer than five seconds in all, like Panic, so a
+// destination that hangs can't keep the program from exiting. A message the writer passed to New fails to write goes
+// to the fallback writer, if there is one, see WithFallbackWriter, and the program exits either way. msg is written
+// whatever the logger's level and isn't filtered, sampled or rate limited.
+func (al *Alog) Fatal(msg string) {
+	al.fatal(msg)
+}
+
+// Fatalf formats the message like fmt.Sprintf and writes it like Fatal, then exits the program with status 1.
+func (al *Alog) Fatalf(format string, args ...any) {
+	al.fatal(sprintf(format, args...))
+}
+
+// Panic writes msg at LevelPanic and then panics with msg, like log.Panic. It waits for msg, and every message
+// logged before it, to be written and flushed first, so the cause of the panic is in the log even if the program
+// dies of it, but no longer than five seconds, so a destination that hangs can't stop the panic. Unlike Fatal it
+// doesn't stop the logger, since the panic may be recovered. msg is written whatever the logger's level and isn't
+// filtered, sampled or rate limited; if the logger isn't running it's written on the calling goroutine.
+func (al *Alog) Panic(msg string) {
+	al.panic(msg)
+}
+
+// Panicf formats the message like fmt.Sprintf and writes it like Panic, then panics with the formatted message.
+func (al *Alog) Panicf(format string, args ...any) {
+	al.panic(sprintf(format, args...))
+}
+
+// panic writes msg and panics. It must only be called by Panic and Panicf, so the caller is always the same number
+// of frames up.
+func (al *Alog) panic(msg string) {
+	e := al.newEntry(LevelPanic, msg)
+	e.Caller = al.caller(2)
+	e.Stack = al.stack(LevelPanic)
+	e.Seq = al.nextSeq()
+	ctx, cancel := context.WithTimeout(context.Background(), al.panicTimeout)
+	defer cancel()
+	runWithin(ctx, func() {
+		if atomic.LoadInt32(&al.state) != stateRunning {
+			al.writeNow(e)
+			return
+		}
+		if al.queue(e) == nil {
+			_ = al.Flush(ctx)
+		}
+	})
+	panic(msg)
+}
+
+// fatal writes msg and exits. It must only be called by Fatal and Fatalf, so the caller is always the same number
+// of frames up.
+func (al *Alog) fatal(msg string) {
+	e := al.newEntry(LevelFatal, msg)
+	e.Caller = al.caller(2)
+	e.Stack = al.stack(LevelFatal)
+	ctx, cancel := context.WithTimeout(context.Background(), al.panicTimeout)
+	defer cancel()
+	_ = al.StopContext(ctx)
+	e.Seq = al.nextSeq()
+	runWithin(ctx, func() {
+		al.writeNow(e)
+	})
+	al.exit(1)
+}
+
+// runWithin calls fn on another goroutine and waits for it to return, or for ctx to be done, whichever comes first,
+// so Fatal and Panic go ahead even if fn is stuck on the destination.
+func runWithin(ctx context.Context, fn func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// writeNow writes e on the calling goroutine and flushes it, for Fatal and Panic.
+func (al *Alog) writeNow(e Entry) {
+	al.m.Lock()
+	n, err := al.writeMessage(e)
+	if err == nil && al.buffer != nil {
+		err = al.buffer.Flush()
+	}
+	al.m.Unlock()
+	switch {
+	case err == nil:
+		al.countWritten(1, n)
+	case err != errVetoed:
+		al.countFailed(1, err)
+		al.reportError(err) // an error handler still gets to see it
+	}
+}
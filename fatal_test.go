@@ -0,0 +1,172 @@
+package alog
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordExit makes Fatal record its exit code instead of exiting.
+func recordExit(alog *Alog) *[]int {
+	var codes []int
+	alog.exit = func(code int) {
+		codes = append(codes, code)
+	}
+	return &codes
+}
+
+func TestFatal(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBufferSize(100))
+	codes := recordExit(alog)
+	go alog.Start()
+	for i := 0; i < 50; i++ {
+		alog.Info("m" + strconv.Itoa(i))
+	}
+	alog.Fatalf("giving up after %d messages", 50)
+
+	if len(*codes) != 1 || (*codes)[0] != 1 {
+		t.Fatalf("Expected a single exit with status 1, got %v", *codes)
+	}
+	lines := strings.Split(strings.TrimSuffix(lb.String(), "\n"), "\n")
+	if len(lines) != 51 {
+		t.Fatalf("Expected 51 lines, got %d", len(lines))
+	}
+	if last := lines[50]; !strings.HasSuffix(last, "[FATAL] - giving up after 50 messages") {
+		t.Errorf("Expected the fatal message last, got %q", last)
+	}
+}
+
+func TestFatalTimeout(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw)
+	alog.panicTimeout = 10 * time.Millisecond
+	exited := make(chan int, 1)
+	alog.exit = func(code int) {
+		exited <- code
+	}
+	go alog.Start()
+	alog.Info("stuck")
+	go alog.Fatal("giving up")
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("Expected status 1, got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected Fatal to exit although the writer is stuck")
+	}
+	close(gw.open)
+	alog.Stop()
+}
+
+func TestFatalAboveLevel(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithLevel(LevelFatal+1))
+	codes := recordExit(alog)
+	alog.Fatal("never started")
+
+	if got := writtenMessages(lb); len(got) != 1 || got[0] != "never started" || len(*codes) != 1 {
+		t.Errorf("Got %q and exit codes %v", got, *codes)
+	}
+}
+
+func TestFatalFallback(t *testing.T) {
+	primary := failMatchingWriter{"FATAL", &lockedBuffer{}}
+	fallback := &lockedBuffer{}
+	alog := New(primary, WithFallbackWriter(fallback))
+	codes := recordExit(alog)
+	go alog.Start()
+	alog.Info("before")
+	alog.Fatal("disk is gone")
+
+	if got := writtenMessages(primary.b); len(got) != 1 || got[0] != "before" {
+		t.Errorf("Expected the primary to get the earlier message, got %q", got)
+	}
+	if got := writtenMessages(fallback); len(got) != 1 || got[0] != "disk is gone" {
+		t.Errorf("Expected the fallback to get the fatal message, got %q", got)
+	}
+	if len(*codes) != 1 || (*codes)[0] != 1 {
+		t.Errorf("Expected an exit with status 1, got %v", *codes)
+	}
+}
+
+func TestFatalLevelString(t *testing.T) {
+	if s := LevelFatal.String(); s != "FATAL" {
+		t.Errorf("Got %q", s)
+	}
+	if s := (LevelFatal - 1).String(); s != "PANIC+1" {
+		t.Errorf("Got %q", s)
+	}
+}
+
+// recoverPanic calls f and returns the value it panicked with.
+func recoverPanic(f func()) (v any) {
+	defer func() {
+		v = recover()
+	}()
+	f()
+	return nil
+}
+
+func TestPanic(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBufferSize(100))
+	go alog.Start()
+	defer alog.Stop()
+	for atomic.LoadInt32(&alog.state) != stateRunning {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 50; i++ {
+		alog.Info("m" + strconv.Itoa(i))
+	}
+	v := recoverPanic(func() { alog.Panicf("bad state %d", 7) })
+
+	if v != "bad state 7" {
+		t.Errorf("Expected to panic with the message, got %v", v)
+	}
+	got := writtenMessages(lb)
+	if len(got) != 51 || got[0] != "m0" || got[50] != "bad state 7" {
+		t.Fatalf("Expected the 50 messages and then the panic's, got %q", got)
+	}
+	if !strings.Contains(lb.String(), "[PANIC] - bad state 7\n") {
+		t.Errorf("Expected the message at LevelPanic, got %q", lb.String())
+	}
+	alog.Info("still running")
+	if err := alog.Flush(context.Background()); err != nil || !strings.HasSuffix(lb.String(), "still running\n") {
+		t.Errorf("Expected the logger to keep running after the panic, got %v", err)
+	}
+}
+
+func TestPanicNotStarted(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb)
+	if v := recoverPanic(func() { alog.Panic("early") }); v != "early" {
+		t.Errorf("Got %v", v)
+	}
+	if got := writtenMessages(lb); len(got) != 1 || got[0] != "early" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestPanicTimeout(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw)
+	alog.panicTimeout = 10 * time.Millisecond
+	go alog.Start()
+	for atomic.LoadInt32(&alog.state) != stateRunning {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	if v := recoverPanic(func() { alog.Panic("stuck") }); v != "stuck" {
+		t.Errorf("Got %v", v)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Expected Panic to give up on the writer, it took %v", d)
+	}
+	close(gw.open)
+	alog.Stop()
+}
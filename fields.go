@@ -0,0 +1,132 @@
+package alog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// badKey is used as the key of a trailing value in a key/value list that has no key of its own.
+const badKey = "!BADKEY"
+
+// Field is a key/value pair attached to a message. A Field whose Value is a []Field is a group: TextFormatter and
+// LogfmtFormatter render its fields with dotted keys, e.g. "request.id=7", and JSONFormatter renders it as a nested
+// object.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// WithFields returns a logger that attaches fields to every message it writes, in addition to the fields of al.
+// Fields are rendered in key order. The returned logger shares al's destination, channels and Start loop, so it
+// doesn't need to be started or stopped separately.
+func (al *Alog) WithFields(fields map[string]any) *Alog {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	add := make([]Field, 0, len(keys))
+	for _, k := range keys {
+		add = append(add, Field{k, fields[k]})
+	}
+	child := al.derive()
+	child.fields = mergeFields(al.fields, add)
+	return child
+}
+
+// Named returns a logger that stamps every message with name, in addition to everything al attaches to its
+// messages. Names nest, so al.Named("http").Named("client") is named "http.client". Like WithFields, the returned
+// logger shares al's destination, channels and Start loop; stopping al stops it too, and a logger named after al
+// has been stopped discards its messages like al does.
+func (al *Alog) Named(name string) *Alog {
+	child := al.derive()
+	if al.name != "" && name != "" {
+		child.name = al.name + "." + name
+	} else if name != "" {
+		child.name = name
+	}
+	return child
+}
+
+// derive returns a logger that shares al's core and has the same fields, prefix and name.
+func (al *Alog) derive() *Alog {
+	child := &Alog{core: al.core, fields: al.fields, name: al.name}
+	child.prefix.Store(al.Prefix())
+	return child
+}
+
+// SetPrefix changes the prefix of messages logged through al from now on. Loggers that were already derived from
+// al keep their prefix. It is safe to call while other goroutines are logging.
+func (al *Alog) SetPrefix(prefix string) {
+	al.prefix.Store(prefix)
+}
+
+// Prefix returns the prefix of messages logged through al.
+func (al *Alog) Prefix() string {
+	p, _ := al.prefix.Load().(string)
+	return p
+}
+
+// kvFields converts alternating keys and values into fields. Keys that aren't strings are formatted with fmt.Sprint.
+func kvFields(kv []any) []Field {
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields = append(fields, Field{badKey, kv[i]})
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fields = append(fields, Field{key, kv[i+1]})
+	}
+	return fields
+}
+
+// mergeFields returns a new slice with the fields of add appended to base. A field in add replaces a field in base
+// that has the same key. Neither argument is modified.
+func mergeFields(base, add []Field) []Field {
+	merged := make([]Field, len(base), len(base)+len(add))
+	copy(merged, base)
+next:
+	for _, f := range add {
+		for i := range merged {
+			if merged[i].Key == f.Key {
+				merged[i] = f
+				continue next
+			}
+		}
+		merged = append(merged, f)
+	}
+	return merged
+}
+
+// appendFields renders fields as space separated key=value pairs, each preceded by a space, and appends them to buf.
+// Values that are empty or contain spaces, quotes or equals signs are quoted.
+func appendFields(buf []byte, fields []Field) []byte {
+	return appendGroupFields(buf, "", fields)
+}
+
+// appendGroupFields appends fields like appendFields, with every key preceded by group.
+func appendGroupFields(buf []byte, group string, fields []Field) []byte {
+	for _, f := range fields {
+		if sub, ok := f.Value.([]Field); ok {
+			buf = appendGroupFields(buf, group+f.Key+".", sub)
+			continue
+		}
+		buf = append(buf, ' ')
+		buf = append(buf, group...)
+		buf = append(buf, f.Key...)
+		buf = append(buf, '=')
+		v := fmt.Sprint(f.Value)
+		if v == "" || strings.ContainsAny(v, " =\"\n\t") {
+			buf = strconv.AppendQuote(buf, v)
+		} else {
+			buf = append(buf, v...)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,178 @@
+package alog
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithFieldsAttachesFields(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	alog.WithFields(map[string]any{"user": "bob", "request_id": 42}).Info("hello")
+	alog.Stop()
+	if !strings.HasSuffix(b.String(), "[INFO] - hello request_id=42 user=bob\n") {
+		t.Errorf("Fields not rendered after the message, got %q", b.String())
+	}
+}
+
+func TestNestedWithFields(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	parent := alog.WithFields(map[string]any{"a": 1, "b": 2})
+	child := parent.WithFields(map[string]any{"b": 3, "c": "x y"})
+	child.Info("child")
+	parent.Info("parent")
+	alog.Info("root")
+	alog.Stop()
+	lines := strings.Split(b.String(), "\n")
+	if !strings.HasSuffix(lines[0], " - child a=1 b=3 c=\"x y\"") {
+		t.Errorf("Nested fields not merged, got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], " - parent a=1 b=2") {
+		t.Errorf("Child fields leaked into the parent, got %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], " - root") {
+		t.Errorf("Derived fields leaked into the root logger, got %q", lines[2])
+	}
+}
+
+func TestKVMethods(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithLevel(LevelDebug))
+	go alog.Start()
+	alog.DebugKV("debug", "k", 1)
+	alog.InfoKV("info", "k", "", 2, true)
+	alog.WarnKV("warn", "odd")
+	alog.WithFields(map[string]any{"k": "field"}).ErrorKV("error", "k", "call")
+	alog.Stop()
+	want := []string{
+		" - debug k=1",
+		` - info k="" 2=true`,
+		" - warn !BADKEY=odd",
+		" - error k=call",
+	}
+	lines := strings.Split(b.String(), "\n")
+	for i, suffix := range want {
+		if !strings.HasSuffix(lines[i], suffix) {
+			t.Errorf("Expected line %d to end with %q, got %q", i, suffix, lines[i])
+		}
+	}
+}
+
+func TestFieldsCapturedAtSendTime(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(1))
+	fields := map[string]any{"k": "before"}
+	derived := alog.WithFields(fields)
+	derived.Info("message")
+	fields["k"] = "after"
+	go alog.Start()
+	alog.Stop()
+	if !strings.Contains(b.String(), "k=before") {
+		t.Errorf("Fields not captured when the message was sent, got %q", b.String())
+	}
+}
+
+func TestConcurrentDerivedLoggers(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			derived := alog.WithFields(map[string]any{"worker": i})
+			for j := 0; j < 100; j++ {
+				derived.Info("message")
+			}
+		}(i)
+	}
+	wg.Wait()
+	derived := alog.WithFields(map[string]any{"worker": "stopper"})
+	derived.Stop()
+	for i := 0; i < 10; i++ {
+		if n := strings.Count(b.String(), "worker="+strconv.Itoa(i)+"\n"); n != 100 {
+			t.Errorf("Expected 100 messages from worker %d, got %d", i, n)
+		}
+	}
+}
+
+func TestWriteIncludesFields(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b).WithFields(map[string]any{"k": "v"})
+	if _, err := alog.Write("sync\n"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "] - sync k=v\n") {
+		t.Errorf("Write didn't include the logger's fields, got %q", b.String())
+	}
+}
+
+// entryRecorder is a Formatter that keeps a copy of every entry it formats and renders them as text.
+type entryRecorder struct {
+	m       *sync.Mutex
+	entries *[]Entry
+}
+
+func (f entryRecorder) Format(buf []byte, e *Entry) []byte {
+	f.m.Lock()
+	*f.entries = append(*f.entries, *e)
+	f.m.Unlock()
+	return TextFormatter{}.Format(buf, e)
+}
+
+func TestStaticFields(t *testing.T) {
+	var entries []Entry
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithStaticFields(map[string]string{"version": "1.2", "service": "api", "host": "override-me"}),
+		WithHostInfo(), WithLatePolicy(LateWriteSync), WithFormatter(entryRecorder{&sync.Mutex{}, &entries}))
+	go alog.Start()
+	alog.MessageChannel() <- "channel"
+	alog.WithFields(map[string]any{"user": "bob"}).Info("fields")
+	alog.Stop()
+	if _, err := alog.Write("sync"); err != nil {
+		t.Fatal(err)
+	}
+
+	host, _ := os.Hostname()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		counts := map[string]int{}
+		for _, f := range e.Fields {
+			counts[f.Key]++
+		}
+		for _, key := range []string{"host", "pid", "service", "version"} {
+			if counts[key] != 1 {
+				t.Errorf("%q: expected %s once, got %d times in %v", e.Message, key, counts[key], e.Fields)
+			}
+		}
+		if got := fieldValue(e.Fields, "host"); got != host {
+			t.Errorf("%q: host is %v, want %q", e.Message, got, host)
+		}
+		if got := fieldValue(e.Fields, "pid"); got != os.Getpid() {
+			t.Errorf("%q: pid is %v, want %d", e.Message, got, os.Getpid())
+		}
+	}
+	want := "[INFO] - fields user=bob host=" + host + " service=api version=1.2 pid=" + strconv.Itoa(os.Getpid())
+	if got := strings.Split(b.String(), "\n")[1]; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func fieldValue(fields []Field, key string) any {
+	for _, f := range fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return nil
+}
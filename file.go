@@ -0,0 +1,367 @@
+package alog
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMaxFileSize is the size at which a FileWriter rotates unless WithMaxFileSize says otherwise.
+const defaultMaxFileSize = 100 << 20
+
+// FileWriter writes to a file and rotates it when it grows past a maximum size: the file is closed, renamed to
+// path.YYYYMMDD-HHMMSS, with .1, .2 and so on added if that name is taken, and a new file is started at path.
+// Rotation only happens between writes, so each message, or batch of messages, ends up whole in one file. With
+// WithTimeRotation it also starts a new file every day or hour, see Rotation.
+//
+// The file and its directory are created on the first write, and errors from opening or rotating are returned by
+// Write, which makes them reach the logger's error channel. If the file can't be renamed, writing carries on at the
+// end of the old file and rotation is tried again once it has grown by another maximum size. FileWriter is safe for
+// concurrent use.
+type FileWriter struct {
+	m         sync.Mutex
+	path      string
+	maxSize   int64
+	rotation  Rotation
+	closeIdle bool
+	name      string // of the current file, path with the period in it if there's a Rotation
+	start     time.Time
+	next      time.Time // the current file's period is from start up to next
+	f         *os.File
+	size      int64 // of f
+	limit     int64 // size at which f is rotated
+	idle      *time.Timer
+	compress  bool
+	marker    func() string  // set by WithRotationMarker
+	header    int64          // the size of the marker at the top of f
+	started   bool           // a file has been opened, so the next new one gets the marker
+	bg        sync.WaitGroup // compressions and cleanups in flight
+	bgWork    sync.Mutex     // held by each compression and cleanup, so they run one at a time
+	bgM       sync.Mutex     // guards bgErrs and queued, which they use without holding m
+	bgErrs    []error
+	queued    map[string]bool // rotated files waiting to be compressed
+
+	maxBackups     int
+	maxBackupBytes int64
+	maxBackupAge   time.Duration
+	cleanedFor     string // the file that was current when cleanup last ran
+
+	durable      bool // set by WithSync, along with syncEvery and syncInterval
+	syncEvery    int
+	syncInterval time.Duration
+	unsynced     int // messages written since the last sync
+	syncTimer    *time.Timer
+	syncErrors   uint64 // accessed atomically
+
+	now    func() time.Time
+	rename func(oldpath, newpath string) error
+	fsync  func(*os.File) error
+}
+
+// Rotation is how often a FileWriter starts a new file, whatever its size. The file for each period is named after
+// it by inserting the period's start before path's extension: app.log becomes app-2024-05-01.log for Daily and
+// app-2024-05-01T15.log for Hourly. Periods follow the local clock, including daylight saving time changes, and a
+// new file is started on the first write after the period ends, rather than on a timer. Writing to a period's file
+// always appends, so a clock that goes back, or an hour that happens twice, never overwrites what's there.
+type Rotation int
+
+const (
+	// NoRotation only rotates the file by size. It's the default.
+	NoRotation Rotation = iota
+	// Hourly starts a new file at the beginning of every hour.
+	Hourly
+	// Daily starts a new file at midnight.
+	Daily
+)
+
+// period returns the start of the period t is in and the start of the next one.
+func (r Rotation) period(t time.Time) (start, next time.Time) {
+	y, mo, d := t.Date()
+	switch r {
+	case Hourly:
+		start = time.Date(y, mo, d, t.Hour(), 0, 0, 0, t.Location())
+		return start, time.Date(y, mo, d, t.Hour()+1, 0, 0, 0, t.Location())
+	default:
+		start = time.Date(y, mo, d, 0, 0, 0, 0, t.Location())
+		return start, time.Date(y, mo, d+1, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// fileName returns the name of the file for the period that starts at start.
+func (r Rotation) fileName(path string, start time.Time) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + start.Format(r.layout()) + ext
+}
+
+// layout returns the layout of the period in file names.
+func (r Rotation) layout() string {
+	if r == Hourly {
+		return "2006-01-02T15"
+	}
+	return "2006-01-02"
+}
+
+// FileOption configures a FileWriter.
+type FileOption func(*FileWriter)
+
+// WithMaxFileSize sets the size in bytes a file may grow to before it's rotated. The default is 100MB, and a size of
+// 0 or less turns rotation off. A single write that's bigger than the maximum is still written to one file.
+func WithMaxFileSize(n int64) FileOption {
+	return func(fw *FileWriter) {
+		fw.maxSize = n
+	}
+}
+
+// WithTimeRotation makes the FileWriter start a new file every period, on top of rotating by size.
+func WithTimeRotation(r Rotation) FileOption {
+	return func(fw *FileWriter) {
+		fw.rotation = r
+	}
+}
+
+// WithIdleClose makes a FileWriter with a Rotation close its file at the end of the period even if nothing is
+// written, instead of on the first write after it, using a timer. The next write opens the new period's file.
+func WithIdleClose() FileOption {
+	return func(fw *FileWriter) {
+		fw.closeIdle = true
+	}
+}
+
+// NewFileWriter returns a FileWriter for path, which can be passed to New. Call Close once the logger has been
+// stopped.
+func NewFileWriter(path string, opts ...FileOption) *FileWriter {
+	fw := &FileWriter{
+		path:    path,
+		name:    path,
+		maxSize: defaultMaxFileSize,
+		now:     time.Now,
+		rename:  os.Rename,
+		fsync:   syncFile,
+	}
+	for _, opt := range opts {
+		opt(fw)
+	}
+	return fw
+}
+
+// Write implements io.Writer. If the file had to be rotated and that failed, data is still written to the old file
+// and the rotation error is returned along with the full length of data. Errors from compressing rotated files are
+// returned the same way by the next Write.
+func (fw *FileWriter) Write(data []byte) (int, error) {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if fw.rotation != NoRotation {
+		if err := fw.roll(fw.now()); err != nil {
+			return 0, err
+		}
+	}
+	if fw.f == nil {
+		if err := fw.open(); err != nil {
+			return 0, err
+		}
+	}
+	var rotateErr error
+	if fw.maxSize > 0 && fw.size > fw.header && fw.size+int64(len(data)) > fw.limit {
+		if rotateErr = fw.rotate(); fw.f == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := fw.f.Write(data)
+	fw.size += int64(n)
+	if err == nil && fw.durable {
+		err = fw.wrote(data)
+	}
+	if err == nil {
+		err = rotateErr
+	}
+	if err == nil {
+		err = fw.backgroundErr()
+	}
+	return n, err
+}
+
+// Close closes the current file and waits for rotated files to be compressed. A later Write opens the file again.
+func (fw *FileWriter) Close() error {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	err := fw.close()
+	fw.bg.Wait()
+	if err == nil {
+		err = fw.backgroundErr()
+	}
+	return err
+}
+
+// Reopen closes the file and opens path again, for log rotation tools like logrotate that rename the file and then
+// send a signal, see ReopenOnSignal. It waits for a Write in progress, so messages aren't split between the files. If
+// the file can't be opened, writing carries on with the old one and the error is returned.
+func (fw *FileWriter) Reopen() error {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if fw.f == nil {
+		return nil // the next Write opens it
+	}
+	if err := fw.sync(); err != nil {
+		fw.addBackgroundErr(err)
+	}
+	old, idle := fw.f, fw.idle
+	if err := fw.open(); err != nil {
+		return err
+	}
+	if idle != nil {
+		idle.Stop()
+	}
+	return old.Close()
+}
+
+func (fw *FileWriter) close() error {
+	if fw.idle != nil {
+		fw.idle.Stop()
+		fw.idle = nil
+	}
+	if fw.f == nil {
+		return nil
+	}
+	// Report a failed sync later rather than fail the write that rotates the file.
+	if err := fw.sync(); err != nil {
+		fw.addBackgroundErr(err)
+	}
+	err := fw.f.Close()
+	fw.f = nil
+	return err
+}
+
+// roll closes the current file if t is outside its period and works out the name of the file for t's period,
+// which the next open uses.
+func (fw *FileWriter) roll(t time.Time) error {
+	if fw.f != nil && !t.Before(fw.start) && t.Before(fw.next) {
+		return nil
+	}
+	start, next := fw.rotation.period(t)
+	name := fw.rotation.fileName(fw.path, start)
+	if fw.f != nil && name != fw.name {
+		if err := fw.close(); err != nil {
+			return err
+		}
+		fw.startAfterRotate(fw.name, name)
+	}
+	fw.name, fw.start, fw.next = name, start, next
+	return nil
+}
+
+// closeIfIdle is called by WithIdleClose's timer.
+func (fw *FileWriter) closeIfIdle() {
+	fw.m.Lock()
+	defer fw.m.Unlock()
+	if fw.f != nil && !fw.now().Before(fw.next) {
+		fw.close()
+		start, _ := fw.rotation.period(fw.now())
+		fw.startAfterRotate(fw.name, fw.rotation.fileName(fw.path, start))
+	}
+}
+
+// open opens the current file for appending, creating it and its directory if needed.
+func (fw *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(fw.name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fw.name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	fw.f = f
+	fw.size = info.Size()
+	fw.limit = fw.maxSize
+	fw.writeRotationMarker()
+	if fw.closeIdle && fw.rotation != NoRotation {
+		fw.idle = time.AfterFunc(fw.next.Sub(fw.now()), fw.closeIfIdle)
+	}
+	if fw.retains() && fw.cleanedFor != fw.name {
+		fw.cleanedFor = fw.name
+		fw.bg.Add(1)
+		go func(current string) {
+			defer fw.bg.Done()
+			fw.bgWork.Lock()
+			defer fw.bgWork.Unlock()
+			fw.cleanup(current)
+		}(fw.name)
+	}
+	return nil
+}
+
+// startAfterRotate starts afterRotate for backup, a file that's just been rotated or whose period has ended, if
+// WithCompression or a retention option is used. The caller holds m.
+func (fw *FileWriter) startAfterRotate(backup, current string) {
+	if !fw.compress && !fw.retains() {
+		return
+	}
+	if fw.compress {
+		fw.bgM.Lock()
+		if fw.queued == nil {
+			fw.queued = map[string]bool{}
+		}
+		fw.queued[backup] = true
+		fw.bgM.Unlock()
+	}
+	fw.bg.Add(1)
+	go fw.afterRotate(backup, current)
+}
+
+// afterRotate compresses the file that was just rotated and deletes old ones, as the options ask for. It waits for
+// the compressions and cleanups started before it that are still running.
+func (fw *FileWriter) afterRotate(backup, current string) {
+	defer fw.bg.Done()
+	fw.bgWork.Lock()
+	defer fw.bgWork.Unlock()
+	if fw.compress {
+		if err := compressFile(backup); err != nil {
+			fw.addBackgroundErr(err)
+		}
+		fw.bgM.Lock()
+		delete(fw.queued, backup)
+		fw.bgM.Unlock()
+	}
+	if fw.retains() {
+		fw.cleanup(current)
+	}
+}
+
+// rotate moves the current file out of the way and opens a new one. If the rename fails the old file is opened
+// again instead. fw.f is nil afterwards if no file could be opened.
+func (fw *FileWriter) rotate() error {
+	if err := fw.close(); err != nil {
+		return err
+	}
+	backup := fw.backupName()
+	renameErr := fw.rename(fw.name, backup)
+	if renameErr == nil {
+		fw.startAfterRotate(backup, fw.name)
+	}
+	if err := fw.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		fw.limit = fw.size + fw.maxSize // don't try again on every write
+	}
+	return renameErr
+}
+
+// backupName returns the name a rotated file is renamed to, which isn't taken yet.
+func (fw *FileWriter) backupName() string {
+	name := fw.name + "." + fw.now().Format("20060102-150405")
+	backup := name
+	for i := 1; ; i++ {
+		if !exists(backup) && !(fw.compress && exists(backup+".gz")) {
+			return backup
+		}
+		backup = name + "." + strconv.Itoa(i)
+	}
+}
@@ -0,0 +1,212 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // for the daylight saving time test
+)
+
+func TestFileWriterRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	fw := NewFileWriter(path, WithMaxFileSize(100))
+	fw.now = newTickingClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)).Now
+	all := bytes.NewBuffer([]byte{})
+	alog := New(fw, WithAdditionalWriter(all), WithBufferSize(10))
+	go alog.Start()
+	for i := 0; i < 50; i++ {
+		alog.Info("message " + strconv.Itoa(i))
+	}
+	alog.Stop()
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) < 5 {
+		t.Fatalf("Expected the log to be rotated a few times, got %q", names)
+	}
+	sort.Strings(names)
+	var got []byte
+	for _, name := range append(names, path) {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 100 {
+			t.Errorf("%s has %d bytes", name, len(data))
+		}
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			t.Errorf("%s doesn't end with a whole line", name)
+		}
+		got = append(got, data...)
+	}
+	if string(got) != all.String() {
+		t.Errorf("The files don't add up to the log:\n%s\nwant:\n%s", got, all)
+	}
+	if want := path + ".20240501-120000"; names[0] != want {
+		t.Errorf("First backup is %s, want %s", names[0], want)
+	}
+}
+
+func TestFileWriterBackupNameTaken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	fw := NewFileWriter(path, WithMaxFileSize(10))
+	fw.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)).Now
+	for i := 0; i < 3; i++ {
+		if _, err := fw.Write([]byte("0123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fw.Close()
+	for _, name := range []string{path, path + ".20240501-120000", path + ".20240501-120000.1"} {
+		if data, err := os.ReadFile(name); err != nil || string(data) != "0123456789\n" {
+			t.Errorf("%s: got %q, %v", name, data, err)
+		}
+	}
+}
+
+func TestFileWriterRenameFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	fw := NewFileWriter(path, WithMaxFileSize(30))
+	fw.rename = func(string, string) error {
+		return errors.New("permission denied")
+	}
+	alog := New(fw, WithBufferSize(10))
+	go alog.Start()
+	for i := 0; i < 5; i++ {
+		alog.Info("message " + strconv.Itoa(i))
+	}
+	alog.Stop()
+	fw.Close()
+
+	if err := <-alog.ErrorChannel(); err == nil || err.Error() != "permission denied" {
+		t.Errorf("Expected the rename error, got %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 5 {
+		t.Errorf("Expected every message in the old file, got %d lines", n)
+	}
+}
+
+func TestFileWriterOpenError(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fw := NewFileWriter(filepath.Join(dir, "file", "app.log"))
+	if _, err := fw.Write([]byte("x\n")); err == nil {
+		t.Error("Expected an error for a directory that can't be created")
+	}
+}
+
+// readFiles returns the contents of the files in dir by name.
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(data)
+	}
+	return files
+}
+
+func TestFileWriterDaily(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local))
+	fw := NewFileWriter(filepath.Join(dir, "app.log"), WithTimeRotation(Daily))
+	fw.now = clock.Now
+	defer fw.Close()
+
+	fw.Write([]byte("a\n"))
+	clock.advance(2 * time.Minute)
+	fw.Write([]byte("b\n"))
+	clock.advance(-90 * time.Second) // the clock is set back before midnight
+	fw.Write([]byte("c\n"))
+	fw.Close()
+
+	files := readFiles(t, dir)
+	if files["app-2024-05-01.log"] != "a\nc\n" || files["app-2024-05-02.log"] != "b\n" || len(files) != 2 {
+		t.Errorf("Got %q", files)
+	}
+}
+
+func TestFileWriterHourlyDaylightSaving(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	// 1:30 happens twice on 2024-11-03, once in EDT and an hour later in EST.
+	clock := newFakeClock(time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC).In(ny))
+	fw := NewFileWriter(filepath.Join(dir, "app.log"), WithTimeRotation(Hourly))
+	fw.now = clock.Now
+	defer fw.Close()
+
+	fw.Write([]byte("edt\n"))
+	clock.advance(time.Hour)
+	fw.Write([]byte("est\n"))
+	clock.advance(time.Hour)
+	fw.Write([]byte("two\n"))
+	fw.Close()
+
+	files := readFiles(t, dir)
+	if files["app-2024-11-03T01.log"] != "edt\nest\n" || files["app-2024-11-03T02.log"] != "two\n" || len(files) != 2 {
+		t.Errorf("Got %q", files)
+	}
+}
+
+func TestFileWriterRotatesPeriodBySize(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local))
+	fw := NewFileWriter(filepath.Join(dir, "app.log"), WithTimeRotation(Daily), WithMaxFileSize(2))
+	fw.now = clock.Now
+	fw.Write([]byte("a\n"))
+	fw.Write([]byte("b\n"))
+	fw.Close()
+
+	files := readFiles(t, dir)
+	if files["app-2024-05-01.log.20240501-120000"] != "a\n" || files["app-2024-05-01.log"] != "b\n" {
+		t.Errorf("Got %q", files)
+	}
+}
+
+func TestFileWriterIdleClose(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 5, 1, 23, 59, 0, 0, time.Local))
+	fw := NewFileWriter(filepath.Join(t.TempDir(), "app.log"), WithTimeRotation(Daily), WithIdleClose())
+	fw.now = clock.Now
+	defer fw.Close()
+	fw.Write([]byte("a\n"))
+	if fw.idle == nil {
+		t.Fatal("No timer was set for the end of the day")
+	}
+
+	fw.closeIfIdle() // early, as if the timer was off
+	if fw.f == nil {
+		t.Fatal("The file was closed before the end of the day")
+	}
+	clock.advance(time.Minute)
+	fw.closeIfIdle()
+	if fw.f != nil || fw.idle != nil {
+		t.Error("The file was left open after the end of the day")
+	}
+}
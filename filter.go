@@ -0,0 +1,56 @@
+package alog
+
+import (
+	"regexp"
+	"sync/atomic"
+)
+
+// WithFilter drops the messages keep returns false for, before they're queued, so they're never written and only
+// counted by Filtered. keep gets the entry with its level, message and fields, but without its time, which is set
+// when it's written. It's called on the goroutine that logs, so it must be safe to call concurrently. Messages sent
+// on MessageChannel are filtered on the Start loop instead. WithFilter can be used more than once, and a message is
+// only kept if every filter keeps it.
+func WithFilter(keep func(e *Entry) bool) Option {
+	return func(al *Alog) {
+		al.filters = append(al.filters, keep)
+	}
+}
+
+// WithDropMatching is WithFilter with a filter that drops the messages whose text matches re, such as
+// regexp.MustCompile(`^GET /healthz `).
+func WithDropMatching(re *regexp.Regexp) Option {
+	return WithFilter(func(e *Entry) bool {
+		return !re.MatchString(e.Message)
+	})
+}
+
+// Filter returns a middleware that drops the messages keep returns false for, like WithFilter, for ordering it
+// with other middlewares, see Use. Unlike WithFilter it runs once the message is about to be written, so keep gets
+// the entry with its time and static fields set, and the messages it drops aren't counted by Filtered.
+func Filter(keep func(e *Entry) bool) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(e *Entry) error {
+			if !keep(e) {
+				return nil
+			}
+			return next(e)
+		}
+	}
+}
+
+// Filtered returns the number of messages dropped by WithFilter.
+func (al *Alog) Filtered() uint64 {
+	return atomic.LoadUint64(&al.filtered)
+}
+
+// filteredOut reports whether one of the filters drops e, and counts it if so. e is passed by value so that it only
+// escapes to the heap when there are filters.
+func (al *Alog) filteredOut(e Entry) bool {
+	for _, keep := range al.filters {
+		if !keep(&e) {
+			atomic.AddUint64(&al.filtered, 1)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package alog
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb,
+		WithDropMatching(regexp.MustCompile(`^GET /healthz `)),
+		WithFilter(func(e *Entry) bool { return e.Level >= LevelWarn || len(e.Fields) == 0 }),
+		WithBufferSize(100))
+	go alog.Start()
+	alog.Info("GET /healthz 200")
+	alog.Info("GET /orders 200")
+	alog.InfoKV("noisy", "component", "poller")
+	alog.WarnKV("slow", "component", "poller")
+	alog.MessageChannel() <- "GET /healthz 200"
+	alog.Write("GET /healthz 200")
+	alog.TryInfof("GET /healthz %d", 200)
+	alog.Stop()
+
+	if got := writtenMessages(lb); strings.Join(got, ",") != "GET /orders 200,slow component=poller" {
+		t.Errorf("Got %q", got)
+	}
+	if n := alog.Filtered(); n != 5 {
+		t.Errorf("Expected 5 filtered messages, got %d", n)
+	}
+	if n := atomic.LoadUint64(&alog.written); n != 2 {
+		t.Errorf("Expected 2 written messages, got %d", n)
+	}
+}
+
+func TestFilterConcurrent(t *testing.T) {
+	d := &Discard{}
+	var calls int64
+	alog := New(d, WithFilter(func(e *Entry) bool {
+		atomic.AddInt64(&calls, 1)
+		return !strings.HasPrefix(e.Message, "drop")
+	}), WithBufferSize(100))
+	go alog.Start()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				alog.Info("keep")
+				alog.Info("drop")
+			}
+		}()
+	}
+	wg.Wait()
+	alog.Stop()
+	if d.Count() != 800 || alog.Filtered() != 800 || calls != 1600 {
+		t.Errorf("Got %d written, %d filtered and %d calls", d.Count(), alog.Filtered(), calls)
+	}
+}
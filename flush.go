@@ -0,0 +1,71 @@
+package alog
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// flusher is implemented by destinations that buffer writes, such as *bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// Flush blocks until every message that was logged before the call has been written to the destination, without
+// stopping the logger. If the destination has a Flush method, like *bufio.Writer, it's called afterwards and its
+// error is returned; so are level writers'. Messages logged while Flush is waiting may or may not be included.
+//
+// Flush returns ctx's error if ctx is done first. It returns nil straight away if the logger has been stopped,
+// since Stop already wrote everything.
+func (al *Alog) Flush(ctx context.Context) error {
+	if atomic.LoadInt32(&al.state) == stateStopped {
+		return nil
+	}
+	_, shutdownCompleteCh := al.runChannels()
+	done := make(chan error, 1)
+	select {
+	case al.entryCh <- Entry{flushed: done}:
+	case <-shutdownCompleteCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-shutdownCompleteCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleEntry writes e, or completes the Flush it stands for.
+func (al *Alog) handleEntry(e Entry, wg *sync.WaitGroup) {
+	if e.flushed == nil {
+		al.process(e, wg)
+		return
+	}
+	// entryCh is FIFO, so every entry queued before the flush has been received. Entries in the priority queue and
+	// on the ring and messages that were sent on msgCh or sentCh before Flush was called are either received already
+	// or still buffered, so take the ones that are buffered too, and then wait for the workers to finish with all of them.
+	for n := len(al.priorityCh); n > 0; n-- {
+		al.process(<-al.priorityCh, wg)
+	}
+	al.drainRing(wg)
+	al.drainSpill(wg)
+	for n := len(al.msgCh); n > 0; n-- {
+		wg.Add(1)
+		al.write(<-al.msgCh, wg)
+	}
+	for n := len(al.sentCh); n > 0; n-- {
+		wg.Add(1)
+		al.writeSent(<-al.sentCh, wg)
+	}
+	al.flushRepeats(wg)
+	wg.Wait()
+	al.m.Lock()
+	err := al.flushDests()
+	al.m.Unlock()
+	e.flushed <- err
+}
@@ -0,0 +1,84 @@
+package alog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer that can be read while the logger writes to it, after an optional delay per Write.
+type lockedBuffer struct {
+	m     sync.Mutex
+	b     bytes.Buffer
+	delay time.Duration
+}
+
+func (lb *lockedBuffer) Write(data []byte) (int, error) {
+	time.Sleep(lb.delay)
+	lb.m.Lock()
+	defer lb.m.Unlock()
+	return lb.b.Write(data)
+}
+
+func (lb *lockedBuffer) String() string {
+	lb.m.Lock()
+	defer lb.m.Unlock()
+	return lb.b.String()
+}
+
+func TestFlush(t *testing.T) {
+	lb := &lockedBuffer{delay: 100 * time.Microsecond}
+	alog := New(lb, WithBufferSize(1000))
+	go alog.Start()
+	defer alog.Stop()
+	for i := 0; i < 250; i++ {
+		alog.Info("method")
+		alog.MessageChannel() <- "channel"
+	}
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(lb.String(), "\n"); lines != 500 {
+		t.Errorf("Expected 500 lines when Flush returned, got %d", lines)
+	}
+}
+
+func TestFlushBufferedDestination(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	bw := bufio.NewWriter(b)
+	alog := New(bw)
+	go alog.Start()
+	defer alog.Stop()
+	alog.Info("buffered")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "[INFO] - buffered\n") {
+		t.Errorf("bufio.Writer wasn't flushed, got %q", b.String())
+	}
+}
+
+func TestFlushContext(t *testing.T) {
+	bw := blockingWriter{make(chan struct{})}
+	defer close(bw.release)
+	alog := New(bw, WithBufferSize(10))
+	go alog.Start()
+	alog.Info("stuck")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := alog.Flush(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestFlushAfterStop(t *testing.T) {
+	alog := New(nil)
+	alog.Stop()
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Errorf("Expected Flush after Stop to return nil, got %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+package alog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Formatter renders an entry as a single log line, including the trailing newline, and appends it to buf.
+// Formatters are called from the goroutine that writes the message and must not retain e or buf.
+type Formatter interface {
+	Format(buf []byte, e *Entry) []byte
+}
+
+// Formatter returns the formatter the logger renders messages with, which is a TextFormatter unless WithFormatter,
+// WithJSONFormat or WithLogfmtFormat was used.
+func (al *Alog) Formatter() Formatter {
+	return al.formatter
+}
+
+// TextFormatter is the default formatter. It renders entries as
+// "[timestamp] [LEVEL] [prefix] [name] caller - message key=value\n", where the timestamp uses Layout. An empty
+// Layout leaves the timestamp out, the level is left out for messages that were logged without one and the prefix,
+// name and caller are left out when they're empty. If everything before the message is left out the message isn't
+// preceded by " - ". A sequence number is rendered as a seq field after the message's fields. The line endings at
+// the end of the message, "\n", "\r\n" or a lone "\r", are replaced by a single newline. A stack trace is written
+// on the following lines, each indented by a tab. MultiLine sets how messages with newlines in them are written.
+type TextFormatter struct {
+	Layout    string
+	MultiLine MultiLinePolicy
+	Color     bool // color the level with ANSI escape sequences, see WithColor
+
+	timestamps *timestampCache // set by New for the default formatter
+}
+
+// MultiLinePolicy is how TextFormatter writes messages that span several lines. Apart from MultiLineRaw, the
+// policies treat "\r\n" like "\n", leave out the newlines at the end of the message and apply to stack traces too.
+// JSONFormatter and LogfmtFormatter always escape newlines, so their messages are one line each.
+type MultiLinePolicy int
+
+const (
+	// MultiLineRaw writes the message as it is, so the lines after the first have no timestamp. It's the default.
+	MultiLineRaw MultiLinePolicy = iota
+	// MultiLineHeader starts every line of the message with the same timestamp, level and so on as the first.
+	MultiLineHeader
+	// MultiLineIndent starts the lines after the first with "\t| ".
+	MultiLineIndent
+	// MultiLineEscape writes the newlines as "\n", so each message is exactly one line.
+	MultiLineEscape
+)
+
+// Format implements Formatter.
+func (f TextFormatter) Format(buf []byte, e *Entry) []byte {
+	start := len(buf)
+	header := false
+	if f.Layout != "" && !e.Time.IsZero() {
+		buf = append(buf, '[')
+		if f.timestamps != nil {
+			buf = f.timestamps.appendFormat(buf, e.Time, f.Layout)
+		} else {
+			buf = e.Time.AppendFormat(buf, f.Layout)
+		}
+		buf = append(buf, "] "...)
+		header = true
+	}
+	if !e.implicit {
+		buf = append(buf, '[')
+		if f.Color {
+			buf = append(buf, levelColor(e.Level)...)
+			buf = append(buf, e.Level.String()...)
+			buf = append(buf, colorReset...)
+		} else {
+			buf = append(buf, e.Level.String()...)
+		}
+		buf = append(buf, "] "...)
+		header = true
+	}
+	if e.Prefix != "" {
+		buf = append(buf, '[')
+		buf = append(buf, e.Prefix...)
+		buf = append(buf, "] "...)
+		header = true
+	}
+	if e.Name != "" {
+		buf = append(buf, '[')
+		buf = append(buf, e.Name...)
+		buf = append(buf, "] "...)
+		header = true
+	}
+	if e.Caller != "" {
+		buf = append(buf, e.Caller...)
+		buf = append(buf, ' ')
+		header = true
+	}
+	if header {
+		buf = append(buf, "- "...)
+	}
+	if f.MultiLine != MultiLineRaw {
+		return f.formatLines(buf, buf[start:], e)
+	}
+	if e.msg != nil {
+		buf = append(buf, trimLineEnd(*e.msg)...)
+	} else {
+		buf = append(buf, trimLineEnd(e.Message)...)
+	}
+	buf = appendFields(buf, e.Fields)
+	if e.Seq != 0 {
+		buf = append(buf, " seq="...)
+		buf = strconv.AppendUint(buf, e.Seq, 10)
+	}
+	buf = append(buf, '\n')
+	if e.Stack != "" {
+		for _, line := range strings.Split(strings.TrimSuffix(e.Stack, "\n"), "\n") {
+			buf = append(buf, '\t')
+			buf = append(buf, line...)
+			buf = append(buf, '\n')
+		}
+	}
+	return buf
+}
+
+// formatLines is the part of Format after header for the policies other than MultiLineRaw.
+func (f TextFormatter) formatLines(buf, header []byte, e *Entry) []byte {
+	if e.msg != nil {
+		buf = appendLines(buf, header, trimLineEnd(*e.msg), "", f.MultiLine)
+	} else {
+		buf = appendLines(buf, header, trimLineEnd(e.Message), "", f.MultiLine)
+	}
+	buf = appendFields(buf, e.Fields)
+	if e.Seq != 0 {
+		buf = append(buf, " seq="...)
+		buf = strconv.AppendUint(buf, e.Seq, 10)
+	}
+	if e.Stack != "" {
+		stack := strings.TrimRight(e.Stack, "\r\n")
+		if f.MultiLine == MultiLineEscape {
+			buf = append(buf, `\n\t`...)
+			buf = appendLines(buf, header, stack, `\t`, f.MultiLine)
+		} else {
+			buf = append(buf, '\n')
+			if f.MultiLine == MultiLineHeader {
+				buf = append(buf, header...)
+			}
+			buf = append(buf, '\t')
+			buf = appendLines(buf, header, stack, "\t", f.MultiLine)
+		}
+	}
+	return append(buf, '\n')
+}
+
+// text is a message as the formatters take it: a string, or the bytes WriteBytes and LogBytes copied it to.
+type text interface {
+	string | []byte
+}
+
+// trimLineEnd returns msg without the line endings at its end, however many there are and in whatever convention.
+func trimLineEnd[T text](msg T) T {
+	n := len(msg)
+	for n > 0 && (msg[n-1] == '\n' || msg[n-1] == '\r') {
+		n--
+	}
+	return msg[:n]
+}
+
+// decodeRune is utf8.DecodeRuneInString for either kind of text.
+func decodeRune[T text](s T) (rune, int) {
+	var b [utf8.UTFMax]byte
+	return utf8.DecodeRune(b[:copy(b[:], s)])
+}
+
+// appendLines appends the lines of s, with what policy puts between them, and indent at the start of every line
+// after the first.
+func appendLines[T text](buf, header []byte, s T, indent string, policy MultiLinePolicy) []byte {
+	for {
+		n := 0
+		for n < len(s) && s[n] != '\n' {
+			n++
+		}
+		line := s[:n]
+		if len(line) > 0 && line[len(line)-1] == '\r' {
+			line = line[:len(line)-1]
+		}
+		buf = append(buf, line...)
+		if n == len(s) {
+			return buf
+		}
+		switch policy {
+		case MultiLineHeader:
+			buf = append(buf, '\n')
+			buf = append(buf, header...)
+		case MultiLineIndent:
+			if indent == "" {
+				buf = append(buf, "\n\t| "...)
+			} else {
+				buf = append(buf, '\n')
+			}
+		case MultiLineEscape:
+			buf = append(buf, `\n`...)
+		}
+		buf = append(buf, indent...)
+		s = s[n+1:]
+	}
+}
+
+// JSONFormatter renders entries as one JSON object per line, e.g.
+// {"time":"2006-01-02T15:04:05Z","level":"info","msg":"hello","key":"value"}. A prefix is rendered as "component",
+// a logger name as "logger", a caller as "caller", a sequence number as "seq" and a stack trace as "stack".
+type JSONFormatter struct{}
+
+// Format implements Formatter.
+func (JSONFormatter) Format(buf []byte, e *Entry) []byte {
+	buf = append(buf, '{')
+	if !e.Time.IsZero() {
+		buf = append(buf, `"time":`...)
+		buf = appendJSONString(buf, e.Time.Format(time.RFC3339))
+		buf = append(buf, ',')
+	}
+	buf = append(buf, `"level":`...)
+	buf = appendJSONString(buf, strings.ToLower(e.Level.String()))
+	buf = append(buf, `,"msg":`...)
+	if e.msg != nil {
+		buf = appendJSONString(buf, trimLineEnd(*e.msg))
+	} else {
+		buf = appendJSONString(buf, trimLineEnd(e.Message))
+	}
+	if e.Prefix != "" {
+		buf = append(buf, `,"component":`...)
+		buf = appendJSONString(buf, e.Prefix)
+	}
+	if e.Name != "" {
+		buf = append(buf, `,"logger":`...)
+		buf = appendJSONString(buf, e.Name)
+	}
+	if e.Caller != "" {
+		buf = append(buf, `,"caller":`...)
+		buf = appendJSONString(buf, e.Caller)
+	}
+	buf = appendJSONFields(buf, e.Fields)
+	if e.Seq != 0 {
+		buf = append(buf, `,"seq":`...)
+		buf = strconv.AppendUint(buf, e.Seq, 10)
+	}
+	if e.Stack != "" {
+		buf = append(buf, `,"stack":`...)
+		buf = appendJSONString(buf, e.Stack)
+	}
+	return append(buf, "}\n"...)
+}
+
+// appendJSONFields appends fields as JSON object members, each preceded by a comma.
+func appendJSONFields(buf []byte, fields []Field) []byte {
+	for _, f := range fields {
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, f.Key)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, f.Value)
+	}
+	return buf
+}
+
+// appendJSONValue appends v encoded as JSON. Groups are encoded as nested objects, and errors and values that can't
+// be marshalled are encoded as strings.
+func appendJSONValue(buf []byte, v any) []byte {
+	switch v := v.(type) {
+	case string:
+		return appendJSONString(buf, v)
+	case error:
+		return appendJSONString(buf, v.Error())
+	case []Field:
+		if len(v) == 0 {
+			return append(buf, "{}"...)
+		}
+		start := len(buf)
+		buf = appendJSONFields(buf, v)
+		buf[start] = '{' // replace the leading comma
+		return append(buf, '}')
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprint(v))
+	}
+	return append(buf, data...)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string. Invalid UTF-8 is replaced with U+FFFD, like encoding/json
+// does, so the output is always valid JSON.
+func appendJSONString[T text](buf []byte, s T) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				buf = append(buf, '\\', c)
+			case c == '\n':
+				buf = append(buf, '\\', 'n')
+			case c == '\r':
+				buf = append(buf, '\\', 'r')
+			case c == '\t':
+				buf = append(buf, '\\', 't')
+			case c < 0x20:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			default:
+				buf = append(buf, c)
+			}
+			i++
+			continue
+		}
+		r, size := decodeRune(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, `\ufffd`...)
+		case r == '\u2028' || r == '\u2029':
+			buf = append(buf, `\u202`...)
+			buf = append(buf, hexDigits[r&0xf])
+		default:
+			buf = append(buf, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+// LogfmtFormatter renders entries as logfmt, e.g. time=2006-01-02T15:04:05Z level=info msg="hello world" key=value.
+// A prefix is rendered as "component", a logger name as "logger", a caller as "caller", a sequence number as "seq"
+// and a stack trace as "stack".
+type LogfmtFormatter struct{}
+
+// Format implements Formatter.
+func (LogfmtFormatter) Format(buf []byte, e *Entry) []byte {
+	if !e.Time.IsZero() {
+		buf = append(buf, "time="...)
+		buf = e.Time.AppendFormat(buf, time.RFC3339)
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, "level="...)
+	buf = append(buf, strings.ToLower(e.Level.String())...)
+	buf = append(buf, " msg="...)
+	if e.msg != nil {
+		buf = appendLogfmtValue(buf, trimLineEnd(*e.msg))
+	} else {
+		buf = appendLogfmtValue(buf, trimLineEnd(e.Message))
+	}
+	if e.Prefix != "" {
+		buf = append(buf, " component="...)
+		buf = appendLogfmtValue(buf, e.Prefix)
+	}
+	if e.Name != "" {
+		buf = append(buf, " logger="...)
+		buf = appendLogfmtValue(buf, e.Name)
+	}
+	if e.Caller != "" {
+		buf = append(buf, " caller="...)
+		buf = appendLogfmtValue(buf, e.Caller)
+	}
+	buf = appendLogfmtFields(buf, "", e.Fields)
+	if e.Seq != 0 {
+		buf = append(buf, " seq="...)
+		buf = strconv.AppendUint(buf, e.Seq, 10)
+	}
+	if e.Stack != "" {
+		buf = append(buf, " stack="...)
+		buf = appendLogfmtValue(buf, e.Stack)
+	}
+	return append(buf, '\n')
+}
+
+// appendLogfmtFields appends fields as key=value pairs, each preceded by a space and with every key preceded by
+// group.
+func appendLogfmtFields(buf []byte, group string, fields []Field) []byte {
+	for _, f := range fields {
+		if sub, ok := f.Value.([]Field); ok {
+			buf = appendLogfmtFields(buf, group+f.Key+".", sub)
+			continue
+		}
+		buf = append(buf, ' ')
+		buf = appendLogfmtKey(buf, group+f.Key)
+		buf = append(buf, '=')
+		buf = appendLogfmtValue(buf, fmt.Sprint(f.Value))
+	}
+	return buf
+}
+
+// appendLogfmtKey appends key with any characters that aren't allowed in a logfmt key replaced by underscores.
+func appendLogfmtKey(buf []byte, key string) []byte {
+	if key == "" {
+		return append(buf, '_')
+	}
+	for _, r := range key {
+		if needsLogfmtQuoting(r) {
+			r = '_'
+		}
+		buf = utf8.AppendRune(buf, r)
+	}
+	return buf
+}
+
+// appendLogfmtValue appends v, quoting and escaping it if it contains spaces, quotes, equals signs, control
+// characters or invalid UTF-8. Empty values are written as nothing, e.g. "key=".
+func appendLogfmtValue[T text](buf []byte, v T) []byte {
+	for i := 0; i < len(v); {
+		r, size := rune(v[i]), 1
+		if r >= utf8.RuneSelf {
+			r, size = decodeRune(v[i:])
+		}
+		if needsLogfmtQuoting(r) {
+			return appendJSONString(buf, v)
+		}
+		i += size
+	}
+	return append(buf, v...)
+}
+
+func needsLogfmtQuoting(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError
+}
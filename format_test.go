@@ -0,0 +1,315 @@
+package alog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeJSONLines(t *testing.T, data string) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Line isn't valid JSON: %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestJSONFormat(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat(), WithTimestampFormat("15:04"))
+	go alog.Start()
+	alog.WithFields(map[string]any{"user": "bob", "n": 3, "err": errors.New("boom")}).Warn("hello")
+	alog.Stop()
+
+	records := decodeJSONLines(t, b.String())
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 JSON line, got %d", len(records))
+	}
+	r := records[0]
+	if _, err := time.Parse(time.RFC3339, r["time"].(string)); err != nil {
+		t.Errorf("time isn't RFC3339: %v", err)
+	}
+	if r["level"] != "warn" || r["msg"] != "hello" {
+		t.Errorf("Unexpected level or msg: %v", r)
+	}
+	if r["user"] != "bob" || r["n"] != float64(3) || r["err"] != "boom" {
+		t.Errorf("Fields not encoded: %v", r)
+	}
+}
+
+func TestJSONFormatEscaping(t *testing.T) {
+	messages := []string{
+		`say "hi"`,
+		"first line\nsecond line",
+		"tab\tand\rcarriage return",
+		"back\\slash",
+		"bad utf8 \xff\xfe",
+		"control \x01 char",
+		"unicode \u2713 \u2028 \u2029",
+	}
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat(), WithLevel(LevelDebug))
+	for _, msg := range messages {
+		if _, err := alog.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	records := decodeJSONLines(t, b.String())
+	if len(records) != len(messages) {
+		t.Fatalf("Expected %d JSON lines, got %d", len(messages), len(records))
+	}
+	for i, msg := range messages {
+		want := strings.Replace(msg, "\xff\xfe", "\ufffd\ufffd", 1)
+		if records[i]["msg"] != want {
+			t.Errorf("Message didn't round trip: got %q, want %q", records[i]["msg"], want)
+		}
+		if records[i]["level"] != "info" {
+			t.Errorf("Expected Write to log at info, got %v", records[i]["level"])
+		}
+	}
+}
+
+func TestJSONFormatStripsTrailingNewline(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat())
+	if _, err := alog.Write("hello\n"); err != nil {
+		t.Fatal(err)
+	}
+	if records := decodeJSONLines(t, b.String()); records[0]["msg"] != "hello" {
+		t.Errorf("Trailing newline not stripped, got %q", records[0]["msg"])
+	}
+}
+
+// decodeLogfmt is a small reference decoder for a single logfmt line: space separated key=value pairs, where values
+// may be double quoted with backslash escapes.
+func decodeLogfmt(t *testing.T, line string) map[string]string {
+	t.Helper()
+	record := map[string]string{}
+	for line != "" {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			t.Fatalf("Missing key in logfmt line at %q", line)
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			end := 1
+			for ; end < len(line) && line[end] != '"'; end++ {
+				if line[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(line) {
+				t.Fatalf("Unterminated quoted value at %q", line)
+			}
+			if err := json.Unmarshal([]byte(line[:end+1]), &value); err != nil {
+				t.Fatalf("Invalid quoted value %q: %v", line[:end+1], err)
+			}
+			line = line[end+1:]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value = line[:end]
+			line = line[end:]
+		}
+		if strings.ContainsAny(key, " =\"") {
+			t.Fatalf("Invalid key %q", key)
+		}
+		record[key] = value
+	}
+	return record
+}
+
+func TestLogfmtFormat(t *testing.T) {
+	tests := []struct {
+		msg    string
+		fields map[string]any
+	}{
+		{"hello world", nil},
+		{`she said "hi"`, map[string]any{"user": "bob smith"}},
+		{"unicode ✓ café", map[string]any{"eq": "a=b"}},
+		{"first\nsecond", map[string]any{"empty": ""}},
+		{"plain", map[string]any{"bad key": 1, "n": 42}},
+	}
+	for _, tt := range tests {
+		b := bytes.NewBuffer([]byte{})
+		alog := New(b, WithLogfmtFormat())
+		go alog.Start()
+		alog.WithFields(tt.fields).Error(tt.msg)
+		alog.Stop()
+
+		line := b.String()
+		if strings.Count(line, "\n") != 1 || !strings.HasSuffix(line, "\n") {
+			t.Errorf("Expected exactly one line, got %q", line)
+		}
+		record := decodeLogfmt(t, strings.TrimSuffix(line, "\n"))
+		if _, err := time.Parse(time.RFC3339, record["time"]); err != nil {
+			t.Errorf("time isn't RFC3339 in %q: %v", line, err)
+		}
+		if record["level"] != "error" {
+			t.Errorf("Expected level=error in %q", line)
+		}
+		if record["msg"] != tt.msg {
+			t.Errorf("msg didn't round trip: got %q, want %q", record["msg"], tt.msg)
+		}
+		for k, v := range tt.fields {
+			key := strings.ReplaceAll(k, " ", "_")
+			if got, ok := record[key]; !ok || got != fmt.Sprint(v) {
+				t.Errorf("Field %q didn't round trip in %q", k, line)
+			}
+		}
+	}
+}
+
+func TestLogfmtEmptyValue(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithLogfmtFormat()).WithFields(map[string]any{"empty": ""})
+	if _, err := alog.Write("msg"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), " msg=msg empty=\n") {
+		t.Errorf("Empty value not emitted, got %q", b.String())
+	}
+}
+
+type appNameFormatter struct {
+	next Formatter
+}
+
+func (f appNameFormatter) Format(buf []byte, e *Entry) []byte {
+	buf = append(buf, "myapp: "...)
+	return f.next.Format(buf, e)
+}
+
+func TestWithFormatter(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithLatePolicy(LateWriteSync), WithFormatter(appNameFormatter{TextFormatter{Layout: "15:04"}}),
+		WithClock(newFakeClock(time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local))))
+	go alog.Start()
+	alog.Warn("async")
+	alog.MessageChannel() <- "channel"
+	alog.Stop()
+	if _, err := alog.Write("sync"); err != nil {
+		t.Fatal(err)
+	}
+	want := "myapp: [09:30] [WARN] - async\nmyapp: [09:30] - channel\nmyapp: [09:30] - sync\n"
+	if got := b.String(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+	if _, ok := alog.Formatter().(appNameFormatter); !ok {
+		t.Errorf("Formatter returned %T", alog.Formatter())
+	}
+	if _, ok := New(b).Formatter().(TextFormatter); !ok {
+		t.Error("Expected a TextFormatter by default")
+	}
+}
+
+func TestTextFormatterMatchesOriginalLayout(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	f := TextFormatter{Layout: defaultTimestampFormat}
+	tests := map[string]string{
+		"test":         "[2024-01-02 10:00:00] - test\n",
+		"test\n":       "[2024-01-02 10:00:00] - test\n",
+		"test\r\n":     "[2024-01-02 10:00:00] - test\n",
+		"test\r":       "[2024-01-02 10:00:00] - test\n",
+		"test\n\n":     "[2024-01-02 10:00:00] - test\n",
+		"test\r\n\r\n": "[2024-01-02 10:00:00] - test\n",
+		"a\rb\n":       "[2024-01-02 10:00:00] - a\rb\n",
+		"":             "[2024-01-02 10:00:00] - \n",
+		"\n":           "[2024-01-02 10:00:00] - \n",
+	}
+	for msg, want := range tests {
+		e := &Entry{Time: ts, Level: LevelInfo, Message: msg, implicit: true}
+		if got := string(f.Format(nil, e)); got != want {
+			t.Errorf("Format(%q) = %q, want %q", msg, got, want)
+		}
+	}
+	e := &Entry{Time: ts, Level: LevelError, Message: "test"}
+	if got := string(f.Format([]byte("prefix "), e)); got != "prefix [2024-01-02 10:00:00] [ERROR] - test\n" {
+		t.Errorf("Format didn't append to buf or render the level, got %q", got)
+	}
+}
+
+func TestMultiLine(t *testing.T) {
+	e := Entry{
+		Time:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Level:   LevelWarn,
+		Message: "first\r\nsecond\n\n\n",
+		Fields:  []Field{{"id", 7}},
+		Stack:   "goroutine 1\r\nmain.go:10\n",
+	}
+	tests := []struct {
+		policy MultiLinePolicy
+		want   string
+	}{
+		{MultiLineHeader, "[12:00] [WARN] - first\n[12:00] [WARN] - second id=7\n[12:00] [WARN] - \tgoroutine 1\n" +
+			"[12:00] [WARN] - \tmain.go:10\n"},
+		{MultiLineIndent, "[12:00] [WARN] - first\n\t| second id=7\n\tgoroutine 1\n\tmain.go:10\n"},
+		{MultiLineEscape, `[12:00] [WARN] - first\nsecond id=7\n\tgoroutine 1\n\tmain.go:10` + "\n"},
+	}
+	for _, test := range tests {
+		got := string(TextFormatter{Layout: "15:04", MultiLine: test.policy}.Format(nil, &e))
+		if got != test.want {
+			t.Errorf("Policy %d: got %q, want %q", test.policy, got, test.want)
+		}
+	}
+}
+
+func TestMultiLineOption(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithMultiLine(MultiLineEscape))
+	alog.Write("one\ntwo\n\n")
+	alog.Write("three")
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], `- one\ntwo`) {
+		t.Errorf("Expected one line per message, got %q", b.String())
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	msgs := []string{"plain", "unix\n", "windows\r\n", "mac\r", "", "several\n\n\r\n", "inner\rcr"}
+	text := []string{"[INFO] - plain", "[INFO] - unix", "[INFO] - windows", "[INFO] - mac", "[INFO] - ",
+		"[INFO] - several", "[INFO] - inner\rcr"}
+	tests := []struct {
+		formatter Formatter
+		ending    string
+		want      []string
+	}{
+		{TextFormatter{}, "\r\n", text},
+		{TextFormatter{}, "\n", text},
+		{TextFormatter{}, "", text},
+		{TextFormatter{MultiLine: MultiLineEscape}, "\r\n", text},
+		{LogfmtFormatter{}, "\r\n", []string{"level=info msg=plain", "level=info msg=unix", "level=info msg=windows",
+			"level=info msg=mac", "level=info msg=", "level=info msg=several", `level=info msg="inner\rcr"`}},
+	}
+	for _, test := range tests {
+		b := &lockedBuffer{}
+		alog := New(b, WithFormatter(test.formatter), WithTimestampFormat(""), WithLineEnding(test.ending))
+		alog.now = func() time.Time { return time.Time{} }
+		go alog.Start()
+		for _, msg := range msgs {
+			alog.Info(msg)
+		}
+		alog.Stop()
+		ending := test.ending
+		if ending == "" {
+			ending = "\n"
+		}
+		if want := strings.Join(test.want, ending) + ending; b.String() != want {
+			t.Errorf("%T with %q: got %q, want %q", test.formatter, test.ending, b.String(), want)
+		}
+	}
+}
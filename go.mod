@@ -1,3 +1,3 @@
 module alog
 
-go 1.14
+go 1.21
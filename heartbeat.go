@@ -0,0 +1,50 @@
+package alog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// heartbeat is the state of WithHeartbeat. It's only used on the Start loop's goroutine.
+type heartbeat struct {
+	interval time.Duration
+	msg      string
+	written  uint64 // Written as of the last tick, counting the heartbeat written then
+}
+
+// WithHeartbeat makes the Start loop write msg at LevelInfo whenever nothing has been written for interval, so a
+// quiet log can be told apart from a logger that's stuck. Heartbeats go through the formatter like any other
+// message, with the logger's timestamp and fields, but they aren't filtered, sampled or held back as repeats. The
+// Start loop checks every interval, so a heartbeat comes between one and two intervals after the last message, and
+// none is written while the destination is busy with messages. Heartbeats stop with the loop. An interval of 0 or
+// less turns them off.
+func WithHeartbeat(interval time.Duration, msg string) Option {
+	return func(al *Alog) {
+		al.heartbeat = nil
+		if interval > 0 {
+			al.heartbeat = &heartbeat{interval: interval, msg: msg}
+		}
+	}
+}
+
+// startHeartbeat returns the ticker for WithHeartbeat, or nil.
+func (al *Alog) startHeartbeat() Ticker {
+	if al.heartbeat == nil {
+		return nil
+	}
+	al.heartbeat.written = atomic.LoadUint64(&al.written)
+	return al.clock.NewTicker(al.heartbeat.interval)
+}
+
+// beat writes a heartbeat if nothing has been written, or is waiting to be, since the last tick.
+func (al *Alog) beat(wg *sync.WaitGroup) {
+	hb := al.heartbeat
+	written := atomic.LoadUint64(&al.written)
+	if written != hb.written || al.Pending() > 0 {
+		hb.written = written
+		return
+	}
+	al.dispatch(al.newEntry(LevelInfo, hb.msg), wg)
+	hb.written = written + 1
+}
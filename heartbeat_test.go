@@ -0,0 +1,69 @@
+package alog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	b := &lockedBuffer{}
+	fc := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local))
+	alog := New(b, WithClock(fc), WithHeartbeat(time.Minute, "alive"), WithStaticFields(map[string]string{"app": "api"}))
+	go alog.Start()
+	fc.ticks <- fc.Now() // idle since the start
+	alog.Info("busy")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fc.ticks <- fc.Now() // busy was written since the last tick
+	fc.ticks <- fc.Now() // idle again
+	alog.Stop()
+
+	want := "[2024-05-01 12:00:00] [INFO] - alive app=api\n" +
+		"[2024-05-01 12:00:00] [INFO] - busy app=api\n" +
+		"[2024-05-01 12:00:00] [INFO] - alive app=api\n"
+	if got := b.String(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+	select {
+	case fc.ticks <- fc.Now():
+		t.Error("Expected the heartbeat to stop with the logger")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestHeartbeatIdleAndBusy(t *testing.T) {
+	b := &lockedBuffer{}
+	alog := New(b, WithHeartbeat(20*time.Millisecond, "alive"), WithTimestampFormat(""))
+	go alog.Start()
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 50; i++ {
+		alog.Info("busy")
+		time.Sleep(2 * time.Millisecond)
+	}
+	alog.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	first, last, beats := -1, -1, 0
+	for i, line := range lines {
+		switch line {
+		case "[INFO] - alive":
+			beats++
+		case "[INFO] - busy":
+			if first < 0 {
+				first = i
+			}
+			last = i
+		default:
+			t.Fatalf("Unexpected line %q", line)
+		}
+	}
+	if beats == 0 || first != beats {
+		t.Errorf("Expected heartbeats while idle and only before the messages, got %q", lines)
+	}
+	if last-first != 49 {
+		t.Errorf("Expected no heartbeats between the messages, got %q", lines[first:last+1])
+	}
+}
@@ -0,0 +1,87 @@
+package alog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HookPanicError is sent on the error channel when a hook added with WithBeforeWrite or WithAfterWrite, or the
+// function passed to WithDeadLetterFunc, panics. The panic is recovered and the message is handled as if the hook
+// had returned normally: a BeforeWrite hook that panics keeps the message, with whatever changes it made before
+// panicking.
+type HookPanicError struct {
+	Hook  string // "BeforeWrite", "AfterWrite" or "DeadLetter"
+	Value any    // the value passed to panic
+}
+
+func (e *HookPanicError) Error() string {
+	return fmt.Sprintf("alog: %s hook panicked: %v", e.Hook, e.Value)
+}
+
+// errVetoed is returned by writeMessage for a message that a BeforeWrite hook decided not to write.
+var errVetoed = errors.New("vetoed by a BeforeWrite hook")
+
+// WithBeforeWrite calls hook with every message just before it's formatted, once its time and static fields are
+// set and it has been truncated and redacted. hook may change the entry, and the message is only written if it
+// returns true. Fields shouldn't be changed in place, since the slice may be shared with other messages; assign a
+// new slice to e.Fields instead. hook must not retain e.
+//
+// Hooks are called on the goroutine that writes the message, which is the Start loop, or the caller for Write, so
+// they hold up every message behind them and must be fast. With WithWorkers they're called by the workers,
+// concurrently. WithBeforeWrite can be used more than once and the hooks are called in the order they were added,
+// until one of them returns false.
+func WithBeforeWrite(hook func(e *Entry) bool) Option {
+	return func(al *Alog) {
+		al.beforeWrite = append(al.beforeWrite, hook)
+	}
+}
+
+// WithAfterWrite calls hook after every write with the formatted bytes, the number of bytes the writer passed to
+// New took and the write's error, including the errors from the other destinations, such as those added with
+// WithAdditionalWriter. With WithBatching hook is called once for each batch. For a destination that's an
+// EntryWriter p is the formatted message, even though the destination was given the entry itself. hook must not
+// retain p.
+//
+// Hooks are called on the same goroutine as the ones added with WithBeforeWrite, so they must be fast too.
+// WithAfterWrite can be used more than once and the hooks are called in the order they were added.
+func WithAfterWrite(hook func(p []byte, n int, err error)) Option {
+	return func(al *Alog) {
+		al.afterWrite = append(al.afterWrite, hook)
+	}
+}
+
+// runBeforeWrite calls the BeforeWrite hooks with e and reports whether e should be written.
+func (al *Alog) runBeforeWrite(e *Entry) bool {
+	for _, hook := range al.beforeWrite {
+		if !al.callBeforeWrite(hook, e) {
+			return false
+		}
+	}
+	return true
+}
+
+// callBeforeWrite calls hook. e is kept if hook panics.
+func (al *Alog) callBeforeWrite(hook func(e *Entry) bool, e *Entry) (keep bool) {
+	keep = true
+	defer al.recoverHook("BeforeWrite")
+	return hook(e)
+}
+
+// runAfterWrite calls the AfterWrite hooks.
+func (al *Alog) runAfterWrite(p []byte, n int, err error) {
+	for _, hook := range al.afterWrite {
+		al.callAfterWrite(hook, p, n, err)
+	}
+}
+
+func (al *Alog) callAfterWrite(hook func(p []byte, n int, err error), p []byte, n int, err error) {
+	defer al.recoverHook("AfterWrite")
+	hook(p, n, err)
+}
+
+// recoverHook recovers from a panicking hook and reports it. It must be deferred by the function calling the hook.
+func (al *Alog) recoverHook(name string) {
+	if v := recover(); v != nil {
+		al.reportError(&HookPanicError{Hook: name, Value: v})
+	}
+}
@@ -0,0 +1,165 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBeforeWrite(t *testing.T) {
+	lb := &lockedBuffer{}
+	var order []string
+	alog := New(lb,
+		WithBeforeWrite(func(e *Entry) bool {
+			order = append(order, "first:"+e.Message)
+			e.Message = strings.ToUpper(e.Message)
+			return true
+		}),
+		WithBeforeWrite(func(e *Entry) bool {
+			order = append(order, "second:"+e.Message)
+			if e.Message == "SECRET" {
+				return false
+			}
+			e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], Field{Key: "hooked", Value: true})
+			return true
+		}),
+		WithBeforeWrite(func(e *Entry) bool {
+			order = append(order, "third:"+e.Message)
+			return true
+		}),
+		WithBufferSize(10))
+	go alog.Start()
+	alog.Info("hello")
+	alog.Info("secret")
+	alog.Stop()
+
+	if got := writtenMessages(lb); len(got) != 1 || got[0] != "HELLO hooked=true" {
+		t.Errorf("Got %q", got)
+	}
+	want := "first:hello second:HELLO third:HELLO first:secret second:SECRET"
+	if got := strings.Join(order, " "); got != want {
+		t.Errorf("Hooks called as %q, want %q", got, want)
+	}
+	if n := atomic.LoadUint64(&alog.written); n != 1 {
+		t.Errorf("Expected 1 written message, got %d", n)
+	}
+}
+
+func TestBeforeWriteSync(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBeforeWrite(func(e *Entry) bool { return e.Message != "drop" }))
+	if n, err := alog.Write("drop"); n != 0 || err != nil {
+		t.Errorf("Got %d, %v for a vetoed message", n, err)
+	}
+	if _, err := alog.Write("keep"); err != nil {
+		t.Fatal(err)
+	}
+	if got := writtenMessages(lb); len(got) != 1 || got[0] != "keep" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestAfterWrite(t *testing.T) {
+	var calls []string
+	alog := New(errorWriter{bytes.NewBuffer(nil)}, WithErrorBuffer(10),
+		WithAfterWrite(func(p []byte, n int, err error) {
+			if !strings.HasSuffix(string(p), "[INFO] - hello\n") || n != 0 || err == nil || err.Error() != "error" {
+				t.Errorf("Got %q, %d, %v", p, n, err)
+			}
+			calls = append(calls, "first")
+		}),
+		WithAfterWrite(func(p []byte, n int, err error) {
+			calls = append(calls, "second")
+		}))
+	go alog.Start()
+	alog.Info("hello")
+	alog.Stop()
+
+	if got := strings.Join(calls, " "); got != "first second" {
+		t.Errorf("Hooks called as %q", got)
+	}
+}
+
+func TestAfterWriteBytes(t *testing.T) {
+	lb := &lockedBuffer{}
+	var written []byte
+	var total int
+	alog := New(lb, WithAfterWrite(func(p []byte, n int, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		written = append(written, p...)
+		total += n
+	}))
+	go alog.Start()
+	alog.Info("one")
+	alog.Info("two")
+	alog.Stop()
+
+	if string(written) != lb.String() || total != len(written) {
+		t.Errorf("Hooks got %q and %d bytes, the destination got %q", written, total, lb.String())
+	}
+}
+
+func TestHookPanic(t *testing.T) {
+	lb := &lockedBuffer{}
+	var after int
+	alog := New(lb, WithErrorBuffer(10),
+		WithBeforeWrite(func(e *Entry) bool {
+			e.Message += "!"
+			if e.Message == "boom!" {
+				panic("before")
+			}
+			return true
+		}),
+		WithBeforeWrite(func(e *Entry) bool { return true }),
+		WithAfterWrite(func(p []byte, n int, err error) { panic("after") }),
+		WithAfterWrite(func(p []byte, n int, err error) { after++ }))
+	go alog.Start()
+	alog.Info("boom")
+	alog.Info("fine")
+	alog.Stop()
+
+	if got := writtenMessages(lb); strings.Join(got, ",") != "boom!,fine!" {
+		t.Errorf("Got %q", got)
+	}
+	if after != 2 {
+		t.Errorf("Expected the second AfterWrite hook to be called twice, got %d", after)
+	}
+	var hooks []string
+	for len(alog.ErrorChannel()) > 0 {
+		var hpe *HookPanicError
+		if err := <-alog.ErrorChannel(); !errors.As(err, &hpe) {
+			t.Fatalf("Unexpected error %v", err)
+		} else {
+			hooks = append(hooks, hpe.Hook+":"+hpe.Value.(string))
+		}
+	}
+	if got := strings.Join(hooks, " "); got != "BeforeWrite:before AfterWrite:after AfterWrite:after" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestHooksBatching(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	var batches int64
+	alog := New(gw, WithBufferSize(100), WithBatching(50, 0),
+		WithBeforeWrite(func(e *Entry) bool { return e.Message != "m2" }),
+		WithAfterWrite(func(p []byte, n int, err error) { atomic.AddInt64(&batches, 1) }))
+	go alog.Start()
+	queueBehind(t, alog, 3)
+	close(gw.open)
+	alog.Stop()
+
+	if got := writtenMessages(gw.b); strings.Join(got, ",") != "m0,m1,m3" {
+		t.Errorf("Got %q", got)
+	}
+	if batches != 2 {
+		t.Errorf("Expected AfterWrite for m0 and a batch, got %d calls", batches)
+	}
+	if n := atomic.LoadUint64(&alog.written); n != 3 {
+		t.Errorf("Expected 3 written messages, got %d", n)
+	}
+}
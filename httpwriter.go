@@ -0,0 +1,319 @@
+package alog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HTTPWriter collects formatted lines and POSTs them to an ingest endpoint, newline-delimited, once a batch is full
+// or a flush interval has passed. Requests are made on a goroutine of its own, so a slow endpoint never holds up
+// the logger. A request that fails with a network error or a 5xx response is retried, waiting twice as long every
+// time; a 4xx response drops the batch. A logger's Flush posts the pending lines and waits for the requests, and
+// Stop does the same for up to 10 seconds.
+//
+// Dropped batches are reported as errors by the next Write, or by Flush, which makes them reach the logger's error
+// channel. HTTPWriter is safe for concurrent use.
+type HTTPWriter struct {
+	url      string
+	client   *http.Client
+	header   http.Header
+	maxLines int
+	interval time.Duration
+	timeout  time.Duration
+	retries  int
+	backoff  time.Duration
+	gzip     bool
+
+	m       sync.Mutex
+	batch   []byte
+	lines   int
+	sendCh  chan httpBatch // to the goroutine that posts
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+	pending sync.WaitGroup // batches handed to the goroutine that haven't been posted yet
+	bgM     sync.Mutex     // guards bgErrs, which the goroutine adds to
+	bgErrs  []error
+}
+
+type httpBatch struct {
+	data  []byte
+	lines int
+}
+
+// HTTPError reports a batch that an HTTPWriter dropped because of the endpoint's response.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Lines      int // in the dropped batch
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("alog: POST %s: %d %s, dropped %d lines", e.URL, e.StatusCode, http.StatusText(e.StatusCode), e.Lines)
+}
+
+// maxQueuedBatches is how many full batches an HTTPWriter holds on to while it's waiting for the endpoint. Later
+// batches are dropped.
+const maxQueuedBatches = 16
+
+// HTTPOption configures an HTTPWriter.
+type HTTPOption func(*HTTPWriter)
+
+// WithHTTPBatch sets how many lines an HTTPWriter posts at once and how long it waits for more before it posts
+// what it has. The defaults are 1000 lines and 1s.
+func WithHTTPBatch(maxLines int, flushInterval time.Duration) HTTPOption {
+	return func(hw *HTTPWriter) {
+		hw.maxLines = maxLines
+		hw.interval = flushInterval
+	}
+}
+
+// WithHTTPHeader sets a header on every request, such as an Authorization token.
+func WithHTTPHeader(key, value string) HTTPOption {
+	return func(hw *HTTPWriter) {
+		hw.header.Set(key, value)
+	}
+}
+
+// WithHTTPTimeout sets how long a request may take. The default is 10s.
+func WithHTTPTimeout(d time.Duration) HTTPOption {
+	return func(hw *HTTPWriter) {
+		hw.timeout = d
+	}
+}
+
+// WithHTTPRetries sets how many times a failed request is retried and how long to wait before the first retry.
+// The defaults are 3 and 100ms.
+func WithHTTPRetries(n int, backoff time.Duration) HTTPOption {
+	return func(hw *HTTPWriter) {
+		hw.retries = n
+		hw.backoff = backoff
+	}
+}
+
+// WithHTTPGzip compresses requests with gzip.
+func WithHTTPGzip() HTTPOption {
+	return func(hw *HTTPWriter) {
+		hw.gzip = true
+	}
+}
+
+// WithHTTPClient sets the client that makes the requests. The default is http.DefaultClient.
+func WithHTTPClient(c *http.Client) HTTPOption {
+	return func(hw *HTTPWriter) {
+		hw.client = c
+	}
+}
+
+// NewHTTPWriter returns an HTTPWriter that posts to url, which can be passed to New. Call Close once the logger
+// has been stopped.
+func NewHTTPWriter(url string, opts ...HTTPOption) *HTTPWriter {
+	hw := &HTTPWriter{
+		url:      url,
+		client:   http.DefaultClient,
+		header:   http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		maxLines: 1000,
+		interval: time.Second,
+		timeout:  10 * time.Second,
+		retries:  3,
+		backoff:  100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(hw)
+	}
+	return hw
+}
+
+// Write implements io.Writer. data is added to the current batch, which is handed over to be posted once it's full.
+func (hw *HTTPWriter) Write(data []byte) (int, error) {
+	hw.m.Lock()
+	if hw.sendCh == nil {
+		hw.start()
+	}
+	hw.batch = append(hw.batch, data...)
+	hw.lines += bytes.Count(data, []byte{'\n'})
+	if hw.lines >= hw.maxLines {
+		hw.handOff()
+	}
+	hw.m.Unlock()
+	return len(data), hw.backgroundErr()
+}
+
+// Flush posts the current batch and waits until every batch has been posted or dropped. It returns the errors
+// for the dropped ones.
+func (hw *HTTPWriter) Flush() error {
+	hw.m.Lock()
+	hw.handOff()
+	hw.m.Unlock()
+	hw.pending.Wait()
+	return hw.backgroundErr()
+}
+
+// Close flushes the HTTPWriter and stops its goroutine. A later Write starts it again.
+func (hw *HTTPWriter) Close() error {
+	err := hw.Flush()
+	hw.m.Lock()
+	stopCh, doneCh := hw.stopCh, hw.doneCh
+	started := hw.sendCh != nil
+	hw.sendCh = nil
+	hw.m.Unlock()
+	if started {
+		close(stopCh)
+		<-doneCh
+	}
+	if err == nil {
+		err = hw.backgroundErr()
+	}
+	return err
+}
+
+func (hw *HTTPWriter) waitBackground(timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- hw.Flush()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errors.New("alog: gave up waiting for log lines to be posted")
+	}
+}
+
+// start starts the goroutine that posts batches. The caller holds m.
+func (hw *HTTPWriter) start() {
+	hw.sendCh = make(chan httpBatch, maxQueuedBatches)
+	hw.stopCh = make(chan struct{})
+	hw.doneCh = make(chan struct{})
+	go hw.run(hw.sendCh, hw.stopCh, hw.doneCh)
+}
+
+func (hw *HTTPWriter) run(sendCh chan httpBatch, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+	var tickCh <-chan time.Time
+	if hw.interval > 0 {
+		ticker := time.NewTicker(hw.interval)
+		defer ticker.Stop()
+		tickCh = ticker.C
+	}
+	for {
+		select {
+		case b := <-sendCh:
+			hw.send(b)
+		case <-tickCh:
+			hw.m.Lock()
+			hw.handOff()
+			hw.m.Unlock()
+		case <-stopCh:
+			for {
+				select {
+				case b := <-sendCh: // written after the Flush in Close
+					hw.send(b)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handOff hands the current batch to the goroutine that posts, or drops it if too many are waiting already. The
+// caller holds m.
+func (hw *HTTPWriter) handOff() {
+	if len(hw.batch) == 0 || hw.sendCh == nil {
+		return
+	}
+	b := httpBatch{data: hw.batch, lines: hw.lines}
+	hw.batch, hw.lines = nil, 0
+	hw.pending.Add(1)
+	select {
+	case hw.sendCh <- b:
+	default:
+		hw.pending.Done()
+		hw.addBackgroundErr(fmt.Errorf("alog: POST %s: too many batches waiting, dropped %d lines", hw.url, b.lines))
+	}
+}
+
+// send posts b and records the error if it's dropped.
+func (hw *HTTPWriter) send(b httpBatch) {
+	if err := hw.post(b); err != nil {
+		hw.addBackgroundErr(err)
+	}
+	hw.pending.Done()
+}
+
+// post posts b, retrying as needed.
+func (hw *HTTPWriter) post(b httpBatch) error {
+	body := b.data
+	if hw.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		zw.Close()
+		body = buf.Bytes()
+	}
+	backoff := hw.backoff
+	for attempt := 0; ; attempt++ {
+		status, err := hw.postOnce(body)
+		if err == nil && status < 300 {
+			return nil
+		}
+		if err == nil && status < 500 {
+			return &HTTPError{URL: hw.url, StatusCode: status, Lines: b.lines}
+		}
+		if attempt >= hw.retries {
+			if err == nil {
+				return &HTTPError{URL: hw.url, StatusCode: status, Lines: b.lines}
+			}
+			return fmt.Errorf("alog: POST %s: dropped %d lines: %w", hw.url, b.lines, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (hw *HTTPWriter) postOnce(body []byte) (int, error) {
+	ctx := context.Background()
+	if hw.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hw.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hw.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range hw.header {
+		req.Header[key] = values
+	}
+	if hw.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	resp, err := hw.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body) // so the connection can be reused
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (hw *HTTPWriter) addBackgroundErr(err error) {
+	hw.bgM.Lock()
+	hw.bgErrs = append(hw.bgErrs, err)
+	hw.bgM.Unlock()
+}
+
+func (hw *HTTPWriter) backgroundErr() error {
+	hw.bgM.Lock()
+	defer hw.bgM.Unlock()
+	err := joinErrors(hw.bgErrs)
+	hw.bgErrs = nil
+	return err
+}
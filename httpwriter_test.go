@@ -0,0 +1,152 @@
+package alog
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ingest is a fake ingest endpoint that records the bodies it receives and answers with the statuses in
+// responses, in turn, and then with 200.
+type ingest struct {
+	m         sync.Mutex
+	bodies    []string
+	requests  int32
+	responses []int
+	header    http.Header
+}
+
+func (in *ingest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&in.requests, 1)
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	data, _ := io.ReadAll(body)
+	in.m.Lock()
+	defer in.m.Unlock()
+	in.header = r.Header
+	if len(in.responses) > 0 {
+		status := in.responses[0]
+		in.responses = in.responses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	in.bodies = append(in.bodies, string(data))
+}
+
+func (in *ingest) received() []string {
+	in.m.Lock()
+	defer in.m.Unlock()
+	return append([]string(nil), in.bodies...)
+}
+
+func TestHTTPWriterBatches(t *testing.T) {
+	in := &ingest{}
+	srv := httptest.NewServer(in)
+	defer srv.Close()
+	hw := NewHTTPWriter(srv.URL, WithHTTPBatch(3, time.Hour))
+	defer hw.Close()
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n", "5\n", "6\n", "7\n"} {
+		hw.Write([]byte(line))
+	}
+	if err := hw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(in.received(), "|"); got != "1\n2\n3\n|4\n5\n6\n|7\n" {
+		t.Errorf("Got batches %q", got)
+	}
+}
+
+func TestHTTPWriterInterval(t *testing.T) {
+	in := &ingest{}
+	srv := httptest.NewServer(in)
+	defer srv.Close()
+	hw := NewHTTPWriter(srv.URL, WithHTTPBatch(100, 10*time.Millisecond))
+	defer hw.Close()
+	hw.Write([]byte("1\n"))
+	for deadline := time.Now().Add(5 * time.Second); len(in.received()) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("The batch wasn't posted on the interval")
+		}
+	}
+}
+
+func TestHTTPWriterRetries(t *testing.T) {
+	in := &ingest{responses: []int{http.StatusServiceUnavailable, http.StatusBadGateway}}
+	srv := httptest.NewServer(in)
+	defer srv.Close()
+	hw := NewHTTPWriter(srv.URL, WithHTTPRetries(3, time.Millisecond), WithHTTPGzip(),
+		WithHTTPHeader("Authorization", "Bearer token"))
+	defer hw.Close()
+	hw.Write([]byte("1\n"))
+	if err := hw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := in.received(); len(got) != 1 || got[0] != "1\n" || atomic.LoadInt32(&in.requests) != 3 {
+		t.Errorf("Expected the batch after two retries, got %q in %d requests", got, in.requests)
+	}
+	if auth := in.header.Get("Authorization"); auth != "Bearer token" {
+		t.Errorf("Got Authorization %q", auth)
+	}
+
+	in.m.Lock()
+	in.responses = []int{500, 500, 500, 500}
+	in.m.Unlock()
+	hw.Write([]byte("2\n"))
+	var he *HTTPError
+	if err := hw.Flush(); !errors.As(err, &he) || he.StatusCode != 500 || he.Lines != 1 {
+		t.Errorf("Expected the batch to be dropped after 3 retries, got %v", err)
+	}
+}
+
+func TestHTTPWriterClientError(t *testing.T) {
+	in := &ingest{responses: []int{http.StatusUnauthorized}}
+	srv := httptest.NewServer(in)
+	defer srv.Close()
+	hw := NewHTTPWriter(srv.URL)
+	defer hw.Close()
+	hw.Write([]byte("1\n2\n"))
+	err := hw.Flush()
+	if want := "alog: POST " + srv.URL + ": 401 Unauthorized, dropped 2 lines"; err == nil || err.Error() != want {
+		t.Errorf("Got %v, want %s", err, want)
+	}
+	if n := atomic.LoadInt32(&in.requests); n != 1 {
+		t.Errorf("Expected no retries, got %d requests", n)
+	}
+}
+
+func TestHTTPWriterLogger(t *testing.T) {
+	in := &ingest{}
+	srv := httptest.NewServer(in)
+	defer srv.Close()
+	hw := NewHTTPWriter(srv.URL, WithHTTPBatch(100, time.Hour))
+	defer hw.Close()
+	alog := New(hw, WithBufferSize(10))
+	go alog.Start()
+	alog.Info("flushed")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	alog.Info("stopped")
+	alog.Stop()
+	got := in.received()
+	if len(got) != 2 || !strings.HasSuffix(got[0], "- flushed\n") || !strings.HasSuffix(got[1], "- stopped\n") {
+		t.Errorf("Expected a POST for Flush and one for Stop, got %q", got)
+	}
+}
@@ -0,0 +1,237 @@
+package alog
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Level is the severity of a log message. Messages below the logger's level are discarded. The values leave gaps
+// between the named levels so the defaults line up with log/slog.
+type Level int32
+
+// Levels supported by the logger, from least to most severe.
+const (
+	LevelTrace Level = -8
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+	LevelPanic Level = 10 // see Alog.Panic
+	LevelFatal Level = 12 // see Alog.Fatal
+)
+
+// String returns the upper case name of the level, e.g. "WARN". Levels between the named ones are rendered as an
+// offset from the closest named level below them, e.g. "INFO+2", and levels below LevelTrace as an offset from it.
+func (l Level) String() string {
+	str := func(base string, offset Level) string {
+		if offset == 0 {
+			return base
+		}
+		return fmt.Sprintf("%s%+d", base, offset)
+	}
+	switch {
+	case l < LevelDebug:
+		return str("TRACE", l-LevelTrace)
+	case l < LevelInfo:
+		return str("DEBUG", l-LevelDebug)
+	case l < LevelWarn:
+		return str("INFO", l-LevelInfo)
+	case l < LevelError:
+		return str("WARN", l-LevelWarn)
+	case l < LevelPanic:
+		return str("ERROR", l-LevelError)
+	case l < LevelFatal:
+		return str("PANIC", l-LevelPanic)
+	default:
+		return str("FATAL", l-LevelFatal)
+	}
+}
+
+// SetLevel changes the minimum level of messages that are written. It is safe to call while the logger is running
+// and while other goroutines are logging; messages that were already queued are not affected.
+func (al *Alog) SetLevel(l Level) {
+	atomic.StoreInt32(&al.level, int32(l))
+}
+
+// Level returns the minimum level of messages that are written.
+func (al *Alog) Level() Level {
+	return Level(atomic.LoadInt32(&al.level))
+}
+
+// enabled reports whether messages at level l should be logged.
+func (al *Alog) enabled(l Level) bool {
+	return l >= al.Level()
+}
+
+// log queues msg for the Start loop if l is enabled, along with the logger's fields and any key/value pairs in kv.
+func (al *Alog) log(l Level, msg string, kv []any) error {
+	if !al.enabled(l) || al.sampler.sampleOut(l) {
+		return nil
+	}
+	return al.logEntry(l, msg, kv)
+}
+
+// logEntry builds the entry for msg and queues it. It must only be called by log, logf and logCtx, which are called
+// by the level methods and the package-level functions, so the caller is always the same number of frames up.
+func (al *Alog) logEntry(l Level, msg string, kv []any) error {
+	e := al.newEntry(l, msg)
+	if len(kv) > 0 {
+		e.Fields = mergeFields(e.Fields, kvFields(kv))
+	}
+	e.Caller = al.caller(3)
+	e.Stack = al.stack(l)
+	return al.enqueue(e)
+}
+
+// newEntry returns an entry for msg that carries al's prefix, name and fields, stamped with the current time. It's
+// called on the logging goroutine, so the time is when the message was logged even if it waits in the queue behind a
+// slow destination.
+func (al *Alog) newEntry(l Level, msg string) Entry {
+	return Entry{Time: al.now(), Level: l, Message: msg, Prefix: al.Prefix(), Name: al.name, Fields: al.fields}
+}
+
+// enqueue numbers e and hands it to the Start loop, unless it's filtered out, see WithFilter, or over WithRateLimit's
+// limit.
+func (al *Alog) enqueue(e Entry) error {
+	if len(al.filters) > 0 && al.filteredOut(e) {
+		return nil
+	}
+	if al.limiter != nil && !al.limit(e.Level) {
+		return ErrRateLimited
+	}
+	e.Seq = al.nextSeq()
+	al.persist(&e)
+	err := al.queue(e)
+	if err == nil {
+		al.markHighWater()
+	} else {
+		al.consumed(e.walEnd)
+	}
+	return err
+}
+
+// queue hands e to the Start loop. If Stop has been called the entry is handled according to the logger's
+// LatePolicy instead.
+func (al *Alog) queue(e Entry) error {
+	if atomic.LoadInt32(&al.state) >= stateStopping {
+		return al.late(e)
+	}
+	if al.prioritized(&e) {
+		return al.queuePriority(e)
+	}
+	if al.ring != nil {
+		return al.pushRing(e)
+	}
+	switch al.overflow {
+	case DropNewest:
+		select {
+		case al.entryCh <- e:
+			return nil
+		default:
+			al.overflowed()
+			return ErrDropped
+		}
+	case DropOldest:
+		al.evictFor(e)
+		return nil
+	case Spill:
+		return al.spillOrQueue(e)
+	}
+	_, shutdownCompleteCh := al.runChannels()
+	select {
+	case al.entryCh <- e:
+		return nil
+	case <-shutdownCompleteCh:
+		return al.late(e)
+	}
+}
+
+// logf formats the message and queues it like log. Nothing is formatted if l isn't enabled or the message is
+// sampled out.
+func (al *Alog) logf(l Level, format string, args ...any) error {
+	if !al.enabled(l) || al.sampler.sampleOut(l) {
+		return nil
+	}
+	return al.logEntry(l, sprintf(format, args...), nil)
+}
+
+// sprintf formats like fmt.Sprintf, except that %w verbs are rendered the way fmt.Errorf renders them instead of
+// as formatting errors. Going through fmt.Errorf also lets vet check calls to the f methods, including %w.
+func sprintf(format string, args ...any) string {
+	return fmt.Errorf(format, args...).Error()
+}
+
+// Trace queues msg to be written at LevelTrace.
+func (al *Alog) Trace(msg string) error {
+	return al.log(LevelTrace, msg, nil)
+}
+
+// TraceKV queues msg to be written at LevelTrace with the alternating keys and values in kv attached to it.
+func (al *Alog) TraceKV(msg string, kv ...any) error {
+	return al.log(LevelTrace, msg, kv)
+}
+
+// Tracef formats the message like fmt.Sprintf and queues it to be written at LevelTrace.
+func (al *Alog) Tracef(format string, args ...any) error {
+	return al.logf(LevelTrace, format, args...)
+}
+
+// Debug queues msg to be written at LevelDebug.
+func (al *Alog) Debug(msg string) error {
+	return al.log(LevelDebug, msg, nil)
+}
+
+// DebugKV queues msg to be written at LevelDebug with the alternating keys and values in kv attached to it.
+func (al *Alog) DebugKV(msg string, kv ...any) error {
+	return al.log(LevelDebug, msg, kv)
+}
+
+// Debugf formats the message like fmt.Sprintf and queues it to be written at LevelDebug.
+func (al *Alog) Debugf(format string, args ...any) error {
+	return al.logf(LevelDebug, format, args...)
+}
+
+// Info queues msg to be written at LevelInfo.
+func (al *Alog) Info(msg string) error {
+	return al.log(LevelInfo, msg, nil)
+}
+
+// InfoKV queues msg to be written at LevelInfo with the alternating keys and values in kv attached to it.
+func (al *Alog) InfoKV(msg string, kv ...any) error {
+	return al.log(LevelInfo, msg, kv)
+}
+
+// Infof formats the message like fmt.Sprintf and queues it to be written at LevelInfo.
+func (al *Alog) Infof(format string, args ...any) error {
+	return al.logf(LevelInfo, format, args...)
+}
+
+// Warn queues msg to be written at LevelWarn.
+func (al *Alog) Warn(msg string) error {
+	return al.log(LevelWarn, msg, nil)
+}
+
+// WarnKV queues msg to be written at LevelWarn with the alternating keys and values in kv attached to it.
+func (al *Alog) WarnKV(msg string, kv ...any) error {
+	return al.log(LevelWarn, msg, kv)
+}
+
+// Warnf formats the message like fmt.Sprintf and queues it to be written at LevelWarn.
+func (al *Alog) Warnf(format string, args ...any) error {
+	return al.logf(LevelWarn, format, args...)
+}
+
+// Error queues msg to be written at LevelError.
+func (al *Alog) Error(msg string) error {
+	return al.log(LevelError, msg, nil)
+}
+
+// ErrorKV queues msg to be written at LevelError with the alternating keys and values in kv attached to it.
+func (al *Alog) ErrorKV(msg string, kv ...any) error {
+	return al.log(LevelError, msg, kv)
+}
+
+// Errorf formats the message like fmt.Sprintf and queues it to be written at LevelError.
+func (al *Alog) Errorf(format string, args ...any) error {
+	return al.logf(LevelError, format, args...)
+}
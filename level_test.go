@@ -0,0 +1,210 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingWriter struct {
+	b     *bytes.Buffer
+	calls int
+}
+
+func (cw *countingWriter) Write(data []byte) (int, error) {
+	cw.calls++
+	return cw.b.Write(data)
+}
+
+func TestLevelString(t *testing.T) {
+	tests := map[Level]string{
+		LevelDebug:     "DEBUG",
+		LevelInfo:      "INFO",
+		LevelWarn:      "WARN",
+		LevelError:     "ERROR",
+		LevelInfo + 2:  "INFO+2",
+		LevelTrace:     "TRACE",
+		LevelDebug - 1: "TRACE+3",
+		LevelTrace - 1: "TRACE-1",
+	}
+	for l, want := range tests {
+		if got := l.String(); got != want {
+			t.Errorf("Level(%d).String() = %q, want %q", int(l), got, want)
+		}
+	}
+}
+
+func TestMinimumLevelFiltering(t *testing.T) {
+	cw := &countingWriter{b: bytes.NewBuffer([]byte{})}
+	alog := New(cw, WithLevel(LevelWarn), WithClock(newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local))))
+	go alog.Start()
+	alog.Debug("debug message")
+	alog.Info("info message")
+	alog.Warn("warn message")
+	alog.Error("error message")
+	alog.MessageChannel() <- "channel message"
+	if _, err := alog.Write("sync message"); err != nil {
+		t.Fatal(err)
+	}
+	alog.Stop()
+
+	written := cw.b.String()
+	for _, dropped := range []string{"debug", "info", "channel", "sync"} {
+		if strings.Contains(written, dropped+" message") {
+			t.Errorf("%s message written by a logger set to LevelWarn", dropped)
+		}
+	}
+	if cw.calls != 2 {
+		t.Errorf("Expected exactly 2 writes to the destination, got %d", cw.calls)
+	}
+	if !strings.Contains(written, "[2024-05-01 12:00:00] [WARN] - warn message\n") {
+		t.Errorf("Warn message not written with its level, got %q", written)
+	}
+	if !strings.Contains(written, "[ERROR] - error message\n") {
+		t.Errorf("Error message not written with its level, got %q", written)
+	}
+}
+
+func TestDefaultLevelIsInfo(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	alog.Debug("debug message")
+	alog.Info("info message")
+	alog.Stop()
+	if strings.Contains(b.String(), "debug message") {
+		t.Error("Debug message written with the default level")
+	}
+	if !strings.Contains(b.String(), "[INFO] - info message") {
+		t.Error("Info message not written with the default level")
+	}
+}
+
+func TestLevelMethodAfterStopDoesNotBlock(t *testing.T) {
+	alog := New(bytes.NewBuffer([]byte{}))
+	go alog.Start()
+	alog.Stop()
+	if err := alog.Error("late"); err != ErrLoggerStopped {
+		t.Errorf("Expected ErrLoggerStopped, got %v", err)
+	}
+	if alog.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped message, got %d", alog.Dropped())
+	}
+}
+
+func TestSetLevelWhileRunning(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	alog.Debug("first debug")
+	if alog.Level() != LevelInfo {
+		t.Errorf("Expected Level() to report LevelInfo, got %v", alog.Level())
+	}
+	alog.SetLevel(LevelDebug)
+	if alog.Level() != LevelDebug {
+		t.Errorf("Expected Level() to report LevelDebug after SetLevel, got %v", alog.Level())
+	}
+	alog.Debug("second debug")
+	alog.Stop()
+	if strings.Contains(b.String(), "first debug") {
+		t.Error("Debug message written before SetLevel(LevelDebug)")
+	}
+	if !strings.Contains(b.String(), "second debug") {
+		t.Error("Debug message not written after SetLevel(LevelDebug)")
+	}
+}
+
+func TestSetLevelConcurrently(t *testing.T) {
+	alog := New(bytes.NewBuffer([]byte{}), WithBufferSize(10))
+	go alog.Start()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				alog.Debug("message")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				alog.SetLevel(Level(j%2) * LevelDebug)
+			}
+		}()
+	}
+	wg.Wait()
+	alog.Stop()
+}
+
+type formatCounter struct {
+	n *int32
+}
+
+func (fc formatCounter) String() string {
+	atomic.AddInt32(fc.n, 1)
+	return "formatted"
+}
+
+func TestFormatMethods(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithTimestampFormat(""), WithLevel(LevelDebug), WithLatePolicy(LateWriteSync))
+	go alog.Start()
+	alog.Debugf("debug %d", 1)
+	alog.Infof("info %s", "two")
+	mismatched := "warn %v %v"
+	alog.Warnf(mismatched, 3)
+	alog.Errorf("failed: %w", errors.New("boom"))
+	alog.Errorf("wrapped: %w and %w", errors.New("a"), errors.New("b"))
+	alog.Stop()
+	if _, err := alog.Writef("write %q", "five"); err != nil {
+		t.Fatal(err)
+	}
+	want := "[DEBUG] - debug 1\n" +
+		"[INFO] - info two\n" +
+		"[WARN] - warn 3 %!v(MISSING)\n" +
+		"[ERROR] - failed: boom\n" +
+		"[ERROR] - wrapped: a and b\n" +
+		"write \"five\"\n"
+	if b.String() != want {
+		t.Errorf("Got %q, want %q", b.String(), want)
+	}
+}
+
+func TestFormatMethodsSkipFormattingWhenFiltered(t *testing.T) {
+	alog := New(bytes.NewBuffer([]byte{}), WithLevel(LevelError))
+	go alog.Start()
+	n := new(int32)
+	alog.Infof("%v", formatCounter{n})
+	alog.Writef("%v", formatCounter{n})
+	alog.Errorf("%v", formatCounter{n})
+	alog.Stop()
+	if got := atomic.LoadInt32(n); got != 1 {
+		t.Errorf("Expected only the enabled message to be formatted, formatted %d", got)
+	}
+}
+
+func TestFormatMethodsConcurrently(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(10))
+	go alog.Start()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				alog.Infof("worker %d message %d", i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	alog.Stop()
+	if lines := strings.Count(b.String(), "\n"); lines != 1000 {
+		t.Errorf("Expected 1000 lines, got %d", lines)
+	}
+}
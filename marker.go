@@ -0,0 +1,71 @@
+package alog
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithBanner makes Start write a message at LevelInfo, before any other, with fn's result as its text, such as the
+// application's name and version, its PID and when it started. fn is called as the message is written, every time
+// the logger is started, or by Stop if the logger is stopped without having been started, and nothing is written if
+// it returns "". Like WithCloseMarker's message, the banner goes through the formatter but isn't filtered or sampled.
+func WithBanner(fn func() string) Option {
+	return func(al *Alog) {
+		al.banner = fn
+	}
+}
+
+// WithCloseMarker makes Stop write a message at LevelInfo after every other, with fn's result as its text, so a
+// file that doesn't end with it was cut short by a crash. With a nil fn the text is "log closed cleanly at " and the
+// time in RFC 3339 format. fn is called as the message is written, and nothing is written if it returns "".
+func WithCloseMarker(fn func() string) Option {
+	return func(al *Alog) {
+		if fn == nil {
+			fn = func() string {
+				return "log closed cleanly at " + al.now().Format(time.RFC3339)
+			}
+		}
+		al.closeMarker = fn
+	}
+}
+
+// WithRotationMarker makes a FileWriter write fn's result, followed by a newline if it doesn't end with one, at the
+// top of every new file it starts after the first, whether it was rotated by size or time or reopened, before the
+// messages being written when it was started. fn is called as the file is started; the marker is written as it is,
+// not formatted, and doesn't count towards WithMaxFileSize. An error writing it is returned by the next Write.
+func WithRotationMarker(fn func() string) FileOption {
+	return func(fw *FileWriter) {
+		fw.marker = fn
+	}
+}
+
+// writeMarker writes the message fn returns, for WithBanner and WithCloseMarker, if fn is set.
+func (al *Alog) writeMarker(fn func() string, wg *sync.WaitGroup) {
+	if fn == nil {
+		return
+	}
+	if msg := fn(); msg != "" {
+		al.dispatch(al.newEntry(LevelInfo, msg), wg)
+	}
+}
+
+// writeRotationMarker writes WithRotationMarker's marker at the top of a file that's just been started. The caller
+// holds m.
+func (fw *FileWriter) writeRotationMarker() {
+	fw.header = 0
+	if fw.marker == nil || !fw.started || fw.size > 0 {
+		fw.started = true
+		return
+	}
+	marker := fw.marker()
+	if !strings.HasSuffix(marker, "\n") {
+		marker += "\n"
+	}
+	n, err := fw.f.WriteString(marker)
+	fw.size += int64(n)
+	fw.header = int64(n)
+	if err != nil {
+		fw.addBackgroundErr(err)
+	}
+}
@@ -0,0 +1,83 @@
+package alog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	rotations := 0
+	fw := NewFileWriter(path, WithMaxFileSize(100), WithRotationMarker(func() string {
+		rotations++
+		return "--- continued, part " + strconv.Itoa(rotations+1)
+	}))
+	fw.now = newTickingClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)).Now
+	alog := New(fw, WithTimestampFormat(""), WithBufferSize(10), WithBanner(func() string {
+		return "app 1.2.0 pid " + strconv.Itoa(os.Getpid())
+	}), WithCloseMarker(nil))
+	alog.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Now
+	go alog.Start()
+	for i := 0; i < 8; i++ {
+		alog.Info("message " + strconv.Itoa(i))
+	}
+	alog.Stop()
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	names = append(names, path)
+	if len(names) != 3 {
+		t.Fatalf("Expected the log to be rotated twice, got %q", names)
+	}
+	var files []string
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, string(data))
+	}
+	if want := "[INFO] - app 1.2.0 pid " + strconv.Itoa(os.Getpid()) + "\n"; !strings.HasPrefix(files[0], want) {
+		t.Errorf("Expected the first file to start with the banner, got %q", files[0])
+	}
+	for i, data := range files[1:] {
+		if want := "--- continued, part " + strconv.Itoa(i+2) + "\n"; !strings.HasPrefix(data, want) {
+			t.Errorf("Expected file %d to start with %q, got %q", i+2, want, data)
+		}
+	}
+	if want := "[INFO] - log closed cleanly at 2024-05-01T12:00:00Z\n"; !strings.HasSuffix(files[2], want) {
+		t.Errorf("Expected the last file to end with the close marker, got %q", files[2])
+	}
+	all := strings.Join(files, "")
+	for i := 0; i < 8; i++ {
+		if !strings.Contains(all, "[INFO] - message "+strconv.Itoa(i)+"\n") {
+			t.Errorf("Message %d is missing from %q", i, all)
+		}
+	}
+}
+
+func TestRotationMarkerNotOnExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("earlier\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fw := NewFileWriter(path, WithRotationMarker(func() string { return "marker" }))
+	fw.Write([]byte("first\n"))
+	fw.Close()
+	fw.Write([]byte("second\n")) // reopens the same file
+	fw.Close()
+	if data, _ := os.ReadFile(path); string(data) != "earlier\nfirst\nsecond\n" {
+		t.Errorf("Expected no markers in a file that was already started, got %q", data)
+	}
+}
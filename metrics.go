@@ -0,0 +1,90 @@
+package alog
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metric is one of the logger's counters as exposed to monitoring systems, see Alog.Metrics.
+type Metric struct {
+	Name    string // such as "messages_written_total"
+	Help    string
+	Counter bool // whether the value only ever goes up, otherwise it's a gauge
+	Value   float64
+}
+
+// Metrics returns the logger's Stats as metrics named the Prometheus way. They're what PrometheusHandler serves,
+// and also what the prometheus.Collector of the alogprom module collects, for programs that register their metrics
+// with the Prometheus client library, which alog itself doesn't depend on.
+func (al *Alog) Metrics() []Metric {
+	s := al.Stats()
+	return []Metric{
+		{"messages_written_total", "Messages written to the destination.", true, float64(s.MessagesWritten)},
+		{"bytes_written_total", "Bytes written to the destination.", true, float64(s.BytesWritten)},
+		{"messages_dropped_total", "Messages that were logged but not written on purpose.", true,
+			float64(s.MessagesDropped)},
+		{"write_errors_total", "Messages that failed to write.", true, float64(s.WriteErrors)},
+		{"fallback_writes_total", "Messages written to the fallback writer instead.", true, float64(s.Fallbacks)},
+		{"queue_depth", "Messages waiting to be written.", false, float64(s.QueueDepth)},
+	}
+}
+
+// PublishExpvar publishes the logger's Metrics with expvar, as a map under name, so they're served by the
+// /debug/vars handler. Every logger needs a name of its own: PublishExpvar returns an error, and publishes nothing,
+// if name is already in use, where expvar.Publish would panic.
+func (al *Alog) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("alog: expvar %q is already published", name)
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		vars := make(map[string]float64)
+		for _, m := range al.Metrics() {
+			vars[m.Name] = m.Value
+		}
+		return vars
+	}))
+	return nil
+}
+
+// PrometheusHandler returns a handler that serves the Metrics of loggers in the Prometheus text format, with their
+// names prefixed with prefix, and labelled with the logger's name in loggers: with the prefix "alog_" the queue
+// depth of the logger named "audit" is served as alog_queue_depth{logger="audit"} 0.
+func PrometheusHandler(prefix string, loggers map[string]*Alog) http.Handler {
+	names := make([]string, 0, len(loggers))
+	for name := range loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics := make([][]Metric, len(names))
+		for i, name := range names {
+			metrics[i] = loggers[name].Metrics()
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		// Every logger has the same metrics, in the same order, and each one's samples go together under its
+		// HELP and TYPE lines.
+		for j := 0; len(metrics) > 0 && j < len(metrics[0]); j++ {
+			m := metrics[0][j]
+			kind := "gauge"
+			if m.Counter {
+				kind = "counter"
+			}
+			name := prefix + m.Name
+			bw.WriteString("# HELP " + name + " " + m.Help + "\n# TYPE " + name + " " + kind + "\n")
+			for i, logger := range names {
+				bw.WriteString(name + `{logger="` + labelEscaper.Replace(logger) + `"} ` +
+					strconv.FormatFloat(metrics[i][j].Value, 'g', -1, 64) + "\n")
+			}
+		}
+		_ = bw.Flush() // the client went away
+	})
+}
+
+// labelEscaper escapes Prometheus label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
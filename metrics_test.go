@@ -0,0 +1,78 @@
+package alog
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	alog := New(&alternatingWriter{}, WithBufferSize(10), WithErrorBuffer(10))
+	name := "alog_test_expvar_" + strconv.FormatInt(time.Now().UnixNano(), 10) // expvar names last as long as the process
+	if err := alog.PublishExpvar(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := New(nil).PublishExpvar(name); err == nil {
+		t.Error("Expected an error publishing a second logger under the same name")
+	}
+	go alog.Start()
+	for i := 0; i < 4; i++ {
+		alog.Info("m")
+	}
+	alog.Stop()
+	alog.Info("late")
+
+	rec := httptest.NewRecorder()
+	expvar.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil {
+		t.Fatal(err)
+	}
+	var vars map[string]float64
+	if err := json.Unmarshal(all[name], &vars); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"messages_written_total": 2, "messages_dropped_total": 1, "write_errors_total": 2,
+		"queue_depth": 0}
+	for name, v := range want {
+		if got, ok := vars[name]; !ok || got != v {
+			t.Errorf("Expected %s to be %v, got %v", name, v, vars)
+		}
+	}
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	audit, app := New(&lockedBuffer{}), New(&lockedBuffer{})
+	audit.Write("one")
+	app.Write("two")
+	app.Write("three")
+
+	rec := httptest.NewRecorder()
+	PrometheusHandler("alog_", map[string]*Alog{"audit": audit, `a"pp`: app}).ServeHTTP(rec,
+		httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE alog_messages_written_total counter\n" +
+			"alog_messages_written_total{logger=\"a\\\"pp\"} 2\n" +
+			"alog_messages_written_total{logger=\"audit\"} 1\n",
+		"# TYPE alog_queue_depth gauge\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected the metrics to contain %q, got\n%s", want, body)
+		}
+	}
+	if n := strings.Count(body, "# HELP "); n != 6 {
+		t.Errorf("Expected 6 metrics, got %d", n)
+	}
+
+	rec = httptest.NewRecorder()
+	PrometheusHandler("myapp_log_", map[string]*Alog{"audit": audit}).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "\nmyapp_log_messages_written_total{logger=\"audit\"} 1\n") ||
+		strings.Contains(body, "alog_") {
+		t.Errorf("Expected the metrics to be prefixed with myapp_log_, got\n%s", body)
+	}
+}
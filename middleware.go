@@ -0,0 +1,90 @@
+package alog
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// HandlerFunc handles an entry on its way to the destinations. The one at the end of a middleware chain formats e
+// and writes it, returning the write's error, which is sent on the error channel.
+type HandlerFunc func(e *Entry) error
+
+// Middleware wraps the handler that comes after it, see Alog.Use. It can change the entry before passing it on,
+// drop it by returning without calling next, or call next more than once. A middleware that passes on something
+// other than e must pass on a copy of it, made with *e, rather than a new Entry. Fields shouldn't be changed in
+// place, since the slice may be shared with other messages; assign a new slice to Fields instead. Middlewares must
+// not retain e.
+//
+// The filtering, redaction and sampling done by WithFilter, WithRedactor and WithSampling are also available as
+// middlewares, see Filter, Redact and Sample, so they can be ordered explicitly, such as redacting before sampling.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// middlewares is the chain set up with Use.
+type middlewares struct {
+	m       sync.Mutex
+	added   []Middleware
+	handler atomic.Value // HandlerFunc, the composed chain
+}
+
+// errNotPassedOn is returned by the end of the middleware chain for an entry that isn't a copy of the one passed
+// to the chain.
+var errNotPassedOn = errors.New("alog: middleware passed on an entry that isn't a copy of the one it was given")
+
+// Use adds middlewares around the step that formats and writes each message. They run in the order they're added,
+// the first one outermost, after the message has been stamped with its time and static fields and the hooks added
+// with WithBeforeWrite have kept it. Messages a middleware drops aren't counted as written.
+//
+// Like the hooks, middlewares are called on the goroutine that writes the message, so they must be fast. Use applies
+// to every logger that shares al's destination, and it's safe to call while the logger is running; messages that
+// are already being written aren't affected.
+func (al *Alog) Use(mw ...Middleware) {
+	al.middlewares.m.Lock()
+	defer al.middlewares.m.Unlock()
+	al.middlewares.added = append(al.middlewares.added, mw...)
+	h := HandlerFunc(al.writeHandled)
+	for i := len(al.middlewares.added) - 1; i >= 0; i-- {
+		h = al.middlewares.added[i](h)
+	}
+	al.middlewares.handler.Store(h)
+}
+
+// handler returns the middleware chain, or nil if Use wasn't called.
+func (al *Alog) handler() HandlerFunc {
+	h, _ := al.middlewares.handler.Load().(HandlerFunc)
+	return h
+}
+
+// handle passes the entry held by fb through the middleware chain h and reports how many times it reached the end
+// of the chain, see writeHandled, and the error the chain returned. fb.n is the number of bytes written to dest.
+func (al *Alog) handle(h HandlerFunc, fb *formatBuffer) (int, error) {
+	handled := fb.handled
+	fb.e.fb = fb
+	err := h(&fb.e)
+	return fb.handled - handled, err
+}
+
+// writeHandled is the end of the middleware chain. It writes e like writePrepared, or adds it to the batch that's
+// being formatted, see writeBatch.
+func (al *Alog) writeHandled(e *Entry) error {
+	fb := e.fb
+	if fb == nil {
+		return errNotPassedOn
+	}
+	if e != &fb.e {
+		fb.e = *e
+	}
+	fb.handled++
+	if fb.batch {
+		al.appendFormatted(fb)
+		if len(al.sinks) > 0 {
+			return al.writeSinks(&fb.e)
+		}
+		return nil
+	}
+	fb.buf = fb.buf[:0] // in case the entry was passed on more than once
+	fb.spans = fb.spans[:0]
+	var err error
+	fb.n, err = al.writePrepared(fb)
+	return err
+}
@@ -0,0 +1,188 @@
+package alog
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// elapsed is an example middleware that adds the time since start to every message.
+func elapsed(start time.Time) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(e *Entry) error {
+			e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], Field{Key: "elapsed", Value: e.Time.Sub(start)})
+			return next(e)
+		}
+	}
+}
+
+// tag returns a middleware that appends name to the message and records that it was called in calls.
+func tag(name string, calls *[]string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(e *Entry) error {
+			*calls = append(*calls, name)
+			e.Message += " " + name
+			return next(e)
+		}
+	}
+}
+
+func TestMiddlewareElapsed(t *testing.T) {
+	lb := &lockedBuffer{}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alog := New(lb)
+	alog.now = newFakeClock(start.Add(1500 * time.Millisecond)).Now
+	alog.Use(elapsed(start))
+	go alog.Start()
+	alog.InfoKV("ready", "port", 8080)
+	alog.Stop()
+
+	if got := writtenMessages(lb); len(got) != 1 || got[0] != "ready port=8080 elapsed=1.5s" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	lb := &lockedBuffer{}
+	var calls []string
+	alog := New(lb)
+	alog.Use(tag("a", &calls), tag("b", &calls))
+	alog.Use(tag("c", &calls))
+	go alog.Start()
+	alog.Info("m")
+	alog.Stop()
+
+	if got := writtenMessages(lb); len(got) != 1 || got[0] != "m a b c" {
+		t.Errorf("Got %q", got)
+	}
+	if got := strings.Join(calls, ","); got != "a,b,c" {
+		t.Errorf("Middlewares called as %q", got)
+	}
+}
+
+func TestMiddlewareBuiltinOrder(t *testing.T) {
+	noEmail := func(e *Entry) bool { return !RedactEmail.MatchString(e.Message) }
+	tests := []struct {
+		name string
+		mw   []Middleware
+		want string
+	}{
+		{"redact first", []Middleware{Redact(RedactEmail, "[EMAIL]"), Filter(noEmail)}, "mail [EMAIL],plain"},
+		{"filter first", []Middleware{Filter(noEmail), Redact(RedactEmail, "[EMAIL]")}, "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := &lockedBuffer{}
+			alog := New(lb)
+			alog.Use(tt.mw...)
+			go alog.Start()
+			alog.Info("mail jo@example.com")
+			alog.Info("plain")
+			alog.Stop()
+			if got := strings.Join(writtenMessages(lb), ","); got != tt.want {
+				t.Errorf("Got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareSample(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithBufferSize(10))
+	alog.Use(Sample(2, LevelError))
+	go alog.Start()
+	for i := 0; i < 4; i++ {
+		alog.Infof("info %d", i)
+	}
+	alog.Error("error")
+	alog.Error("error")
+	alog.Stop()
+
+	if got := strings.Join(writtenMessages(lb), ","); got != "info 0,info 2,error,error" {
+		t.Errorf("Got %q", got)
+	}
+	if n := atomic.LoadUint64(&alog.written); n != 4 {
+		t.Errorf("Expected 4 written messages, got %d", n)
+	}
+}
+
+func TestMiddlewareDropAndPassOnTwice(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb)
+	alog.Use(func(next HandlerFunc) HandlerFunc {
+		return func(e *Entry) error {
+			switch e.Message {
+			case "drop":
+				return nil
+			case "twice":
+				if err := next(e); err != nil {
+					return err
+				}
+				copied := *e
+				copied.Message = "again"
+				return next(&copied)
+			}
+			return next(e)
+		}
+	})
+	if n, err := alog.Write("drop"); n != 0 || err != nil {
+		t.Errorf("Got %d, %v for a dropped message", n, err)
+	}
+	if n, err := alog.Write("twice"); n == 0 || err != nil {
+		t.Errorf("Got %d, %v", n, err)
+	}
+	if got := strings.Join(writtenMessages(lb), ","); got != "twice,again" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	alog := New(&lockedBuffer{}, WithErrorBuffer(10))
+	alog.Use(func(next HandlerFunc) HandlerFunc {
+		return func(e *Entry) error {
+			if e.Message == "new" {
+				return next(&Entry{Message: e.Message})
+			}
+			return errors.New("rejected")
+		}
+	})
+	go alog.Start()
+	alog.Info("new")
+	alog.Info("old")
+	alog.Stop()
+
+	if err := <-alog.ErrorChannel(); err != errNotPassedOn {
+		t.Errorf("Got %v, want %v", err, errNotPassedOn)
+	}
+	if err := <-alog.ErrorChannel(); err == nil || err.Error() != "rejected" {
+		t.Errorf("Got %v", err)
+	}
+}
+
+func TestMiddlewareBatching(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	cc := &callCounter{w: gw}
+	alog := New(cc, WithBufferSize(100), WithBatching(50, 0))
+	alog.Use(Filter(func(e *Entry) bool { return e.Message != "m2" }), func(next HandlerFunc) HandlerFunc {
+		return func(e *Entry) error {
+			e.Message = strings.ToUpper(e.Message)
+			return next(e)
+		}
+	})
+	go alog.Start()
+	queueBehind(t, alog, 3)
+	close(gw.open)
+	alog.Stop()
+
+	if got := strings.Join(writtenMessages(gw.b), ","); got != "M0,M1,M3" {
+		t.Errorf("Got %q", got)
+	}
+	if calls := atomic.LoadInt64(&cc.calls); calls != 2 {
+		t.Errorf("Expected m0 and a batch, got %d writes", calls)
+	}
+	if n := atomic.LoadUint64(&alog.written); n != 3 {
+		t.Errorf("Expected 3 written messages, got %d", n)
+	}
+}
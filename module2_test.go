@@ -81,12 +81,13 @@ func (ew errorWriter) Write(data []byte) (int, error) {
 func TestWriteSendsErrorsToErrorChannelModule2(t *testing.T) {
 	alog := New(&errorWriter{bytes.NewBuffer([]byte{})})
 	alog.errorCh = make(chan error, 1)
+	alog.ErrorChannel() // errors are only sent on the channel once it's been asked for
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 	alog.write("test", wg)
 	go func() {
 		if (<-alog.errorCh).Error() != "error" {
-			t.Fatal("Did not receive destination writer's error on errorCh")
+			t.Error("Did not receive destination writer's error on errorCh")
 		}
 	}()
 	time.Sleep(100 * time.Millisecond)
@@ -112,29 +113,16 @@ func TestStartHandlesMessagesModule2(t *testing.T) {
 	alog.msgCh = make(chan string, 2)
 	go alog.Start()
 	alog.msgCh <- "test message"
+	alog.msgCh <- "second message"
 	time.Sleep(100 * time.Millisecond)
 	written := b.Bytes()
 	if !regexp.MustCompile(messageTimestampPattern + "test message\n$").Match(written) {
-		t.Error("Message not written to logger's destination")
-	}
-	shouldRelock := false
-	if alog.m != nil {
-		mutexState := reflect.ValueOf(*alog.m).FieldByName("state").Int()
-		if mutexState != 0 {
-			alog.m.Unlock()
-			shouldRelock = true
-		}
-	}
-	alog.msgCh <- "second message"
-	time.Sleep(100 * time.Millisecond)
-	if alog.m != nil {
-		if shouldRelock {
-			alog.m.Lock()
-		}
+		t.Error("Message not written to logger's destination, or second message written before the first completed")
 	}
+	alog.Stop()
 	written = b.Bytes()
-	if !regexp.MustCompile(messageTimestampPattern + "test message\n" + messageTimestampPattern + "second message\n").Match(written) {
-		t.Error("write method not called as a goroutine")
+	if !regexp.MustCompile(messageTimestampPattern + "test message\nwrite complete" + messageTimestampPattern + "second message\nwrite complete$").Match(written) {
+		t.Error("Messages not written sequentially in the order they were received")
 	}
 }
 
@@ -209,7 +197,7 @@ func TestWriteSendsErrorsAsynchronouslyModule2(t *testing.T) {
 	}
 	errorReceived := false
 	go func() {
-		<-alog.errorCh
+		<-alog.ErrorChannel()
 		errorReceived = true
 	}()
 	time.Sleep(100 * time.Millisecond)
@@ -3,6 +3,7 @@ package alog
 import (
 	"bytes"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -27,12 +28,9 @@ func TestShutdownMethodModule3(t *testing.T) {
 	alog.shutdown()
 	time.Sleep(100 * time.Millisecond)
 	select {
-	case _, ok := <-alog.msgCh:
-		if ok {
-			t.Error("msgCh not closed by shutdown() method")
-		}
-	default:
-		t.Error("msgCh not closed by shutdown() method")
+	case alog.msgCh <- "late":
+	case <-time.After(100 * time.Millisecond):
+		t.Error("msgCh not drained after shutdown() method")
 	}
 	select {
 	case <-alog.shutdownCompleteCh:
@@ -53,12 +51,7 @@ func TestStartMethodCallsShutdownModule3(t *testing.T) {
 	alog.shutdownCh <- struct{}{}
 	time.Sleep(100 * time.Millisecond)
 
-	select {
-	case _, ok := <-alog.msgCh:
-		if ok {
-			t.Error("Passing message to shutdownCh doesn't call shutdown()")
-		}
-	default:
+	if atomic.LoadInt32(&alog.state) != stateStopped {
 		t.Error("Passing message to shutdownCh doesn't call shutdown()")
 	}
 	select {
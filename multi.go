@@ -0,0 +1,68 @@
+package alog
+
+import (
+	"errors"
+	"io"
+)
+
+// multiWriter writes to every destination set up with WithAdditionalWriter. Unlike io.MultiWriter it carries on
+// after a destination fails, so the others still get every message.
+type multiWriter []io.Writer
+
+func (mw multiWriter) Write(data []byte) (int, error) {
+	var errs []error
+	for i, w := range mw {
+		// An open circuit isn't an error once the message has reached the other destinations, see CircuitStats.
+		if _, err := w.Write(data); err != nil && err != ErrCircuitOpen {
+			errs = append(errs, &DestinationError{Dest: i, Writer: destWriter(w), Err: err})
+		}
+	}
+	return len(data), joinErrors(errs)
+}
+
+// Flush flushes the destinations that buffer writes, see flusher.
+func (mw multiWriter) Flush() error {
+	var errs []error
+	for i, w := range mw {
+		if f, ok := w.(flusher); ok {
+			if err := f.Flush(); err != nil {
+				errs = append(errs, &DestinationError{Dest: i, Writer: destWriter(w), Err: err})
+			}
+		}
+	}
+	return joinErrors(errs)
+}
+
+// destWriter returns the writer w wraps if it's the writer passed to New, wrapped by the logger.
+func destWriter(w io.Writer) io.Writer {
+	if fw, ok := w.(*fallbackWriter); ok {
+		w = fw.primary
+	}
+	if bw, ok := w.(*breakerWriter); ok {
+		w = bw.w
+	}
+	if rw, ok := w.(*retryWriter); ok {
+		w = rw.w
+	}
+	if o, ok := w.(*output); ok {
+		w = o.writer()
+	}
+	return w
+}
+
+// joinErrors returns nil, the only error or all of them joined, so a single failing destination is reported as its
+// own *DestinationError. Nil errors are left out.
+func joinErrors(errs []error) error {
+	var last error
+	n := 0
+	for _, err := range errs {
+		if err != nil {
+			last = err
+			n++
+		}
+	}
+	if n <= 1 {
+		return last
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+package alog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAdditionalWriter(t *testing.T) {
+	fw := &flakyWriter{fail: 1, b: &lockedBuffer{}}
+	lb := &lockedBuffer{}
+	alog := New(fw, WithAdditionalWriter(lb), WithBufferSize(10))
+	go alog.Start()
+	alog.Info("one")
+	alog.Info("two")
+	alog.Stop()
+
+	if got := writtenMessages(lb); strings.Join(got, ",") != "one,two" {
+		t.Errorf("Expected the working destination to get every line, got %q", got)
+	}
+	for i := 0; i < 2; i++ {
+		var de *DestinationError
+		if err := <-alog.ErrorChannel(); !errors.As(err, &de) || de.Dest != 0 || de.Writer != fw {
+			t.Errorf("Expected an error from destination 0, got %v", err)
+		} else if err.Error() != "alog: destination 0: disk full" {
+			t.Errorf("Got %q", err)
+		}
+	}
+}
+
+func TestAdditionalWriterWrite(t *testing.T) {
+	b1, b2 := bytes.NewBuffer([]byte{}), bytes.NewBuffer([]byte{})
+	fw := &flakyWriter{fail: 1, b: &lockedBuffer{}}
+	alog := New(b1, WithAdditionalWriter(fw), WithAdditionalWriter(b2))
+	_, err := alog.Write("sync")
+	var de *DestinationError
+	if !errors.As(err, &de) || de.Dest != 1 {
+		t.Errorf("Expected Write to return destination 1's error, got %v", err)
+	}
+	if !strings.HasSuffix(b1.String(), "- sync\n") || b2.String() != b1.String() {
+		t.Errorf("Expected both buffers to get the message, got %q and %q", b1, b2)
+	}
+}
+
+func TestAdditionalWriterFlush(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	bw := bufio.NewWriter(b)
+	alog := New(bytes.NewBuffer([]byte{}), WithAdditionalWriter(bw))
+	go alog.Start()
+	defer alog.Stop()
+	alog.Info("flushed")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "- flushed\n") {
+		t.Errorf("Flush didn't flush the additional destination, got %q", b.String())
+	}
+}
@@ -0,0 +1,91 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNamedLoggers(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithTimestampFormat(""))
+	http := alog.Named("http")
+	client := http.Named("client")
+	if got := http.Named("").name; got != "http" {
+		t.Errorf("Named with an empty name should keep the parent's name, got %q", got)
+	}
+	go alog.Start()
+	http.Info("one")
+	client.Info("two")
+	alog.Info("three")
+	alog.Stop()
+	want := "[INFO] [http] - one\n[INFO] [http.client] - two\n[INFO] - three\n"
+	if b.String() != want {
+		t.Errorf("Got %q, want %q", b.String(), want)
+	}
+}
+
+func TestNamedLoggersInterleaved(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(50))
+	go alog.Start()
+	wg := &sync.WaitGroup{}
+	for _, name := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func(child *Alog) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				child.Info(fmt.Sprint(i))
+			}
+		}(alog.Named(name))
+	}
+	wg.Wait()
+	alog.Stop()
+
+	linePattern := regexp.MustCompile(`\[INFO] \[(\w)] - (\d+)$`)
+	next := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		match := linePattern.FindStringSubmatch(line)
+		if match == nil {
+			t.Fatalf("Couldn't parse %q", line)
+		}
+		name, n := match[1], 0
+		fmt.Sscan(match[2], &n)
+		if n != next[name] {
+			t.Fatalf("Logger %s wrote %d, expected %d", name, n, next[name])
+		}
+		next[name]++
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if next[name] != 200 {
+			t.Errorf("Expected 200 messages from %s, got %d", name, next[name])
+		}
+	}
+}
+
+func TestNamedAfterStop(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	alog.Stop()
+	child := alog.Named("late")
+	child.Info("dropped")
+	child.Stop()
+	if b.Len() != 0 {
+		t.Errorf("Logger named after Stop wrote %q", b.String())
+	}
+}
+
+func TestNamedInStructuredFormats(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat()).Named("http").Named("client")
+	if _, err := alog.Write("msg"); err != nil {
+		t.Fatal(err)
+	}
+	if record := decodeJSONLines(t, b.String())[0]; record["logger"] != "http.client" {
+		t.Errorf("Name not rendered as logger in JSON: %v", record)
+	}
+}
@@ -0,0 +1,213 @@
+package alog
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NetWriter sends messages to a log collector over a network connection, which it makes on the first write and
+// makes again whenever it's lost. While it's disconnected messages are kept, up to a limit set with
+// WithNetBuffer, and sent as soon as the connection is back; messages beyond the limit are dropped and counted. A
+// message the connection was lost in the middle of is kept from where it was cut off, so it isn't sent twice.
+// Reconnecting happens in the background, waiting twice as long after every failed attempt.
+//
+// Connection failures, and being connected again, are reported as a *ConnectionError by the next Write, which makes
+// them reach the logger's error channel. Write returns the full length of data with such an error, since data has
+// been sent or kept. NetWriter is safe for concurrent use.
+type NetWriter struct {
+	m            sync.Mutex
+	network      string
+	addr         string
+	conn         net.Conn
+	pending      [][]byte // messages kept while disconnected
+	maxPending   int
+	unreported   uint64 // messages dropped since the last ConnectionError
+	dropped      uint64 // atomic
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	backoff      time.Duration // before the next attempt
+	writeTimeout time.Duration
+	retry        *time.Timer // set while waiting to reconnect
+	errs         []error     // for the next Write to return
+
+	dial func(network, addr string) (net.Conn, error)
+}
+
+// ConnectionError reports a change in a NetWriter's connection.
+type ConnectionError struct {
+	Addr      string
+	Connected bool   // whether this reports the connection coming back, rather than failing
+	Dropped   uint64 // messages that were dropped while disconnected, if Connected
+	Err       error  // why the connection failed, if not Connected
+}
+
+func (e *ConnectionError) Error() string {
+	if !e.Connected {
+		return fmt.Sprintf("alog: connection to %s failed: %v", e.Addr, e.Err)
+	}
+	if e.Dropped > 0 {
+		return fmt.Sprintf("alog: connected to %s again, %d messages dropped", e.Addr, e.Dropped)
+	}
+	return fmt.Sprintf("alog: connected to %s again", e.Addr)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// NetOption configures a NetWriter.
+type NetOption func(*NetWriter)
+
+// WithNetBuffer sets how many messages a NetWriter keeps while it's disconnected. The default is 1000.
+func WithNetBuffer(n int) NetOption {
+	return func(nw *NetWriter) {
+		nw.maxPending = n
+	}
+}
+
+// WithNetBackoff sets how long a NetWriter waits before its first attempt to reconnect, doubling that after every
+// failed attempt up to max. The defaults are 100ms and 30s.
+func WithNetBackoff(min, max time.Duration) NetOption {
+	return func(nw *NetWriter) {
+		nw.minBackoff = min
+		nw.maxBackoff = max
+	}
+}
+
+// WithNetWriteTimeout sets how long a write may take before the connection is given up on. The default is 10s.
+func WithNetWriteTimeout(d time.Duration) NetOption {
+	return func(nw *NetWriter) {
+		nw.writeTimeout = d
+	}
+}
+
+// NewNetWriter returns a NetWriter for addr on network, as understood by net.Dial, which can be passed to New.
+// Call Close once the logger has been stopped.
+func NewNetWriter(network, addr string, opts ...NetOption) *NetWriter {
+	nw := &NetWriter{
+		network:      network,
+		addr:         addr,
+		maxPending:   1000,
+		minBackoff:   100 * time.Millisecond,
+		maxBackoff:   30 * time.Second,
+		writeTimeout: 10 * time.Second,
+		dial:         net.Dial,
+	}
+	for _, opt := range opts {
+		opt(nw)
+	}
+	nw.backoff = nw.minBackoff
+	return nw
+}
+
+// Write implements io.Writer.
+func (nw *NetWriter) Write(data []byte) (int, error) {
+	nw.m.Lock()
+	defer nw.m.Unlock()
+	if nw.conn == nil && nw.retry == nil {
+		nw.connect()
+	}
+	if nw.conn == nil {
+		nw.keep(data)
+	} else if n, err := nw.send(data); err != nil && n < len(data) {
+		nw.keep(data[n:])
+	}
+	errs := nw.errs
+	nw.errs = nil
+	return len(data), joinErrors(errs)
+}
+
+// Dropped returns the number of messages that were dropped because too many were kept while disconnected, or
+// because they were still kept when Close was called.
+func (nw *NetWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&nw.dropped)
+}
+
+// Close closes the connection and stops reconnecting. Messages that are still kept are dropped and counted by
+// Dropped. A later Write connects again.
+func (nw *NetWriter) Close() error {
+	nw.m.Lock()
+	defer nw.m.Unlock()
+	if nw.retry != nil {
+		nw.retry.Stop()
+		nw.retry = nil
+	}
+	atomic.AddUint64(&nw.dropped, uint64(len(nw.pending)))
+	nw.pending = nil
+	if nw.conn == nil {
+		return nil
+	}
+	err := nw.conn.Close()
+	nw.conn = nil
+	return err
+}
+
+// connect dials addr and sends the kept messages, or schedules another attempt. The caller holds m.
+func (nw *NetWriter) connect() {
+	conn, err := nw.dial(nw.network, nw.addr)
+	if err != nil {
+		nw.disconnected(err)
+		return
+	}
+	nw.conn = conn
+	nw.backoff = nw.minBackoff
+	if len(nw.pending) > 0 || nw.unreported > 0 {
+		nw.errs = append(nw.errs, &ConnectionError{Addr: nw.addr, Connected: true, Dropped: nw.unreported})
+		nw.unreported = 0
+	}
+	for len(nw.pending) > 0 {
+		if n, err := nw.send(nw.pending[0]); err != nil {
+			nw.pending[0] = nw.pending[0][n:]
+			return
+		}
+		nw.pending[0] = nil
+		nw.pending = nw.pending[1:]
+	}
+}
+
+// send writes data to the connection, dropping the connection if that fails, and returns how much of data was
+// written. The caller holds m.
+func (nw *NetWriter) send(data []byte) (int, error) {
+	if nw.writeTimeout > 0 {
+		nw.conn.SetWriteDeadline(time.Now().Add(nw.writeTimeout))
+	}
+	n, err := nw.conn.Write(data)
+	if err != nil {
+		nw.conn.Close()
+		nw.conn = nil
+		nw.disconnected(err)
+	}
+	return n, err
+}
+
+// disconnected reports err and schedules an attempt to reconnect. The caller holds m.
+func (nw *NetWriter) disconnected(err error) {
+	nw.errs = append(nw.errs, &ConnectionError{Addr: nw.addr, Err: err})
+	var retry *time.Timer
+	retry = time.AfterFunc(nw.backoff, func() {
+		nw.m.Lock()
+		defer nw.m.Unlock()
+		if nw.retry != retry {
+			return // stopped by Close
+		}
+		nw.retry = nil
+		nw.connect()
+	})
+	nw.retry = retry
+	if nw.backoff *= 2; nw.backoff > nw.maxBackoff {
+		nw.backoff = nw.maxBackoff
+	}
+}
+
+// keep holds on to a copy of data until the connection is back. The caller holds m.
+func (nw *NetWriter) keep(data []byte) {
+	if len(nw.pending) >= nw.maxPending {
+		nw.unreported++
+		atomic.AddUint64(&nw.dropped, 1)
+		return
+	}
+	nw.pending = append(nw.pending, append([]byte(nil), data...))
+}
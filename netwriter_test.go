@@ -0,0 +1,231 @@
+package alog
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector is a fake log collector that records the lines it receives. It can be stopped and started again on
+// the same address.
+type collector struct {
+	t     *testing.T
+	addr  string
+	l     net.Listener
+	m     sync.Mutex
+	conns []net.Conn
+	lines []string
+}
+
+func startCollector(t *testing.T, addr string) *collector {
+	t.Helper()
+	c := &collector{t: t, addr: addr}
+	c.start()
+	return c
+}
+
+func (c *collector) start() {
+	l, err := net.Listen("tcp", c.addr)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.l, c.addr = l, l.Addr().String()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			c.m.Lock()
+			c.conns = append(c.conns, conn)
+			c.m.Unlock()
+			go func() {
+				s := bufio.NewScanner(conn)
+				for s.Scan() {
+					c.m.Lock()
+					c.lines = append(c.lines, s.Text())
+					c.m.Unlock()
+				}
+			}()
+		}
+	}()
+}
+
+func (c *collector) stop() {
+	c.l.Close()
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+	c.conns = nil
+}
+
+// waitFor waits until the collector has received line.
+func (c *collector) waitFor(line string) {
+	c.t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		c.m.Lock()
+		for _, l := range c.lines {
+			if l == line {
+				c.m.Unlock()
+				return
+			}
+		}
+		c.m.Unlock()
+	}
+	c.t.Fatalf("%q never arrived", line)
+}
+
+func TestNetWriterReconnects(t *testing.T) {
+	c := startCollector(t, "127.0.0.1:0")
+	defer c.stop()
+	nw := NewNetWriter("tcp", c.addr, WithNetBackoff(5*time.Millisecond, 20*time.Millisecond))
+	defer nw.Close()
+	if _, err := nw.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	c.waitFor("before")
+
+	c.stop()
+	// Writes to a connection the other end has closed can succeed until the close is noticed.
+	var ce *ConnectionError
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if _, err := nw.Write([]byte("probe\n")); errors.As(err, &ce) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("The lost connection wasn't noticed")
+		}
+	}
+	if ce.Connected {
+		t.Fatalf("Expected a connection failure, got %v", ce)
+	}
+	for _, line := range []string{"during 1\n", "during 2\n"} {
+		nw.Write([]byte(line))
+	}
+
+	c.start()
+	c.waitFor("during 1")
+	c.waitFor("during 2")
+	_, err := nw.Write([]byte("after\n"))
+	if !errors.As(err, &ce) || !ce.Connected {
+		t.Errorf("Expected the connection to be reported back, got %v", err)
+	}
+	c.waitFor("after")
+}
+
+func TestNetWriterDropsBeyondBuffer(t *testing.T) {
+	nw := NewNetWriter("tcp", "127.0.0.1:1", WithNetBuffer(2), WithNetBackoff(time.Hour, time.Hour))
+	nw.dial = func(string, string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	defer nw.Close()
+	var ce *ConnectionError
+	if _, err := nw.Write([]byte("m0\n")); !errors.As(err, &ce) || ce.Error() != "alog: connection to 127.0.0.1:1 failed: connection refused" {
+		t.Errorf("Expected the dial error, got %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := nw.Write([]byte("m\n")); err != nil {
+			t.Errorf("Expected one error per attempt to connect, got %v", err)
+		}
+	}
+	if nw.Dropped() != 3 {
+		t.Errorf("Expected 3 messages dropped, got %d", nw.Dropped())
+	}
+
+	server, client := net.Pipe()
+	defer server.Close()
+	received := make(chan string)
+	go func() {
+		s := bufio.NewScanner(server)
+		var lines []string
+		for s.Scan() {
+			if lines = append(lines, s.Text()); len(lines) == 3 {
+				break
+			}
+		}
+		received <- strings.Join(lines, ",")
+	}()
+	nw.m.Lock()
+	nw.dial = func(string, string) (net.Conn, error) {
+		return client, nil
+	}
+	nw.retry.Stop()
+	nw.retry = nil
+	nw.m.Unlock()
+	if _, err := nw.Write([]byte("new\n")); err == nil || err.Error() != "alog: connected to 127.0.0.1:1 again, 3 messages dropped" {
+		t.Errorf("Got %v", err)
+	}
+	if got := <-received; got != "m0,m,new" {
+		t.Errorf("Got %q, want the kept messages before the new one", got)
+	}
+}
+
+// cutConn is a connection that takes the first cut bytes written to it and then fails, or takes everything if cut
+// is less than 0.
+type cutConn struct {
+	net.Conn
+	b   *lockedBuffer
+	cut int
+}
+
+func (cc *cutConn) Write(data []byte) (int, error) {
+	if cc.cut < 0 {
+		return cc.b.Write(data)
+	}
+	n, _ := cc.b.Write(data[:min(cc.cut, len(data))])
+	cc.cut -= n
+	return n, errors.New("connection reset")
+}
+
+func (*cutConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func (*cutConn) Close() error {
+	return nil
+}
+
+func TestNetWriterResumesCutMessage(t *testing.T) {
+	b := &lockedBuffer{}
+	nw := NewNetWriter("tcp", "127.0.0.1:1", WithNetBackoff(time.Hour, time.Hour))
+	nw.dial = func(string, string) (net.Conn, error) {
+		return &cutConn{b: b, cut: 3}, nil
+	}
+	if _, err := nw.Write([]byte("hello\n")); err == nil {
+		t.Fatal("Expected the connection failure")
+	}
+	nw.m.Lock()
+	nw.dial = func(string, string) (net.Conn, error) {
+		return &cutConn{b: b, cut: -1}, nil
+	}
+	nw.retry.Stop()
+	nw.retry = nil
+	nw.m.Unlock()
+	nw.Write([]byte("next\n"))
+	if got := b.String(); got != "hello\nnext\n" {
+		t.Errorf("Got %q, want the rest of the cut message and then the next one", got)
+	}
+	nw.Close()
+}
+
+func TestNetWriterCloseCountsKept(t *testing.T) {
+	nw := NewNetWriter("tcp", "127.0.0.1:1", WithNetBackoff(time.Hour, time.Hour))
+	nw.dial = func(string, string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	for i := 0; i < 3; i++ {
+		nw.Write([]byte("m\n"))
+	}
+	if err := nw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if nw.Dropped() != 3 {
+		t.Errorf("Expected the 3 kept messages to be counted as dropped, got %d", nw.Dropped())
+	}
+}
@@ -0,0 +1,315 @@
+package alog
+
+import (
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// Option configures an Alog. Options are passed to New and applied in order before the logger's channels are
+// created.
+type Option func(*Alog)
+
+// WithBufferSize sets the capacity of the channel returned by MessageChannel. With a buffer of n, up to n messages
+// can be sent without blocking while the logger is busy writing. The default is an unbuffered channel.
+func WithBufferSize(n int) Option {
+	return func(al *Alog) {
+		al.bufferSize = n
+	}
+}
+
+// WithTimestampFormat sets the layout, as understood by time.Time.Format, that is used for the timestamp at the
+// start of every message. The default layout is "2006-01-02 15:04:05" and an empty layout omits the timestamp, for
+// messages that already carry their own. See WithPrecision for fractional seconds. It only affects the default
+// TextFormatter.
+func WithTimestampFormat(layout string) Option {
+	return func(al *Alog) {
+		al.timestampFormat = layout
+	}
+}
+
+// WithMultiLine sets how messages that span several lines are written, see MultiLinePolicy. It only affects the
+// default TextFormatter.
+func WithMultiLine(p MultiLinePolicy) Option {
+	return func(al *Alog) {
+		al.multiLine = p
+	}
+}
+
+// WithLineEnding sets what's written at the end of every message instead of "\n", such as "\r\n" for tools that
+// expect Windows line endings. Whatever a message ends with is replaced: the formatters drop the "\n", "\r\n" or
+// "\r" at the end of the message itself, and the newline they write after it becomes ending. The newlines within a
+// message and its stack trace are written as the MultiLinePolicy says. It applies to every formatter whose lines end
+// with "\n".
+func WithLineEnding(ending string) Option {
+	return func(al *Alog) {
+		al.lineEnding = ending
+		if ending == "\n" {
+			al.lineEnding = ""
+		}
+	}
+}
+
+// WithPrefix tags every message with prefix. TextFormatter renders it between the level and the message, e.g.
+// "[2024-01-02 10:00:00] [ingest] - message", and the structured formats render it as a "component" key. Loggers
+// derived with WithFields inherit the prefix.
+func WithPrefix(prefix string) Option {
+	return func(al *Alog) {
+		al.prefix.Store(prefix)
+	}
+}
+
+// WithLocation converts timestamps to loc before they're formatted, by every formatter, so a layout with a zone,
+// such as "MST" or "-07:00", renders loc's. By default timestamps use the local time zone, time.Local, or the zone
+// of the Time of an entry sent on EntryChannel.
+func WithLocation(loc *time.Location) Option {
+	return func(al *Alog) {
+		al.location = loc
+	}
+}
+
+// WithUTC converts timestamps to UTC before they're formatted, like WithLocation(time.UTC).
+func WithUTC() Option {
+	return WithLocation(time.UTC)
+}
+
+// WithErrorBuffer sets the capacity of the channel returned by ErrorChannel. The default is 16. With an unbuffered
+// channel errors are only delivered to a goroutine that's already waiting to receive them.
+func WithErrorBuffer(n int) Option {
+	return func(al *Alog) {
+		al.errorBufferSize = n
+	}
+}
+
+// WithHostInfo attaches "host" and "pid" fields, identifying the machine and process, to every message, including
+// messages sent on MessageChannel. Both are looked up once, when the logger is created. If the host name can't be
+// determined it's reported as "unknown".
+func WithHostInfo() Option {
+	return func(al *Alog) {
+		host, err := os.Hostname()
+		if err != nil || host == "" {
+			host = "unknown"
+		}
+		al.staticFields = mergeFields(al.staticFields, []Field{{"host", host}, {"pid", os.Getpid()}})
+	}
+}
+
+// WithStaticFields attaches fields to every message, including messages sent on MessageChannel, for values that
+// don't change for the life of the process such as a service name or version. Static fields are rendered in key
+// order after the message's own fields, and a key that's passed again, or that's also set by WithHostInfo, is only
+// rendered once, with the last value.
+func WithStaticFields(fields map[string]string) Option {
+	return func(al *Alog) {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		add := make([]Field, 0, len(keys))
+		for _, k := range keys {
+			add = append(add, Field{k, fields[k]})
+		}
+		al.staticFields = mergeFields(al.staticFields, add)
+	}
+}
+
+// WithSequence stamps every message with a sequence number, starting at 1, that's rendered as "seq". Numbers are
+// handed out when a message is logged, after level filtering, so a gap in the output shows that messages were lost,
+// for example because they were logged after Stop. Messages sent on MessageChannel are numbered when the Start loop
+// receives them. Sequence returns the last number handed out.
+func WithSequence() Option {
+	return func(al *Alog) {
+		al.sequence = true
+	}
+}
+
+// LatePolicy decides what happens to messages that are logged after Stop has been called.
+type LatePolicy int
+
+const (
+	// LateDrop drops late messages and counts them, see Alog.Dropped. Write and the level methods return
+	// ErrLoggerStopped.
+	LateDrop LatePolicy = iota
+	// LateWriteSync writes late messages on the goroutine that logged them, as a best effort, and returns any write
+	// error to the caller.
+	LateWriteSync
+)
+
+// WithLatePolicy sets what happens to messages that are logged after Stop. The default is LateDrop.
+func WithLatePolicy(p LatePolicy) Option {
+	return func(al *Alog) {
+		al.latePolicy = p
+	}
+}
+
+// WithWorkers makes the Start loop hand messages to n goroutines that format and write them concurrently. It's only
+// useful for destinations that are safe for concurrent use and slow enough for parallel writes to pay off, and
+// messages are no longer written in the order they were logged; Flush and Stop still wait for all of them. The
+// default, and any n below 2, is a single writer that keeps messages in order.
+func WithWorkers(n int) Option {
+	return func(al *Alog) {
+		al.workers = n
+	}
+}
+
+// WithAdditionalWriter makes the logger write every message to ws as well as to the writer passed to New. Each
+// destination gets the formatted message in turn, and one that fails doesn't stop the others from getting it: its
+// error is sent on the error channel as a *DestinationError that says which destination it was, and Write returns
+// it. Flush flushes every destination that has a Flush method. The option can be used more than once.
+func WithAdditionalWriter(ws ...io.Writer) Option {
+	return func(al *Alog) {
+		al.extraDests = append(al.extraDests, ws...)
+	}
+}
+
+// WithLevelWriter makes the logger also write the messages at minLevel and above to w, for example to copy
+// warnings and errors to os.Stderr while everything goes to a file. It can be used more than once, and each message
+// goes to every destination whose level it meets, in the order the messages were written. Errors from w are sent on
+// the error channel as a *DestinationError; level writers are numbered after the destinations from
+// WithAdditionalWriter, in the order they were added.
+func WithLevelWriter(minLevel Level, w io.Writer) Option {
+	return func(al *Alog) {
+		al.levelWriters = append(al.levelWriters, levelWriter{min: minLevel, w: w})
+	}
+}
+
+// WithBatching makes the Start loop write the messages that are already queued when it picks one up together, with
+// a single call to the destination's Write, which saves a system call per message for files and sockets. A batch
+// holds at most maxMessages messages and stops growing once it reaches maxBytes; a maxBytes of 0 or less uses 16KB.
+// Messages are still written in the order they were logged, and a shallow queue just means smaller batches, down to
+// one message per Write. If writing a batch fails, a single *BatchError is sent on the error channel and none of the
+// batch's messages are counted as written. Batching is not used together with WithWorkers, and a maxMessages below 2
+// turns it off, which is the default.
+func WithBatching(maxMessages, maxBytes int) Option {
+	return func(al *Alog) {
+		al.batchMessages = maxMessages
+		al.batchBytes = maxBytes
+		if maxBytes <= 0 {
+			al.batchBytes = defaultBatchBytes
+		}
+	}
+}
+
+// WithFallbackWriter makes the logger write messages to w when writing them to the writer passed to New fails, so
+// they aren't lost when a disk fills up or a pipe breaks. The error is still sent on the error channel, as a
+// *FallbackError that tells whether w took the messages, but the messages w took are counted as Fallbacks in Stats
+// rather than as WriteErrors. Each write tries the writer passed to New first, unless WithFallbackRetry is used. The
+// fallback isn't used if the writer passed to New is an EntryWriter.
+func WithFallbackWriter(w io.Writer) Option {
+	return func(al *Alog) {
+		al.fallbackDest = w
+	}
+}
+
+// WithFallbackRetry makes a logger with a fallback writer, see WithFallbackWriter, write straight to the fallback for
+// interval after the writer passed to New fails, and try the writer passed to New again after that. The logger goes
+// back to it once a write succeeds. Only the failed tries are sent on the error channel, not every message written
+// to the fallback in between, unless the fallback fails as well.
+func WithFallbackRetry(interval time.Duration) Option {
+	return func(al *Alog) {
+		al.fallbackRetry = interval
+	}
+}
+
+// WithBuffering puts a buffer of size bytes in front of the destination, so messages reach it in larger writes,
+// and flushes it every flushInterval, which bounds how long a message can sit in the buffer. The buffer is also
+// flushed by Flush and Stop, and when it fills up; a size of 0 or less uses bufio's default of 4KB. An interval of 0
+// or less flushes only in those cases. Errors from flushing on the interval or on Stop are sent on the error channel.
+// Write goes through the same buffer, taking turns with the Start loop, and after Stop late writes flush it straight
+// away.
+func WithBuffering(size int, flushInterval time.Duration) Option {
+	return func(al *Alog) {
+		al.buffered = true
+		al.writeBufferSize = size
+		al.flushInterval = flushInterval
+	}
+}
+
+// WithRingBuffer replaces the channel between the level methods and the Start loop with a lock-free ring of at least
+// capacity messages, rounded up to a power of two, for producers that log millions of messages a second. The level
+// methods, AsWriter, StdLogger and the slog handler push to the ring, and the Start loop empties it whenever it has
+// been woken up for a push, so a busy loop keeps writing without waiting on a channel. When the ring is full the
+// OverflowPolicy applies; with Block the caller spins, yielding to other goroutines, until there's room. The
+// capacity given to WithOverflowPolicy is not used. MessageChannel keeps working and is read by the loop as before.
+func WithRingBuffer(capacity int) Option {
+	return func(al *Alog) {
+		al.ringSize = capacity
+	}
+}
+
+// WithOverflowPolicy sets the capacity of the queue between the level methods and the Start loop and what happens
+// to a message that's logged while the queue is full. It applies to the level methods, AsWriter, StdLogger and the
+// slog handler; sends on MessageChannel always block, and its capacity is still set with WithBufferSize. DropOldest
+// needs room for at least one message, so a smaller capacity is raised to 1. Spill is configured with WithSpill.
+func WithOverflowPolicy(p OverflowPolicy, capacity int) Option {
+	return func(al *Alog) {
+		al.overflow = p
+		al.queueSize = nonNegative(capacity)
+	}
+}
+
+// WithDropReport makes the logger write a warning with the number of messages it dropped because the queue was
+// full, once the queue has been emptied again.
+func WithDropReport() Option {
+	return func(al *Alog) {
+		al.dropReport = true
+	}
+}
+
+// WithLevel sets the minimum level of messages that are written. Messages below it are discarded before they're
+// formatted or queued. The default is LevelInfo. The level can be changed later with SetLevel.
+func WithLevel(l Level) Option {
+	return func(al *Alog) {
+		al.level = int32(l)
+	}
+}
+
+// WithCaller annotates every message with the file and line it was logged from, e.g.
+// "[2024-01-02 10:00:00] server.go:42 - message". Only Write, Writef and the level methods can report a caller;
+// messages sent on MessageChannel or written through AsWriter and StdLogger don't have one. skip is the number of
+// additional stack frames to skip, so a function that wraps the logger can pass 1 to report its own caller instead
+// of itself.
+func WithCaller(skip int) Option {
+	return func(al *Alog) {
+		al.reportCaller = true
+		al.callerSkip = skip
+	}
+}
+
+// WithStacktrace attaches the stack trace of the logging goroutine to every message at minLevel or above. The stack
+// is captured when the message is logged, so it shows the code that logged it rather than the Start loop.
+// TextFormatter writes it below the message, indented by a tab, and the structured formats render it as a "stack"
+// key. Like WithCaller, it only applies to Write, Writef and the level methods.
+func WithStacktrace(minLevel Level) Option {
+	return func(al *Alog) {
+		al.stacktrace = true
+		al.stackLevel = minLevel
+	}
+}
+
+// WithFormatter sets the Formatter used to render every message, for both the asynchronous path and Write. It
+// replaces WithTimestampFormat, WithJSONFormat and WithLogfmtFormat.
+func WithFormatter(f Formatter) Option {
+	return func(al *Alog) {
+		al.formatter = f
+	}
+}
+
+// WithJSONFormat makes the logger write one JSON object per line with "time", "level" and "msg" keys, followed by
+// any fields attached to the message. Timestamps are always formatted as RFC3339 in this mode, regardless of
+// WithTimestampFormat.
+func WithJSONFormat() Option {
+	return func(al *Alog) {
+		al.formatter = JSONFormatter{}
+	}
+}
+
+// WithLogfmtFormat makes the logger write logfmt lines with "time", "level" and "msg" keys, followed by any fields
+// attached to the message. Timestamps are always formatted as RFC3339 in this mode, regardless of
+// WithTimestampFormat.
+func WithLogfmtFormat() Option {
+	return func(al *Alog) {
+		al.formatter = LogfmtFormatter{}
+	}
+}
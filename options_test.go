@@ -0,0 +1,154 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithBufferSize(t *testing.T) {
+	alog := New(nil, WithBufferSize(5))
+	for i := 0; i < 5; i++ {
+		select {
+		case alog.MessageChannel() <- "message":
+		default:
+			t.Fatalf("Send %d blocked with a buffer size of 5", i+1)
+		}
+	}
+	select {
+	case alog.MessageChannel() <- "message":
+		t.Error("Send beyond the buffer size didn't block")
+	default:
+	}
+}
+
+func TestWithTimestampFormat(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithTimestampFormat("15:04"), WithClock(newFakeClock(time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local))))
+	if _, err := alog.Write("test"); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "[09:30] - test\n" {
+		t.Errorf("Timestamp format not applied, got %q", b.String())
+	}
+}
+
+func TestWithErrorBuffer(t *testing.T) {
+	alog := New(&errorWriter{bytes.NewBuffer([]byte{})}, WithErrorBuffer(3))
+	wg := &sync.WaitGroup{}
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		alog.write("test", wg)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := len(alog.ErrorChannel()); n != 3 {
+		t.Errorf("Expected 3 errors buffered for the first reader, got %d", n)
+	}
+}
+
+func TestNegativeBufferSizes(t *testing.T) {
+	alog := New(nil, WithBufferSize(-1), WithErrorBuffer(-1))
+	if cap(alog.msgCh) != 0 || cap(alog.errorCh) != 0 {
+		t.Error("Negative buffer sizes should produce unbuffered channels")
+	}
+}
+
+func TestTimestampLayouts(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 23, 30, 15, 123456789, time.FixedZone("UTC+2", 2*60*60))
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{nil, "[2024-05-01 23:30:15] - test\n"},
+		{[]Option{WithTimestampFormat(time.RFC3339Nano)}, "[2024-05-01T23:30:15.123456789+02:00] - test\n"},
+		{[]Option{WithTimestampFormat(time.RFC3339), WithUTC()}, "[2024-05-01T21:30:15Z] - test\n"},
+		{[]Option{WithTimestampFormat("")}, "test\n"},
+		{[]Option{WithJSONFormat(), WithUTC()}, `{"time":"2024-05-01T21:30:15Z","level":"info","msg":"test"}` + "\n"},
+	}
+	for _, tt := range tests {
+		b := bytes.NewBuffer([]byte{})
+		alog := New(b, tt.opts...)
+		alog.now = newFakeClock(ts).Now
+		if _, err := alog.Write("test"); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("Got %q, want %q", b.String(), tt.want)
+		}
+	}
+}
+
+func TestWithLocation(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		loc  *time.Location
+		want string
+	}{
+		{time.FixedZone("EST", -5*60*60), "[2024-05-01 07:00:00 EST -05:00] - test\n"},
+		{time.FixedZone("JST", 9*60*60), "[2024-05-01 21:00:00 JST +09:00] - test\n"},
+		{time.UTC, "[2024-05-01 12:00:00 UTC +00:00] - test\n"},
+	}
+	for _, tt := range tests {
+		b := bytes.NewBuffer([]byte{})
+		alog := New(b, WithTimestampFormat("2006-01-02 15:04:05 MST -07:00"), WithLocation(tt.loc))
+		alog.now = newFakeClock(ts).Now
+		if _, err := alog.Write("test"); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tt.want {
+			t.Errorf("Got %q, want %q", b.String(), tt.want)
+		}
+	}
+}
+
+func TestEmptyLayoutAsync(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithTimestampFormat(""), WithUTC())
+	go alog.Start()
+	alog.MessageChannel() <- "2024-01-01 my own timestamp"
+	alog.Warn("leveled")
+	alog.Stop()
+	if b.String() != "2024-01-01 my own timestamp\n[WARN] - leveled\n" {
+		t.Errorf("Timestamp not omitted, got %q", b.String())
+	}
+}
+
+func TestUnreadErrorsDoNotLeakGoroutines(t *testing.T) {
+	alog := New(&errorWriter{bytes.NewBuffer([]byte{})}, WithBufferSize(100))
+	go alog.Start()
+	alog.Info("warm up")
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100000; i++ {
+		alog.Info("test")
+	}
+	alog.Stop()
+	if after := runtime.NumGoroutine(); after > before+1 {
+		t.Errorf("Expected no more than %d goroutines after 100000 failed writes, got %d", before+1, after)
+	}
+	if n := alog.ErrorCount(); n != 100001 {
+		t.Errorf("Expected 100001 errors to be counted, got %d", n)
+	}
+	if n := len(alog.RecentErrors()); n != defaultErrorBufferSize {
+		t.Errorf("Expected the last %d errors to be kept, got %d", defaultErrorBufferSize, n)
+	}
+	if n := alog.SuppressedErrors(); n != 0 {
+		t.Errorf("Expected no errors to be suppressed before the channel was asked for, got %d", n)
+	}
+	if n := len(alog.ErrorChannel()); n != defaultErrorBufferSize {
+		t.Errorf("Expected %d buffered errors, got %d", defaultErrorBufferSize, n)
+	}
+}
+
+func TestErrorChannelKeepsNewestErrors(t *testing.T) {
+	alog := New(nil, WithErrorBuffer(2))
+	for i := 0; i < 5; i++ {
+		alog.reportError(errors.New(strconv.Itoa(i)))
+	}
+	if a, b := (<-alog.ErrorChannel()).Error(), (<-alog.ErrorChannel()).Error(); a != "3" || b != "4" {
+		t.Errorf("Expected errors 3 and 4, got %s and %s", a, b)
+	}
+}
@@ -0,0 +1,90 @@
+package alog
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// output holds the writer passed to New, or to SetOutput, underneath the wrapping dest gets for the other
+// destinations. Writes hold a read lock, so SetOutput can tell when the old writer is no longer in use.
+type output struct {
+	m  sync.RWMutex
+	w  io.Writer   // as it was given
+	ew EntryWriter // what entries are handed to if the logger was created with an EntryWriter
+}
+
+func (o *output) Write(data []byte) (int, error) {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	return o.w.Write(data)
+}
+
+func (o *output) WriteEntry(e *Entry) error {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	return o.ew.WriteEntry(e)
+}
+
+// Flush flushes the writer if it buffers writes, see flusher.
+func (o *output) Flush() error {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	if f, ok := o.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (o *output) waitBackground(timeout time.Duration) error {
+	o.m.RLock()
+	w := o.w
+	o.m.RUnlock()
+	if b, ok := w.(backgrounder); ok {
+		return b.waitBackground(timeout)
+	}
+	return nil
+}
+
+// writer returns the current writer.
+func (o *output) writer() io.Writer {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	return o.w
+}
+
+// SetOutput replaces the writer passed to New with w, or os.Stdout if w is nil, while the logger is running, and
+// returns the writer it replaced so it can be closed. Each message is written whole to one or the other: the ones
+// being written when SetOutput is called, and the ones buffered by WithBuffering, go to the old writer before
+// SetOutput returns, and later ones go to w. The other destinations set up with options stay as they are.
+//
+// If the logger was created with an EntryWriter, w is handed entries if it's one too, and otherwise gets the lines
+// formatted by the logger's formatter.
+func (al *Alog) SetOutput(w io.Writer) io.Writer {
+	if w == nil {
+		w = os.Stdout
+	}
+	al.m.Lock()
+	var err error
+	if al.buffer != nil {
+		err = al.buffer.Flush()
+	}
+	o := al.output
+	o.m.Lock()
+	old := o.w
+	o.w = w
+	if al.entryDest != nil {
+		ew, ok := w.(EntryWriter)
+		if !ok {
+			ew = &writerSink{w: w, f: al.formatter}
+		}
+		o.ew = ew
+	}
+	o.m.Unlock()
+	al.m.Unlock()
+	if err != nil {
+		al.reportError(err)
+	}
+	return old
+}
@@ -0,0 +1,113 @@
+package alog
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSetOutput(t *testing.T) {
+	b1, b2 := &lockedBuffer{}, &lockedBuffer{}
+	alog := New(b1, WithBufferSize(100))
+	go alog.Start()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				msg := strconv.Itoa(g*250+i) + strings.Repeat("x", 100)
+				if i%2 == 0 {
+					alog.Info(msg)
+				} else {
+					alog.Write(msg)
+				}
+				if g == 0 && i == 125 {
+					if old := alog.SetOutput(b2); old != b1 {
+						t.Errorf("Expected SetOutput to return the old writer, got %v", old)
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	alog.Stop()
+
+	seen := map[string]int{}
+	for _, b := range []*lockedBuffer{b1, b2} {
+		msgs := writtenMessages(b)
+		if len(msgs) == 0 {
+			t.Error("Expected both writers to get messages")
+		}
+		for _, msg := range msgs {
+			seen[msg]++
+		}
+		if n := strings.Count(b.String(), "\n"); n != len(msgs) {
+			t.Errorf("Got %d lines but %d messages", n, len(msgs))
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		if msg := strconv.Itoa(i) + strings.Repeat("x", 100); seen[msg] != 1 {
+			t.Errorf("Message %d was written %d times", i, seen[msg])
+		}
+	}
+}
+
+func TestSetOutputFlushesBuffer(t *testing.T) {
+	b1, b2 := bytes.NewBuffer([]byte{}), bytes.NewBuffer([]byte{})
+	alog := New(b1, WithBuffering(4096, 0))
+	go alog.Start()
+	alog.Write("old")
+	alog.SetOutput(b2)
+	alog.Write("new")
+	alog.Flush(context.Background())
+	alog.Stop()
+	if !strings.HasSuffix(b1.String(), "- old\n") || strings.Contains(b1.String(), "new") {
+		t.Errorf("Got %q in the old writer", b1)
+	}
+	if !strings.HasSuffix(b2.String(), "- new\n") || strings.Contains(b2.String(), "old") {
+		t.Errorf("Got %q in the new writer", b2)
+	}
+}
+
+func TestSetOutputNil(t *testing.T) {
+	alog := New(bytes.NewBuffer([]byte{}))
+	alog.SetOutput(nil)
+	if old := alog.SetOutput(bytes.NewBuffer([]byte{})); old != os.Stdout {
+		t.Errorf("Expected nil to mean os.Stdout, got %v", old)
+	}
+}
+
+// recordingEntryWriter is an EntryWriter that can be passed to New.
+type recordingEntryWriter struct {
+	recordingSink
+}
+
+func (er *recordingEntryWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func TestSetOutputEntryWriter(t *testing.T) {
+	rs := &recordingEntryWriter{}
+	alog := New(rs)
+	b := bytes.NewBuffer([]byte{})
+	if old := alog.SetOutput(b); old != rs {
+		t.Errorf("Expected SetOutput to return the EntryWriter, got %v", old)
+	}
+	if _, err := alog.Write("formatted"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "- formatted\n") {
+		t.Errorf("Expected a formatted line, got %q", b)
+	}
+	rs2 := &recordingEntryWriter{}
+	alog.SetOutput(rs2)
+	alog.Write("entry")
+	if rs.sequence() != "" || rs2.sequence() != "write:entry" {
+		t.Errorf("Got calls %q and %q", rs.sequence(), rs2.sequence())
+	}
+}
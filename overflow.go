@@ -0,0 +1,69 @@
+package alog
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what happens to a message that's logged while the queue to the Start loop is full.
+type OverflowPolicy int
+
+const (
+	// Block makes the caller wait until there's room in the queue. It's the default.
+	Block OverflowPolicy = iota
+	// DropNewest drops the message that's being logged. The level methods return ErrDropped.
+	DropNewest
+	// DropOldest makes room by dropping the oldest message in the queue.
+	DropOldest
+	// Spill appends the message to a file, see WithSpill, which the Start loop writes out once it has caught up.
+	Spill
+)
+
+// entryCapacity returns the capacity of entryCh.
+func (al *Alog) entryCapacity() int {
+	if al.queueSize < 0 {
+		return nonNegative(al.bufferSize)
+	}
+	if al.overflow == DropOldest && al.queueSize < 1 {
+		return 1
+	}
+	return al.queueSize
+}
+
+// evictFor queues e, dropping the oldest queued entries until there's room for it.
+func (al *Alog) evictFor(e Entry) {
+	for {
+		select {
+		case al.entryCh <- e:
+			return
+		default:
+		}
+		select {
+		case old := <-al.entryCh:
+			if old.flushed != nil {
+				old.flushed <- ErrDropped
+			} else {
+				al.overflowed()
+				al.consumed(old.walEnd)
+			}
+		default:
+		}
+	}
+}
+
+// overflowed counts a message that was dropped because the queue was full.
+func (al *Alog) overflowed() {
+	atomic.AddUint64(&al.dropped, 1)
+	atomic.AddUint64(&al.unreported, 1)
+}
+
+// reportDrops writes a warning about the messages that were dropped because the queue was full, if WithDropReport
+// was used, there are any and the queue has been emptied.
+func (al *Alog) reportDrops(wg *sync.WaitGroup) {
+	if !al.dropReport || len(al.entryCh) > 0 || al.ringLen() > 0 || atomic.LoadUint64(&al.unreported) == 0 {
+		return
+	}
+	n := atomic.SwapUint64(&al.unreported, 0)
+	al.process(Entry{Level: LevelWarn, Message: strconv.FormatUint(n, 10) + " messages dropped"}, wg)
+}
@@ -0,0 +1,107 @@
+package alog
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gatedWriter blocks every Write until open is closed and then writes to b.
+type gatedWriter struct {
+	open chan struct{}
+	b    *lockedBuffer
+}
+
+func (gw gatedWriter) Write(data []byte) (int, error) {
+	<-gw.open
+	return gw.b.Write(data)
+}
+
+// fillQueue logs "m0", waits until the Start loop is stuck writing it and then logs m1 to m5.
+func fillQueue(t *testing.T, alog *Alog) []error {
+	t.Helper()
+	alog.Info("m0")
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var errs []error
+	for i := 1; i <= 5; i++ {
+		errs = append(errs, alog.Info("m"+strconv.Itoa(i)))
+	}
+	return errs
+}
+
+func writtenMessages(lb *lockedBuffer) []string {
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSuffix(lb.String(), "\n"), "\n") {
+		if i := strings.Index(line, "] - "); i >= 0 {
+			msgs = append(msgs, line[i+len("] - "):])
+		}
+	}
+	return msgs
+}
+
+func TestOverflowDropNewest(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(DropNewest, 3), WithDropReport())
+	go alog.Start()
+	for i, err := range fillQueue(t, alog) {
+		var want error
+		if i >= 3 {
+			want = ErrDropped
+		}
+		if err != want {
+			t.Errorf("m%d: expected %v, got %v", i+1, want, err)
+		}
+	}
+	close(gw.open)
+	alog.Stop()
+	if got, want := strings.Join(writtenMessages(gw.b), ","), "m0,m1,m2,m3,2 messages dropped"; got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+	if alog.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped messages, got %d", alog.Dropped())
+	}
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(DropOldest, 3))
+	go alog.Start()
+	for i, err := range fillQueue(t, alog) {
+		if err != nil {
+			t.Errorf("m%d: expected nil, got %v", i+1, err)
+		}
+	}
+	close(gw.open)
+	alog.Stop()
+	if got, want := strings.Join(writtenMessages(gw.b), ","), "m0,m3,m4,m5"; got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+	if alog.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped messages, got %d", alog.Dropped())
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 3))
+	go alog.Start()
+	doneCh := make(chan []error)
+	go func() {
+		doneCh <- fillQueue(t, alog)
+	}()
+	select {
+	case <-doneCh:
+		t.Fatal("Logging into a full queue didn't block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(gw.open)
+	<-doneCh
+	alog.Stop()
+	if got, want := strings.Join(writtenMessages(gw.b), ","), "m0,m1,m2,m3,m4,m5"; got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,355 @@
+package alog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	walName       = "alog.wal"     // the records
+	walPosName    = "alog.wal.pos" // how much of the records has been consumed
+	walHeaderSize = 8              // the length of a record's payload and its CRC-32, both little endian
+	walCompactAt  = 1 << 20        // bytes of consumed records the file is compacted at, once they're half of it
+)
+
+// WithPersistence makes the logger append every message logged with the level methods to a write-ahead file in
+// dir before queueing it, so messages survive a crash of the process between being logged and being written. A
+// record is consumed once its message has been written to the destination without an error, or deliberately not
+// written, such as when a BeforeWrite hook drops it or the queue is full; the file is emptied whenever every record
+// in it has been consumed. A message that failed to write isn't consumed, so the records after it can't be emptied
+// away, and the file is compacted instead, rewritten with only the records that haven't been consumed, once those
+// that have add up to 1 MB and half the file, and when the logger is stopped. The next logger with the same dir
+// writes the records that weren't consumed before anything else when it's started, failed ones included, so after a
+// crash a message is written at least once and at most twice.
+//
+// Each record is the message as Spill keeps it, see WithSpill, behind its length and a CRC-32. Records that are cut
+// short or corrupt, as the last one may be after a crash, are discarded along with everything after them, with a
+// warning logged in their place. Errors from the file go to the error channel and don't stop messages from being
+// logged. Messages sent on the channels or written with Write aren't persisted, and the file isn't synced, so
+// messages don't survive the machine losing power.
+func WithPersistence(dir string) Option {
+	return func(al *Alog) {
+		al.wal = &walFile{dir: dir, compactAt: walCompactAt, done: map[int64]bool{}}
+	}
+}
+
+// walFile holds the write-ahead file of WithPersistence. Records are identified by where they end, as an offset
+// into every record written by the logger, so an offset isn't reused when the file is emptied or compacted.
+type walFile struct {
+	dir       string
+	compactAt int64 // walCompactAt, but tests lower it
+
+	m        sync.Mutex
+	f        *os.File
+	pos      *os.File
+	last     int64          // the end of the last record written
+	size     int64          // of f
+	read     int64          // the bytes at the start of f that have been consumed
+	waste    int64          // the bytes in f of records that have been consumed, read included
+	consumed int64          // the offset up to which every record has been consumed
+	ends     []int64        // the ends of the records after consumed, in order
+	offs     []int64        // where each of those records ends in f
+	done     map[int64]bool // the ends of the records after consumed that have been consumed
+	replay   []Entry        // read back from the file when it was opened, for Start
+	corrupt  int64          // the bytes discarded from the file when it was opened
+	buf      []byte
+}
+
+// open reads back the records left in the file and rewrites it with only those, so offsets start again. The
+// caller holds m.
+func (w *walFile) open() error {
+	if err := os.MkdirAll(w.dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(w.dir, walName)
+	pos, err := os.OpenFile(filepath.Join(w.dir, walPosName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		pos.Close()
+		return err
+	}
+	var b [8]byte
+	read := int64(0)
+	if _, err := pos.ReadAt(b[:], 0); err == nil {
+		read = int64(binary.LittleEndian.Uint64(b[:]))
+	}
+	if read < 0 || read > int64(len(data)) {
+		read = 0 // the pos file is from before the file was last emptied
+	}
+	w.consumed = w.last
+	records, entries, offs := w.scan(data[read:])
+	w.pos = pos
+	if n := int64(len(records)); read > 0 || n < int64(len(data))-read {
+		w.corrupt = int64(len(data)) - read - n
+		if err := w.rewrite(records); err != nil {
+			pos.Close()
+			w.pos = nil
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		pos.Close()
+		w.pos = nil
+		return err
+	}
+	w.f = f
+	w.size, w.read, w.waste = int64(len(records)), 0, 0
+	if err := w.savePos(); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		w.ends = append(w.ends, e.walEnd)
+	}
+	w.offs = append(w.offs, offs...)
+	w.replay = entries
+	return nil
+}
+
+// scan returns the valid records at the start of data, their entries, with the ends set, and where each one ends in
+// the records.
+func (w *walFile) scan(data []byte) ([]byte, []Entry, []int64) {
+	var entries []Entry
+	var offs []int64
+	off := 0
+	for len(data)-off >= walHeaderSize {
+		n := int(binary.LittleEndian.Uint32(data[off:]))
+		sum := binary.LittleEndian.Uint32(data[off+4:])
+		end := off + walHeaderSize + n
+		if n < 0 || end > len(data) || crc32.ChecksumIEEE(data[off+walHeaderSize:end]) != sum {
+			break
+		}
+		e, err := decodeSpilled(data[off+walHeaderSize : end])
+		if err != nil {
+			break
+		}
+		w.last += int64(end - off)
+		e.walEnd = w.last
+		entries = append(entries, e)
+		offs = append(offs, int64(end))
+		off = end
+	}
+	return data[:off], entries, offs
+}
+
+// rewrite replaces the file with one holding records, none of them consumed. The pos file is reset first, so a
+// crash in between replays records rather than skipping some. The caller holds m and reopens f.
+func (w *walFile) rewrite(records []byte) error {
+	path := filepath.Join(w.dir, walName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, records, 0o644); err != nil {
+		return err
+	}
+	w.read = 0
+	if err := w.savePos(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// append writes e to the file and sets its end. The caller holds m.
+func (w *walFile) append(e *Entry) error {
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+	payload := encodeSpilled(e)
+	payload = payload[:len(payload)-1] // records aren't lines
+	w.buf = append(w.buf[:0], make([]byte, walHeaderSize)...)
+	w.buf = append(w.buf, payload...)
+	binary.LittleEndian.PutUint32(w.buf, uint32(len(payload)))
+	binary.LittleEndian.PutUint32(w.buf[4:], crc32.ChecksumIEEE(payload))
+	if _, err := w.f.Write(w.buf); err != nil {
+		_ = w.f.Truncate(w.size) // so the records after this one can still be read back
+		return err
+	}
+	w.size += int64(len(w.buf))
+	w.last += int64(len(w.buf))
+	e.walEnd = w.last
+	w.ends = append(w.ends, e.walEnd)
+	w.offs = append(w.offs, w.size)
+	return nil
+}
+
+// consume marks the records ending at ends as consumed, and saves how far the file has been consumed if that
+// changed, emptying it if it's been consumed completely and compacting it if enough of it has been otherwise.
+func (w *walFile) consume(ends ...int64) error {
+	w.m.Lock()
+	defer w.m.Unlock()
+	for _, end := range ends {
+		if end > w.consumed && !w.done[end] {
+			w.done[end] = true
+			w.waste += w.recordSize(end)
+		}
+	}
+	advanced := false
+	for len(w.ends) > 0 && w.done[w.ends[0]] {
+		delete(w.done, w.ends[0])
+		w.consumed, w.read = w.ends[0], w.offs[0]
+		w.ends, w.offs = w.ends[1:], w.offs[1:]
+		advanced = true
+	}
+	if w.f == nil {
+		return nil
+	}
+	switch {
+	case len(w.ends) == 0 && advanced:
+		if err := w.f.Truncate(0); err != nil {
+			return err
+		}
+		w.consumed = w.last
+		w.size, w.read, w.waste = 0, 0, 0
+	case w.waste > 0 && w.waste >= w.compactAt && 2*w.waste >= w.size:
+		return w.compact()
+	case !advanced:
+		return nil
+	}
+	return w.savePos()
+}
+
+// recordSize returns the size in f of the record ending at end, or 0 if it isn't in f. The caller holds m.
+func (w *walFile) recordSize(end int64) int64 {
+	i := sort.Search(len(w.ends), func(i int) bool { return w.ends[i] >= end })
+	if i == len(w.ends) || w.ends[i] != end {
+		return 0
+	}
+	if i == 0 {
+		return w.offs[0] - w.read
+	}
+	return w.offs[i] - w.offs[i-1]
+}
+
+// compact rewrites the file with only the records that haven't been consumed. The caller holds m.
+func (w *walFile) compact() error {
+	data := make([]byte, w.size)
+	if _, err := w.f.ReadAt(data, 0); err != nil {
+		return err
+	}
+	var records []byte
+	ends, offs := w.ends[:0], w.offs[:0]
+	start := w.read
+	for i, end := range w.ends {
+		off := w.offs[i]
+		if w.done[end] {
+			delete(w.done, end)
+		} else {
+			records = append(records, data[start:off]...)
+			ends, offs = append(ends, end), append(offs, int64(len(records)))
+		}
+		start = off
+	}
+	if err := w.rewrite(records); err != nil {
+		return err
+	}
+	w.ends, w.offs = ends, offs
+	w.size, w.waste = int64(len(records)), 0
+	w.f.Close()
+	f, err := os.OpenFile(filepath.Join(w.dir, walName), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		// Start over from the file, which has the same records, when a message is next logged.
+		w.pos.Close()
+		w.f, w.pos = nil, nil
+		w.ends, w.offs = w.ends[:0], w.offs[:0]
+		clear(w.done)
+		return err
+	}
+	w.f = f
+	return nil
+}
+
+// savePos writes how far the file has been consumed to the pos file. The caller holds m.
+func (w *walFile) savePos() error {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(w.read))
+	_, err := w.pos.WriteAt(b[:], 0)
+	return err
+}
+
+// persist appends e to the write-ahead file if WithPersistence is used. An error is reported, and e is queued
+// without being persisted.
+func (al *Alog) persist(e *Entry) {
+	if al.wal == nil {
+		return
+	}
+	al.wal.m.Lock()
+	err := al.wal.append(e)
+	al.wal.m.Unlock()
+	if err != nil {
+		// Without holding wal.m, in case the error handler logs.
+		al.reportError(fmt.Errorf("alog: persisting message: %w", err))
+	}
+}
+
+// consumed marks the records of entries that have been dealt with as consumed, see WithPersistence.
+func (al *Alog) consumed(ends ...int64) {
+	if al.wal == nil {
+		return
+	}
+	if err := al.wal.consume(ends...); err != nil {
+		al.reportError(fmt.Errorf("alog: consuming persisted messages: %w", err))
+	}
+}
+
+// replayPersisted writes the messages that a previous logger with the same directory didn't consume, before the
+// Start loop handles anything else.
+func (al *Alog) replayPersisted(wg *sync.WaitGroup) {
+	if al.wal == nil {
+		return
+	}
+	w := al.wal
+	w.m.Lock()
+	var err error
+	if w.f == nil {
+		err = w.open()
+	}
+	entries, corrupt := w.replay, w.corrupt
+	w.replay, w.corrupt = nil, 0
+	w.m.Unlock()
+	if err != nil {
+		al.reportError(fmt.Errorf("alog: opening persisted messages: %w", err))
+		return
+	}
+	if corrupt > 0 {
+		msg := strconv.FormatInt(corrupt, 10) + " bytes of corrupt persisted messages discarded"
+		al.process(Entry{Level: LevelWarn, Message: msg}, wg)
+	}
+	for _, e := range entries {
+		al.process(e, wg)
+	}
+}
+
+// closePersistence compacts and closes the write-ahead file when the logger is stopped. It's opened again if a
+// message is logged.
+func (al *Alog) closePersistence() {
+	if al.wal == nil {
+		return
+	}
+	w := al.wal
+	w.m.Lock()
+	var err error
+	if w.f != nil && w.waste > 0 {
+		err = w.compact() // so the next logger doesn't write the consumed records again
+	}
+	if w.f != nil {
+		w.f.Close()
+		w.pos.Close()
+		w.f, w.pos = nil, nil
+		w.ends, w.offs = w.ends[:0], w.offs[:0]
+		clear(w.done)
+	}
+	w.m.Unlock()
+	if err != nil {
+		al.reportError(fmt.Errorf("alog: compacting persisted messages: %w", err))
+	}
+}
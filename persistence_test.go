@@ -0,0 +1,161 @@
+package alog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// crashWriter writes the first n messages and then hangs, as the process would if it crashed mid-stream.
+type crashWriter struct {
+	b       *lockedBuffer
+	m       sync.Mutex
+	n       int
+	crashed chan struct{}
+}
+
+func (cw *crashWriter) Write(data []byte) (int, error) {
+	cw.m.Lock()
+	if cw.n == 0 {
+		close(cw.crashed)
+		cw.m.Unlock()
+		select {} // the process is gone
+	}
+	cw.n--
+	cw.m.Unlock()
+	return cw.b.Write(data)
+}
+
+func TestPersistenceReplaysAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	cw := &crashWriter{b: &lockedBuffer{}, n: 7, crashed: make(chan struct{})}
+	crashed := New(cw, WithPersistence(dir), WithOverflowPolicy(Block, 20))
+	go crashed.Start()
+	for i := 0; i < 20; i++ {
+		if err := crashed.Info("m" + strconv.Itoa(i)); err != nil {
+			t.Fatalf("Message %d: %v", i, err)
+		}
+	}
+	<-cw.crashed
+
+	lb := &lockedBuffer{}
+	alog := New(lb, WithPersistence(dir))
+	go alog.Start()
+	alog.Info("after")
+	alog.Stop()
+
+	got := append(writtenMessages(cw.b), writtenMessages(lb)...)
+	if len(got) != 21 {
+		t.Fatalf("Expected 21 messages, got %d: %q", len(got), got)
+	}
+	for i, msg := range got[:20] {
+		if msg != "m"+strconv.Itoa(i) {
+			t.Fatalf("Message %d is %q, got %q", i, msg, got)
+		}
+	}
+	if got[20] != "after" {
+		t.Errorf("Expected the replayed messages before the new one, got %q", got)
+	}
+
+	// Everything was consumed, so there's nothing left to replay.
+	lb = &lockedBuffer{}
+	alog = New(lb, WithPersistence(dir))
+	go alog.Start()
+	alog.Stop()
+	if lb.String() != "" {
+		t.Errorf("Expected nothing to be replayed, got %q", lb.String())
+	}
+	if fi, err := os.Stat(filepath.Join(dir, walName)); err != nil || fi.Size() != 0 {
+		t.Errorf("Expected an empty write-ahead file, got %v, %v", fi, err)
+	}
+}
+
+func TestPersistenceTruncatesCorruptRecords(t *testing.T) {
+	dir := t.TempDir()
+	crashed := New(&lockedBuffer{}, WithPersistence(dir), WithOverflowPolicy(Block, 10))
+	crashed.Info("a") // queued, but never written
+	crashed.Info("b")
+	path := filepath.Join(dir, walName)
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crashed.Info("c")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := len(data) - int(fi.Size()) + 3 // the record of c, and a record cut short
+	data[len(data)-2] ^= 0xff                 // inside the payload of c
+	data = append(data, 3, 0, 0)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lb := &lockedBuffer{}
+	alog := New(lb, WithPersistence(dir), WithTimestampFormat(""))
+	go alog.Start()
+	alog.Stop()
+	want := "[WARN] - " + strconv.Itoa(corrupt) + " bytes of corrupt persisted messages discarded\n" +
+		"[INFO] - a\n[INFO] - b\n"
+	if got := lb.String(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPersistenceCompactsAfterFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	failing := New(failMatchingWriter{"unwritten", &lockedBuffer{}}, WithPersistence(dir), WithBufferSize(10))
+	failing.wal.compactAt = 100
+	go failing.Start()
+	failing.Info("unwritten")
+	for i := 0; i < 50; i++ {
+		failing.Info("written " + strconv.Itoa(i))
+	}
+	if err := failing.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, walName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records, entries, _ := (&walFile{}).scan(data); len(entries) > 3 || len(records) != len(data) ||
+		entries[0].Message != "unwritten" {
+		t.Errorf("Expected the file to be compacted behind the failed message, got %d bytes with %d messages",
+			len(data), len(entries))
+	}
+	failing.Stop()
+	data, _ = os.ReadFile(filepath.Join(dir, walName))
+	if _, entries, _ := (&walFile{}).scan(data); len(entries) != 1 {
+		t.Errorf("Expected Stop to compact the file down to the failed message, got %d messages", len(entries))
+	}
+
+	lb := &lockedBuffer{}
+	alog := New(lb, WithPersistence(dir), WithTimestampFormat(""))
+	go alog.Start()
+	alog.Stop()
+	if got := lb.String(); got != "[INFO] - unwritten\n" {
+		t.Errorf("Expected only the failed message to be replayed, got %q", got)
+	}
+}
+
+func TestPersistenceKeepsFailedWrites(t *testing.T) {
+	dir := t.TempDir()
+	fw := failingWriter{make(chan struct{})}
+	close(fw.open)
+	failing := New(fw, WithPersistence(dir))
+	go failing.Start()
+	failing.Info("unwritten")
+	failing.Stop()
+
+	lb := &lockedBuffer{}
+	alog := New(lb, WithPersistence(dir), WithTimestampFormat(""))
+	go alog.Start()
+	alog.Stop()
+	if got := lb.String(); got != "[INFO] - unwritten\n" {
+		t.Errorf("Expected the failed message to be replayed, got %q", got)
+	}
+}
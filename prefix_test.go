@@ -0,0 +1,66 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithPrefix(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithPrefix("ingest"), WithLatePolicy(LateWriteSync))
+	alog.now = newFakeClock(time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local)).Now
+	go alog.Start()
+	alog.Warn("async")
+	alog.Stop()
+	if _, err := alog.Write("sync"); err != nil {
+		t.Fatal(err)
+	}
+	want := "[2024-01-02 10:00:00] [WARN] [ingest] - async\n[2024-01-02 10:00:00] [ingest] - sync\n"
+	if b.String() != want {
+		t.Errorf("Got %q, want %q", b.String(), want)
+	}
+}
+
+func TestSetPrefixAndDerivedLoggers(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithTimestampFormat(""))
+	alog.SetPrefix("parent")
+	child := alog.WithFields(map[string]any{"k": "v"})
+	child.SetPrefix("child")
+	grandchild := child.WithFields(nil)
+	alog.SetPrefix("renamed")
+	for _, l := range []*Alog{alog, child, grandchild} {
+		if _, err := l.Write("msg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "[renamed] - msg\n[child] - msg k=v\n[child] - msg k=v\n"
+	if b.String() != want {
+		t.Errorf("Got %q, want %q", b.String(), want)
+	}
+	if alog.Prefix() != "renamed" || child.Prefix() != "child" {
+		t.Error("Prefix doesn't report the prefix set with SetPrefix")
+	}
+}
+
+func TestPrefixInStructuredFormats(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat(), WithPrefix("ingest"))
+	if _, err := alog.Write("msg"); err != nil {
+		t.Fatal(err)
+	}
+	if record := decodeJSONLines(t, b.String())[0]; record["component"] != "ingest" {
+		t.Errorf("Prefix not rendered as component in JSON: %v", record)
+	}
+
+	b.Reset()
+	alog = New(b, WithLogfmtFormat(), WithPrefix("in gest"))
+	if _, err := alog.Write("msg"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), ` msg=msg component="in gest"`+"\n") {
+		t.Errorf("Prefix not rendered as component in logfmt: %q", b.String())
+	}
+}
@@ -0,0 +1,78 @@
+package alog
+
+import "sync"
+
+const (
+	defaultPriorityCapacity = 64
+	priorityBurst           = 8 // priority messages written for every other message while both are queued
+)
+
+// WithPriorityLane gives the logger a second queue, of capacity messages or 64 if capacity is 0 or less, for
+// messages at LevelError and above and those logged with ErrorNow. The Start loop writes up to 8 messages from it
+// for every message from the normal queue, so an error doesn't wait behind a backlog of less important messages, and
+// the normal queue still moves while errors keep coming. Messages keep their order within each queue but not across
+// them. When the priority queue is full a message waits for room in it, whatever the WithOverflowPolicy, and Flush
+// and Stop write what's in both queues.
+func WithPriorityLane(capacity int) Option {
+	return func(al *Alog) {
+		if capacity <= 0 {
+			capacity = defaultPriorityCapacity
+		}
+		al.prioritySize = capacity
+	}
+}
+
+// ErrorNow queues msg to be written at LevelError like Error, and sends it ahead of the messages waiting in the
+// normal queue if WithPriorityLane is used.
+func (al *Alog) ErrorNow(msg string) error {
+	if !al.enabled(LevelError) || al.sampler.sampleOut(LevelError) {
+		return nil
+	}
+	e := al.newEntry(LevelError, msg)
+	e.Caller = al.caller(1)
+	e.Stack = al.stack(LevelError)
+	e.priority = true
+	return al.enqueue(e)
+}
+
+// prioritized reports whether e goes in the priority queue.
+func (al *Alog) prioritized(e *Entry) bool {
+	return al.priorityCh != nil && (e.priority || e.Level >= LevelError)
+}
+
+// queuePriority hands e to the Start loop through the priority queue, waiting for room in it.
+func (al *Alog) queuePriority(e Entry) error {
+	_, shutdownCompleteCh := al.runChannels()
+	select {
+	case al.priorityCh <- e:
+		return nil
+	case <-shutdownCompleteCh:
+		return al.late(e)
+	}
+}
+
+// takePriority writes up to priorityBurst of the messages waiting in the priority queue, without waiting for any,
+// and returns how many it wrote.
+func (al *Alog) takePriority(wg *sync.WaitGroup) int {
+	if al.priorityCh == nil {
+		return 0
+	}
+	for i := 0; i < priorityBurst; i++ {
+		select {
+		case e := <-al.priorityCh:
+			al.process(e, wg)
+		default:
+			return i
+		}
+	}
+	return priorityBurst
+}
+
+// priorityLane returns the priority queue for the Start loop to wait on after takePriority wrote took messages, or
+// nil once it wrote a full burst and a message is waiting in the normal queue, which then gets its turn.
+func (al *Alog) priorityLane(took int) <-chan Entry {
+	if took == priorityBurst && len(al.msgCh)+len(al.sentCh)+len(al.entryCh) > 0 {
+		return nil
+	}
+	return al.priorityCh
+}
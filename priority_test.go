@@ -0,0 +1,82 @@
+package alog
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPriorityLane(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 10000), WithPriorityLane(0))
+	go alog.Start()
+	queueBehind(t, alog, 10000)
+	alog.Error("urgent")
+	if n := alog.Pending(); n != 10002 {
+		t.Errorf("Expected 10002 pending messages, got %d", n)
+	}
+	close(gw.open)
+	alog.Stop()
+
+	got := writtenMessages(gw.b)
+	if len(got) != 10002 {
+		t.Fatalf("Expected 10002 messages, got %d", len(got))
+	}
+	if got[1] != "urgent" {
+		t.Errorf("Expected the error to be written right after m0, got %q", got[:5])
+	}
+	got = append(got[:1], got[2:]...)
+	for i, msg := range got {
+		if msg != "m"+strconv.Itoa(i) {
+			t.Fatalf("Message %d is %q", i, msg)
+		}
+	}
+}
+
+func TestPriorityLaneTakesTurns(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 10), WithPriorityLane(20))
+	go alog.Start()
+	queueBehind(t, alog, 3)
+	for i := 0; i < 20; i++ {
+		alog.ErrorNow("e" + strconv.Itoa(i))
+	}
+	close(gw.open)
+	for alog.Pending() > 0 { // so Stop doesn't take over the queues
+		time.Sleep(time.Millisecond)
+	}
+	alog.Stop()
+
+	var want []string
+	for i := 0; i < 20; i++ {
+		if i%8 == 0 {
+			want = append(want, "m"+strconv.Itoa(i/8))
+		}
+		want = append(want, "e"+strconv.Itoa(i))
+	}
+	want = append(want, "m3")
+	got := writtenMessages(gw.b)
+	if len(got) != len(want) {
+		t.Fatalf("Got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestErrorNowWithoutPriorityLane(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 10))
+	go alog.Start()
+	queueBehind(t, alog, 2)
+	alog.ErrorNow("error")
+	close(gw.open)
+	alog.Stop()
+
+	got := writtenMessages(gw.b)
+	if len(got) != 4 || got[3] != "error" {
+		t.Errorf("Expected the error to wait its turn, got %q", got)
+	}
+}
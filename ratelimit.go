@@ -0,0 +1,140 @@
+package alog
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateLimiter lets at most n messages through per window, see WithRateLimit.
+type rateLimiter struct {
+	n      int
+	per    time.Duration
+	exempt Level // messages at this level or above aren't limited
+
+	m          sync.Mutex
+	start      time.Time // of the current window
+	count      int       // messages let through in the current window
+	suppressed int       // messages dropped in the current window
+	timer      *time.Timer
+}
+
+// WithRateLimit lets at most n messages logged with the level methods, or a slog.Handler, through every per; the
+// ones beyond that are dropped before they're queued and counted by Dropped. Once the window is over the logger
+// writes a single LevelWarn message with the number that were dropped, such as "suppressed 1250 messages in the last
+// 1s". That happens when the next message is logged or when the window's time is up, whichever comes first, and on
+// Stop for a window that isn't over yet. Messages sent on MessageChannel or written with Write aren't limited, and
+// see WithRateLimitExempt to let important messages through whatever the rate.
+func WithRateLimit(n int, per time.Duration) Option {
+	return func(al *Alog) {
+		exempt := Level(math.MaxInt32)
+		if al.limiter != nil {
+			exempt = al.limiter.exempt
+		}
+		al.limiter = &rateLimiter{n: n, per: per, exempt: exempt}
+	}
+}
+
+// WithRateLimitExempt makes WithRateLimit let messages at min or above through, without counting them towards the
+// limit. It must come after WithRateLimit.
+func WithRateLimitExempt(min Level) Option {
+	return func(al *Alog) {
+		if al.limiter != nil {
+			al.limiter.exempt = min
+		}
+	}
+}
+
+// limit reports whether a message at level l fits in the current window. If it's the first message of a new window
+// the summary of the last one is queued first.
+func (al *Alog) limit(l Level) bool {
+	rl := al.limiter
+	if l >= rl.exempt {
+		return true
+	}
+	now := al.now()
+	rl.m.Lock()
+	suppressed := rl.roll(now)
+	ok := rl.count < rl.n
+	if ok {
+		rl.count++
+	} else {
+		rl.suppressed++
+		if rl.timer == nil {
+			rl.timer = time.AfterFunc(rl.start.Add(rl.per).Sub(now), al.rollLimit)
+		}
+	}
+	rl.m.Unlock()
+	if suppressed > 0 {
+		al.queueSummary(suppressed)
+	}
+	if !ok {
+		atomic.AddUint64(&al.dropped, 1)
+	}
+	return ok
+}
+
+// roll starts a new window if the current one is over at now and returns the number of messages suppressed in the
+// one that ended. The caller holds m.
+func (rl *rateLimiter) roll(now time.Time) int {
+	if now.Sub(rl.start) < rl.per && !now.Before(rl.start) {
+		return 0
+	}
+	suppressed := rl.suppressed
+	rl.start, rl.count, rl.suppressed = now, 0, 0
+	if rl.timer != nil {
+		rl.timer.Stop()
+		rl.timer = nil
+	}
+	return suppressed
+}
+
+// rollLimit runs on rateLimiter.timer at the end of a window in which messages were suppressed, in case nothing is
+// logged after it.
+func (al *Alog) rollLimit() {
+	rl := al.limiter
+	rl.m.Lock()
+	rl.timer = nil
+	suppressed := rl.roll(al.now())
+	rl.m.Unlock()
+	if suppressed > 0 {
+		al.queueSummary(suppressed)
+	}
+}
+
+// queueSummary queues the message for a window in which n messages were suppressed.
+func (al *Alog) queueSummary(n int) {
+	e := al.limitSummary(n)
+	e.Seq = al.nextSeq()
+	_ = al.queue(e)
+}
+
+// limitSummary returns the message saying that n messages were suppressed.
+func (al *Alog) limitSummary(n int) Entry {
+	msg := "suppressed " + strconv.Itoa(n) + " messages in the last " + al.limiter.per.String()
+	return Entry{Level: LevelWarn, Message: msg}
+}
+
+// reportSuppressed writes the summary for the current window, if messages were suppressed in it, when the logger is
+// stopped. The window carries on, with its count of suppressed messages starting from 0.
+func (al *Alog) reportSuppressed(wg *sync.WaitGroup) {
+	rl := al.limiter
+	if rl == nil {
+		return
+	}
+	rl.m.Lock()
+	suppressed := rl.suppressed
+	rl.suppressed = 0
+	if rl.timer != nil {
+		rl.timer.Stop()
+		rl.timer = nil
+	}
+	rl.m.Unlock()
+	if suppressed > 0 {
+		e := al.limitSummary(suppressed)
+		e.Seq = al.nextSeq()
+		al.process(e, wg)
+	}
+}
@@ -0,0 +1,82 @@
+package alog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	lb := &lockedBuffer{}
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog := New(lb, WithRateLimit(3, time.Second), WithBufferSize(100))
+	alog.now = clock.Now
+	go alog.Start()
+	for i := 0; i < 10; i++ {
+		err := alog.Warn("disk almost full")
+		if i < 3 && err != nil {
+			t.Errorf("Message %d: %v", i, err)
+		} else if i >= 3 && err != ErrRateLimited {
+			t.Errorf("Expected message %d to be rate limited, got %v", i, err)
+		}
+	}
+	clock.advance(999 * time.Millisecond)
+	alog.Info("still limited")
+	clock.advance(time.Millisecond)
+	alog.Info("next window")
+	alog.Stop()
+
+	want := []string{"disk almost full", "disk almost full", "disk almost full",
+		"suppressed 8 messages in the last 1s", "next window"}
+	if got := writtenMessages(lb); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if n := alog.Dropped(); n != 8 {
+		t.Errorf("Expected 8 dropped messages, got %d", n)
+	}
+}
+
+func TestRateLimitExempt(t *testing.T) {
+	lb := &lockedBuffer{}
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog := New(lb, WithRateLimit(1, time.Minute), WithRateLimitExempt(LevelError), WithBufferSize(100))
+	alog.now = clock.Now
+	go alog.Start()
+	alog.Info("one")
+	alog.Info("two")
+	alog.Error("failed")
+	alog.Info("three")
+	alog.Error("failed again")
+	alog.Stop()
+
+	want := "one,failed,failed again,suppressed 2 messages in the last 1m0s"
+	if got := writtenMessages(lb); strings.Join(got, ",") != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestRateLimitSummaryOnTimer(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithRateLimit(1, 20*time.Millisecond), WithBufferSize(100))
+	go alog.Start()
+	defer alog.Stop()
+	for i := 0; i < 5; i++ {
+		alog.Info("burst")
+	}
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(lb.String(), "suppressed 4 messages in the last 20ms") {
+		if time.Now().After(deadline) {
+			t.Fatalf("No summary once the window was over, got %q", lb.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimitTry(t *testing.T) {
+	clock := newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	alog := New(&lockedBuffer{}, WithRateLimit(1, time.Second), WithBufferSize(100))
+	alog.now = clock.Now
+	if !alog.TryInfof("one") || alog.TryInfof("two") {
+		t.Error("Expected TryInfof to follow the rate limit")
+	}
+}
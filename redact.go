@@ -0,0 +1,99 @@
+package alog
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Patterns for WithRedactor that match common secrets and personal data.
+var (
+	// RedactEmail matches email addresses.
+	RedactEmail = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// RedactBearerToken matches bearer tokens, such as those in an Authorization header, with "Bearer " as the
+	// first submatch, so a replacement of "${1}[REDACTED]" keeps it.
+	RedactBearerToken = regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9._~+/-]+=*`)
+)
+
+// redactor is a pattern added with WithRedactor.
+type redactor struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// redactors are applied in order, each to the result of the ones before it.
+type redactors []redactor
+
+// WithRedactor replaces the text that matches re in every message, and in the fields' values, with replacement,
+// which can refer to submatches like regexp.Regexp.ReplaceAllString's. It can be used more than once, and the
+// patterns are applied in the order they were added, each to the result of the ones before it. Redaction happens
+// just before a message is formatted, on the Start loop or the goroutine calling Write, so nothing reaches a
+// destination, sink or EntryWriter unredacted. Field values that aren't strings are redacted if they're errors or
+// fmt.Stringers, and replaced by the redacted text if anything matched.
+func WithRedactor(re *regexp.Regexp, replacement string) Option {
+	return func(al *Alog) {
+		al.redactors = append(al.redactors, redactor{re, replacement})
+	}
+}
+
+// Redact returns a middleware that redacts messages like WithRedactor, for ordering it with other middlewares, see
+// Use. The redactors added with WithRedactor have already been applied to the entries it gets.
+func Redact(re *regexp.Regexp, replacement string) Middleware {
+	rs := redactors{{re, replacement}}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(e *Entry) error {
+			rs.redact(e)
+			return next(e)
+		}
+	}
+}
+
+// redact applies the redactors to e's message and fields. The fields are copied before they're changed, since
+// they're shared with the logger and other entries.
+func (rs redactors) redact(e *Entry) {
+	e.Message = rs.redactString(e.Message)
+	if fields, changed := rs.redactFields(e.Fields); changed {
+		e.Fields = fields
+	}
+}
+
+func (rs redactors) redactString(s string) string {
+	for _, r := range rs {
+		s = r.re.ReplaceAllString(s, r.replacement)
+	}
+	return s
+}
+
+// redactFields returns fields with the redactors applied to their values, and whether any of them changed. fields
+// is left as it is.
+func (rs redactors) redactFields(fields []Field) ([]Field, bool) {
+	var redacted []Field
+	for i, f := range fields {
+		v, changed := rs.redactValue(f.Value)
+		if !changed {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]Field(nil), fields...)
+		}
+		redacted[i].Value = v
+	}
+	return redacted, redacted != nil
+}
+
+func (rs redactors) redactValue(v any) (any, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case []Field:
+		return rs.redactFields(v)
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return v, false
+	}
+	r := rs.redactString(s)
+	return r, r != s
+}
@@ -0,0 +1,46 @@
+//go:build !plan9
+
+package alog
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ReopenOnSignal calls Reopen whenever the process receives one of sigs, or syscall.SIGHUP if none are given, which
+// is what logrotate's postrotate scripts usually send. Errors from Reopen are returned by the next Write, so they
+// reach the logger's error channel. The returned function stops watching the signals.
+func (fw *FileWriter) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, sigs...)
+	return fw.reopenOn(sigCh, func() { signal.Stop(sigCh) })
+}
+
+// reopenOn calls Reopen for every value received on sigCh until the returned function is called, which calls
+// unregister.
+func (fw *FileWriter) reopenOn(sigCh <-chan os.Signal, unregister func()) func() {
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for {
+			select {
+			case <-sigCh:
+				if err := fw.Reopen(); err != nil {
+					fw.addBackgroundErr(err)
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return func() {
+		unregister()
+		close(stopCh)
+		<-doneCh
+	}
+}
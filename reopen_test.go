@@ -0,0 +1,136 @@
+//go:build !plan9
+
+package alog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFileWriterReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	fw := NewFileWriter(path)
+	alog := New(fw)
+	go alog.Start()
+	defer alog.Stop()
+	alog.Info("before")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	alog.Info("still old")
+	if err := fw.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	alog.Info("after")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	files := readFiles(t, dir)
+	if got := files["app.log.1"]; !strings.Contains(got, "- before\n") || strings.Contains(got, "- after") {
+		t.Errorf("Got %q in the renamed file", got)
+	}
+	if got := files["app.log"]; !strings.HasSuffix(got, "- after\n") || strings.Contains(got, "before") {
+		t.Errorf("Got %q in the new file", got)
+	}
+}
+
+func TestFileWriterReopenFails(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	fw := NewFileWriter(filepath.Join(sub, "app.log"))
+	if _, err := fw.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(sub, "app.log"), filepath.Join(dir, "app.log.1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sub, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Reopen(); err == nil {
+		t.Fatal("Expected an error for a directory that can't be created")
+	}
+	if _, err := fw.Write([]byte("two\n")); err != nil {
+		t.Fatal(err)
+	}
+	fw.Close()
+	if got := readFiles(t, dir)["app.log.1"]; got != "one\ntwo\n" {
+		t.Errorf("Expected writing to carry on in the old file, got %q", got)
+	}
+}
+
+func TestReopenOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	fw := NewFileWriter(path)
+	if _, err := fw.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	sigCh := make(chan os.Signal)
+	unregistered := false
+	stop := fw.reopenOn(sigCh, func() { unregistered = true })
+	sigCh <- syscall.SIGHUP
+	sigCh <- syscall.SIGHUP // handled after the first Reopen returned
+	stop()
+	if !unregistered {
+		t.Error("Stopping didn't unregister the signals")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the signal to reopen the file: %v", err)
+	}
+	if _, err := fw.Write([]byte("two\n")); err != nil {
+		t.Fatal(err)
+	}
+	fw.Close()
+	if files := readFiles(t, dir); files["app.log"] != "two\n" || files["app.log.1"] != "one\n" {
+		t.Errorf("Got %q", files)
+	}
+}
+
+func TestReopenOnSignalDelivered(t *testing.T) {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	fw := NewFileWriter(path)
+	defer fw.Close()
+	if _, err := fw.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	stop := fw.ReopenOnSignal()
+	defer stop()
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("Can't send SIGHUP: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("File wasn't reopened on SIGHUP")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
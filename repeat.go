@@ -0,0 +1,94 @@
+package alog
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// repeats is the state of WithRepeatSuppression. It's only used on the Start loop's goroutine.
+type repeats struct {
+	interval time.Duration
+	timer    *time.Timer
+	armed    bool   // the timer is running and its channel hasn't been received from
+	last     []byte // the last message written, formatted without its time and sequence number
+	held     Entry  // the last of the repeats held back
+	count    int    // the number of repeats held back
+}
+
+// WithRepeatSuppression collapses runs of identical messages, like syslog: a message that's the same as the one
+// before it, apart from its time, is held back and counted. When a different message arrives, or flushInterval
+// after the first repeat if it's more than 0, the logger writes "last message repeated N times", at the level of the
+// repeated message, before carrying on. A single repeat is written as it is rather than summarized. Flush and Stop
+// write what's held back first. A logger with repeat suppression doesn't batch messages, see WithBatching.
+func WithRepeatSuppression(flushInterval time.Duration) Option {
+	return func(al *Alog) {
+		t := time.NewTimer(time.Hour)
+		t.Stop()
+		al.repeats = &repeats{interval: flushInterval, timer: t}
+	}
+}
+
+// repeated reports whether e is the same as the last message and has been held back. Otherwise the repeats held
+// back, if any, are written and e becomes the message the next ones are compared with.
+func (al *Alog) repeated(e Entry, wg *sync.WaitGroup) bool {
+	r := al.repeats
+	fb := getFormatBuffer()
+	defer putFormatBuffer(fb)
+	fb.e = e
+	fb.e.Time, fb.e.noTime, fb.e.Seq = time.Time{}, true, 0
+	fb.buf = al.formatter.Format(fb.buf, &fb.e)
+	if r.last != nil && bytes.Equal(fb.buf, r.last) {
+		r.held = e
+		r.count++
+		if r.count == 1 && r.interval > 0 {
+			r.timer.Reset(r.interval)
+			r.armed = true
+		}
+		return true
+	}
+	al.flushRepeats(wg)
+	r.last = append(r.last[:0], fb.buf...)
+	return false
+}
+
+// flushRepeats writes the repeats held back by WithRepeatSuppression, if there are any.
+func (al *Alog) flushRepeats(wg *sync.WaitGroup) {
+	r := al.repeats
+	if r == nil || r.count == 0 {
+		return
+	}
+	if r.armed {
+		if !r.timer.Stop() {
+			<-r.timer.C
+		}
+		r.armed = false
+	}
+	e := r.held
+	if r.count > 1 {
+		e = Entry{
+			Level:   r.held.Level,
+			Message: "last message repeated " + strconv.Itoa(r.count) + " times",
+			Prefix:  r.held.Prefix,
+			Name:    r.held.Name,
+			Seq:     r.held.Seq,
+		}
+	}
+	r.count = 0
+	al.dispatch(e, wg)
+}
+
+// repeatTimer returns the channel of WithRepeatSuppression's timer while it's running, or nil.
+func (al *Alog) repeatTimer() <-chan time.Time {
+	if al.repeats == nil || !al.repeats.armed {
+		return nil
+	}
+	return al.repeats.timer.C
+}
+
+// repeatTimerFired writes the repeats held back when the timer fires.
+func (al *Alog) repeatTimerFired(wg *sync.WaitGroup) {
+	al.repeats.armed = false
+	al.flushRepeats(wg)
+}
@@ -0,0 +1,96 @@
+package alog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepeatSuppression(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithRepeatSuppression(0), WithBufferSize(100))
+	go alog.Start()
+	alog.Info("connecting")
+	for i := 0; i < 5; i++ {
+		alog.Warn("connection refused")
+	}
+	alog.Info("connected")
+	alog.Info("connected")
+	alog.Info("ready")
+	alog.Stop()
+
+	want := []string{"connecting", "connection refused", "last message repeated 4 times", "connected", "connected",
+		"ready"}
+	if got := writtenMessages(lb); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if !strings.Contains(lb.String(), "[WARN] - last message repeated") {
+		t.Errorf("Expected the summary at the level of the repeated message, got %q", lb.String())
+	}
+}
+
+func TestRepeatSuppressionAlternating(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithRepeatSuppression(0), WithBufferSize(100))
+	go alog.Start()
+	for i := 0; i < 3; i++ {
+		alog.Info("tick")
+		alog.Info("tock")
+	}
+	alog.Warn("tock") // not a repeat, the level differs
+	alog.Stop()
+	if got := writtenMessages(lb); strings.Join(got, ",") != "tick,tock,tick,tock,tick,tock,tock" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestRepeatSuppressionStop(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithRepeatSuppression(time.Hour), WithBufferSize(100))
+	go alog.Start()
+	for i := 0; i < 3; i++ {
+		alog.Error("disk full")
+	}
+	alog.Stop()
+	if got := writtenMessages(lb); strings.Join(got, ",") != "disk full,last message repeated 2 times" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestRepeatSuppressionFlush(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithRepeatSuppression(0), WithWorkers(2), WithBufferSize(100))
+	go alog.Start()
+	defer alog.Stop()
+	alog.Info("same")
+	alog.Info("same")
+	alog.Info("same")
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := writtenMessages(lb); strings.Join(got, ",") != "same,last message repeated 2 times" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestRepeatSuppressionInterval(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithRepeatSuppression(10*time.Millisecond), WithBufferSize(100))
+	go alog.Start()
+	defer alog.Stop()
+	for i := 0; i < 4; i++ {
+		alog.Info("retrying")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := writtenMessages(lb)
+		if strings.Join(got, ",") == "retrying,last message repeated 3 times" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("No summary after the interval, got %q", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,45 @@
+package alog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ShutdownReport summarizes how the logger was stopped, see StopReport.
+type ShutdownReport struct {
+	Flushed   int   // messages written while StopReport waited, those it drained from the queue among them
+	Dropped   int   // messages dropped over the logger's lifetime, see Dropped
+	Remaining int   // messages still waiting to be written when StopReport gave up, 0 if it didn't
+	Err       error // what StopContext returned, a *StopError if ctx was done first
+}
+
+// String returns the report as a line such as "alog: flushed 12, dropped 3, remaining 0", followed by the error
+// if there is one, to write to stderr as the last thing the program does.
+func (r ShutdownReport) String() string {
+	s := fmt.Sprintf("alog: flushed %d, dropped %d, remaining %d", r.Flushed, r.Dropped, r.Remaining)
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+	return s
+}
+
+// StopReport stops the logger like StopContext and reports how that went: how many messages were written while it
+// waited, how many have been dropped, by the WithOverflowPolicy or otherwise, and how many were abandoned because
+// ctx was done before they could be written. The logger keeps writing those in the background, like it does after
+// StopContext gives up.
+func (al *Alog) StopReport(ctx context.Context) ShutdownReport {
+	before := atomic.LoadUint64(&al.written)
+	err := al.StopContext(ctx)
+	r := ShutdownReport{
+		Flushed: int(atomic.LoadUint64(&al.written) - before),
+		Dropped: int(al.Dropped()),
+		Err:     err,
+	}
+	var stopErr *StopError
+	if errors.As(err, &stopErr) {
+		r.Remaining = stopErr.Pending
+	}
+	return r
+}
@@ -0,0 +1,57 @@
+package alog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStopReport(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 10))
+	go alog.Start()
+	queueBehind(t, alog, 5)
+	close(gw.open)
+	r := alog.StopReport(context.Background())
+
+	if r != (ShutdownReport{Flushed: 6}) {
+		t.Errorf("Expected a clean drain of 6 messages, got %+v", r)
+	}
+	if got, want := r.String(), "alog: flushed 6, dropped 0, remaining 0"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestStopReportTimeout(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Block, 10))
+	go alog.Start()
+	queueBehind(t, alog, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	r := alog.StopReport(ctx)
+
+	if r.Flushed != 0 || r.Remaining != 4 || !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Errorf("Expected 4 messages to be abandoned, got %+v", r)
+	}
+	want := "alog: flushed 0, dropped 0, remaining 4: alog: stopped with 4 messages pending: context deadline exceeded"
+	if got := r.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	close(gw.open)
+	alog.Stop()
+}
+
+func TestStopReportDropped(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(DropNewest, 2))
+	go alog.Start()
+	fillQueue(t, alog)
+	close(gw.open)
+	r := alog.StopReport(context.Background())
+
+	if r != (ShutdownReport{Flushed: 3, Dropped: 3}) {
+		t.Errorf("Expected 3 messages written and 3 dropped, got %+v", r)
+	}
+}
@@ -0,0 +1,154 @@
+package alog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithMaxBackups makes the FileWriter keep at most n rotated files, deleting the oldest ones.
+func WithMaxBackups(n int) FileOption {
+	return func(fw *FileWriter) {
+		fw.maxBackups = n
+	}
+}
+
+// WithMaxBackupBytes makes the FileWriter delete the oldest rotated files once all of them together take up more
+// than n bytes.
+func WithMaxBackupBytes(n int64) FileOption {
+	return func(fw *FileWriter) {
+		fw.maxBackupBytes = n
+	}
+}
+
+// WithMaxBackupAge makes the FileWriter delete rotated files that were last written to more than d ago.
+func WithMaxBackupAge(d time.Duration) FileOption {
+	return func(fw *FileWriter) {
+		fw.maxBackupAge = d
+	}
+}
+
+// retains reports whether any of the retention options were used.
+func (fw *FileWriter) retains() bool {
+	return fw.maxBackups > 0 || fw.maxBackupBytes > 0 || fw.maxBackupAge > 0
+}
+
+// cleanup deletes the rotated files that the retention options don't allow to keep, which are the oldest ones, as
+// the newest are kept first, leaving current alone. Rotated files are found by their names, so nothing else in the
+// directory is touched. It runs after every rotation and when the first file, or the first file of a period, is
+// opened, which takes care of files left by an earlier run. Files waiting to be compressed count towards the limits
+// but aren't deleted, the cleanup after their compression sees to them, and a .gz next to the file it was made from
+// is left alone too, as it's only partly written. Errors are recorded like compression errors. The caller holds
+// bgWork.
+func (fw *FileWriter) cleanup(current string) {
+	entries, err := os.ReadDir(filepath.Dir(fw.path))
+	if err != nil {
+		fw.addBackgroundErr(err)
+		return
+	}
+	fw.bgM.Lock()
+	queued := make(map[string]bool, len(fw.queued))
+	for name := range fw.queued {
+		queued[name] = true
+	}
+	fw.bgM.Unlock()
+	present := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		present[filepath.Join(filepath.Dir(fw.path), entry.Name())] = true
+	}
+	type backup struct {
+		name string
+		info os.FileInfo
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := filepath.Join(filepath.Dir(fw.path), entry.Name())
+		if name == current || !entry.Type().IsRegular() || !fw.isBackup(entry.Name()) {
+			continue
+		}
+		if orig, ok := strings.CutSuffix(name, ".gz"); ok && (present[orig] || queued[orig]) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // deleted in the meantime
+		}
+		backups = append(backups, backup{name, info})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		ti, tj := backups[i].info.ModTime(), backups[j].info.ModTime()
+		if ti.Equal(tj) {
+			return backups[i].name > backups[j].name // the names have the time of the rotation in them
+		}
+		return ti.After(tj)
+	})
+	var kept int
+	var total int64
+	now := fw.now()
+	for _, b := range backups {
+		total += b.info.Size()
+		if (fw.maxBackups <= 0 || kept < fw.maxBackups) &&
+			(fw.maxBackupBytes <= 0 || total <= fw.maxBackupBytes) &&
+			(fw.maxBackupAge <= 0 || now.Sub(b.info.ModTime()) <= fw.maxBackupAge) {
+			kept++
+			continue
+		}
+		if queued[b.name] {
+			continue
+		}
+		if err := os.Remove(b.name); err != nil && !os.IsNotExist(err) {
+			fw.addBackgroundErr(err)
+		}
+	}
+}
+
+// isBackup reports whether base is the name of a file that was rotated by fw: the file name, with the period in
+// it if there's a Rotation, followed by the time it was rotated by size, and .gz once it's compressed. Files of
+// earlier periods count as rotated too.
+func (fw *FileWriter) isBackup(base string) bool {
+	base = strings.TrimSuffix(base, ".gz")
+	name := filepath.Base(fw.path)
+	if fw.rotation != NoRotation {
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext) + "-"
+		layout := fw.rotation.layout()
+		if !strings.HasPrefix(base, stem) || len(base) < len(stem)+len(layout) {
+			return false
+		}
+		period := base[len(stem) : len(stem)+len(layout)]
+		if _, err := time.Parse(layout, period); err != nil {
+			return false
+		}
+		name = stem + period + ext
+		if base == name {
+			return true // an earlier period's file
+		}
+	}
+	rest, ok := strings.CutPrefix(base, name)
+	if !ok {
+		return false
+	}
+	// What's left is ".20060102-150405", maybe followed by ".N".
+	const layout = "20060102-150405"
+	if len(rest) < 1+len(layout) || rest[0] != '.' {
+		return false
+	}
+	if _, err := time.Parse(layout, rest[1:1+len(layout)]); err != nil {
+		return false
+	}
+	rest = rest[1+len(layout):]
+	if rest == "" {
+		return true
+	}
+	if rest[0] != '.' || len(rest) == 1 {
+		return false
+	}
+	for _, c := range rest[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package alog
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeFiles creates the files in dir with size bytes each, modified age ago.
+func makeFiles(t *testing.T, dir string, now time.Time, files map[string]time.Duration, size int) {
+	t.Helper()
+	for name, age := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func fileNames(t *testing.T, dir string) string {
+	t.Helper()
+	var names []string
+	for name := range readFiles(t, dir) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+func TestFileWriterRetention(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	day := 24 * time.Hour
+	backups := map[string]time.Duration{
+		"app.log.20240501-120000":      9 * day,
+		"app.log.20240502-120000.gz":   8 * day,
+		"app.log.20240502-120000.1.gz": 7 * day,
+		"app.log.20240509-120000":      time.Hour,
+	}
+	unrelated := map[string]time.Duration{
+		"app.log.bak":               10 * day,
+		"app.log.20240501":          10 * day,
+		"app.log.20240501-120000x":  10 * day,
+		"other.log.20240501-120000": 10 * day,
+	}
+	tests := []struct {
+		opt  FileOption
+		want string
+	}{
+		{WithMaxBackups(2), "app.log.20240502-120000.1.gz app.log.20240509-120000"},
+		{WithMaxBackupBytes(30), "app.log.20240502-120000.1.gz app.log.20240502-120000.gz app.log.20240509-120000"},
+		{WithMaxBackupAge(8 * day), "app.log.20240502-120000.1.gz app.log.20240502-120000.gz app.log.20240509-120000"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		makeFiles(t, dir, now, backups, 10)
+		makeFiles(t, dir, now, unrelated, 10)
+		fw := NewFileWriter(filepath.Join(dir, "app.log"), tt.opt)
+		fw.now = newFakeClock(now).Now
+		if _, err := fw.Write([]byte("a\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := fw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		got := fileNames(t, dir)
+		want := tt.want + " app.log app.log.20240501 app.log.20240501-120000x app.log.bak other.log.20240501-120000"
+		for _, name := range strings.Fields(want) {
+			if !strings.Contains(" "+got+" ", " "+name+" ") {
+				t.Errorf("%s was deleted: %s", name, got)
+			}
+		}
+		if len(strings.Fields(got)) != len(strings.Fields(want)) {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+}
+
+func TestFileWriterRetentionAfterRotation(t *testing.T) {
+	dir := t.TempDir()
+	fw := NewFileWriter(filepath.Join(dir, "app.log"), WithMaxFileSize(2), WithMaxBackups(2))
+	fw.now = newTickingClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)).Now
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n", "e\n"} {
+		if _, err := fw.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		fw.bg.Wait() // so the modification times follow the rotations
+	}
+	fw.Close()
+	files := readFiles(t, dir)
+	if len(files) != 3 || files["app.log"] != "e\n" {
+		t.Errorf("Expected the current file and two backups, got %q", files)
+	}
+}
+
+func TestFileWriterRetentionPeriods(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	makeFiles(t, dir, now, map[string]time.Duration{
+		"app-2024-05-08.log":                    48 * time.Hour,
+		"app-2024-05-09.log.20240509-120000.gz": 36 * time.Hour,
+		"app-2024-05-09.log":                    24 * time.Hour,
+		"app-notadate.log":                      72 * time.Hour,
+	}, 1)
+	fw := NewFileWriter(filepath.Join(dir, "app.log"), WithTimeRotation(Daily), WithMaxBackups(1))
+	fw.now = newFakeClock(now).Now
+	fw.Write([]byte("a\n"))
+	fw.Close()
+	if got, want := fileNames(t, dir), "app-2024-05-09.log app-2024-05-10.log app-notadate.log"; got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+}
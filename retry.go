@@ -0,0 +1,158 @@
+package alog
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxRetryDelay caps the wait between two attempts made by WithRetry.
+const maxRetryDelay = time.Minute
+
+// RetryError is sent on the error channel when a message couldn't be written to the writer passed to New in the
+// attempts allowed by WithRetry.
+type RetryError struct {
+	Attempts int   // attempts made, fewer than allowed if the logger was being stopped
+	Err      error // the last attempt's error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("alog: write failed after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetry makes up to maxAttempts attempts to write each message to the writer passed to New, waiting baseDelay
+// before the second attempt and twice as long before each one after that, up to a minute. Up to half of each wait
+// is taken off at random, so loggers that share a destination don't retry in step. Only the part of a message that
+// wasn't written is retried. The message is retried on the goroutine that writes it, before anything behind it, so
+// messages stay in order, and the error, a *RetryError, is only sent on the error channel once the attempts have
+// run out. The other destinations, such as those added with WithAdditionalWriter, aren't retried, and a
+// maxAttempts of 1 or less doesn't retry at all.
+//
+// Once Stop has been called the waits of all the messages that are still written add up to no more than one
+// message's would, and then failed writes aren't retried any more, so a destination that's down can't hold up
+// shutdown for long.
+func WithRetry(maxAttempts int, baseDelay time.Duration) Option {
+	return func(al *Alog) {
+		al.retryAttempts = maxAttempts
+		al.retryDelay = baseDelay
+	}
+}
+
+// retryWriter is the writer passed to New, or the EntryWriter in its place, when WithRetry is used.
+type retryWriter struct {
+	w           io.Writer
+	ew          EntryWriter
+	maxAttempts int
+	baseDelay   time.Duration
+	full        time.Duration // the longest a single message can wait in all
+	stopping    func() bool
+	sleep       func(d time.Duration)
+
+	m      sync.Mutex    // workers may retry concurrently
+	budget time.Duration // left for waiting once the logger is stopping
+}
+
+func (al *Alog) newRetryWriter() *retryWriter {
+	rw := &retryWriter{
+		maxAttempts: al.retryAttempts,
+		baseDelay:   al.retryDelay,
+		stopping:    func() bool { return atomic.LoadInt32(&al.state) >= stateStopping },
+		sleep:       time.Sleep,
+	}
+	rw.full = rw.fullBudget()
+	rw.budget = rw.full
+	return rw
+}
+
+func (rw *retryWriter) Write(p []byte) (int, error) {
+	written := 0
+	for attempt := 1; ; attempt++ {
+		n, err := rw.w.Write(p[written:])
+		written += min(max(n, 0), len(p)-written)
+		if err == nil {
+			return written, nil
+		}
+		if !rw.wait(attempt) {
+			return written, &RetryError{Attempts: attempt, Err: err}
+		}
+	}
+}
+
+func (rw *retryWriter) WriteEntry(e *Entry) error {
+	for attempt := 1; ; attempt++ {
+		err := rw.ew.WriteEntry(e)
+		if err == nil {
+			return nil
+		}
+		if !rw.wait(attempt) {
+			return &RetryError{Attempts: attempt, Err: err}
+		}
+	}
+}
+
+// Flush flushes the writer if it buffers writes, see flusher.
+func (rw *retryWriter) Flush() error {
+	if f, ok := rw.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// wait waits after the failed attempt and reports whether there should be another one.
+func (rw *retryWriter) wait(attempt int) bool {
+	if attempt >= rw.maxAttempts {
+		return false
+	}
+	d := rw.delay(attempt)
+	d -= time.Duration(rand.Float64() * float64(d) / 2)
+	rw.m.Lock()
+	if rw.stopping() {
+		if d > 0 && rw.budget <= 0 {
+			rw.m.Unlock()
+			return false
+		}
+		d = min(d, rw.budget)
+		rw.budget -= d
+	}
+	rw.m.Unlock()
+	if d > 0 {
+		rw.sleep(d)
+	}
+	return true
+}
+
+// reset gives a restarted logger the whole budget for its next Stop.
+func (rw *retryWriter) reset() {
+	rw.m.Lock()
+	rw.budget = rw.full
+	rw.m.Unlock()
+}
+
+// delay returns the wait after the failed attempt, before jitter.
+func (rw *retryWriter) delay(attempt int) time.Duration {
+	d := rw.baseDelay
+	for i := 1; i < attempt && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryDelay)
+}
+
+// fullBudget returns the longest a single message can wait in all.
+func (rw *retryWriter) fullBudget() time.Duration {
+	var total time.Duration
+	for attempt := 1; attempt < rw.maxAttempts; attempt++ {
+		d := rw.delay(attempt)
+		if d == maxRetryDelay {
+			return total + time.Duration(rw.maxAttempts-attempt)*d
+		}
+		total += d
+	}
+	return total
+}
@@ -0,0 +1,128 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordSleeps replaces the retry waits of alog with recording them.
+func recordSleeps(alog *Alog) func() []time.Duration {
+	var m sync.Mutex
+	var sleeps []time.Duration
+	alog.retry.sleep = func(d time.Duration) {
+		m.Lock()
+		sleeps = append(sleeps, d)
+		m.Unlock()
+	}
+	return func() []time.Duration {
+		m.Lock()
+		defer m.Unlock()
+		return sleeps
+	}
+}
+
+func TestRetry(t *testing.T) {
+	fw := &failFirstWriter{n: 2, b: &lockedBuffer{}}
+	alog := New(fw, WithRetry(3, 10*time.Millisecond), WithBufferSize(10), WithErrorBuffer(10))
+	sleeps := recordSleeps(alog)
+	go alog.Start()
+	alog.Info("one")
+	alog.Info("two")
+	alog.Stop()
+
+	if got := writtenMessages(fw.b); strings.Join(got, ",") != "one,two" {
+		t.Errorf("Got %q", got)
+	}
+	if len(alog.ErrorChannel()) != 0 {
+		t.Errorf("Unexpected error %v", <-alog.ErrorChannel())
+	}
+	got := sleeps()
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 waits, got %v", got)
+	}
+	for i, d := range got {
+		if base := 10 * time.Millisecond << i; d < base/2 || d > base {
+			t.Errorf("Wait %d is %v, want between %v and %v", i, d, base/2, base)
+		}
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	alog := New(errorWriter{b}, WithRetry(3, time.Millisecond), WithErrorBuffer(10))
+	recordSleeps(alog)
+	go alog.Start()
+	alog.Info("lost")
+	alog.Stop()
+
+	var re *RetryError
+	if err := <-alog.ErrorChannel(); !errors.As(err, &re) || re.Attempts != 3 || re.Err.Error() != "error" {
+		t.Errorf("Got %v", err)
+	}
+	if n := strings.Count(b.String(), "lost\n"); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+	if n := atomic.LoadUint64(&alog.written); n != 0 {
+		t.Errorf("Expected nothing to be counted as written, got %d", n)
+	}
+}
+
+// halfWriter writes half of the first write and fails it.
+type halfWriter struct {
+	failed bool
+	b      *lockedBuffer
+}
+
+func (hw *halfWriter) Write(data []byte) (int, error) {
+	if !hw.failed {
+		hw.failed = true
+		n, _ := hw.b.Write(data[:len(data)/2])
+		return n, errors.New("short write")
+	}
+	return hw.b.Write(data)
+}
+
+func TestRetryPartialWrite(t *testing.T) {
+	hw := &halfWriter{b: &lockedBuffer{}}
+	alog := New(hw, WithRetry(2, time.Millisecond))
+	recordSleeps(alog)
+	if n, err := alog.Write("a longer message"); err != nil || n != len(hw.b.String()) {
+		t.Errorf("Got %d, %v", n, err)
+	}
+	if got := writtenMessages(hw.b); len(got) != 1 || got[0] != "a longer message" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestRetryStopBudget(t *testing.T) {
+	alog := New(errorWriter{bytes.NewBuffer(nil)}, WithRetry(4, 10*time.Millisecond), WithBufferSize(10),
+		WithErrorBuffer(10))
+	sleeps := recordSleeps(alog)
+	for i := 0; i < 5; i++ {
+		alog.Info("m")
+	}
+	alog.Stop()
+
+	var total time.Duration
+	for _, d := range sleeps() {
+		total += d
+	}
+	if total != 70*time.Millisecond {
+		t.Errorf("Expected the waits to add up to one message's 70ms, got %v", total)
+	}
+	var attempts []int
+	for len(alog.ErrorChannel()) > 0 {
+		var re *RetryError
+		if err := <-alog.ErrorChannel(); errors.As(err, &re) {
+			attempts = append(attempts, re.Attempts)
+		}
+	}
+	if len(attempts) != 5 || attempts[4] != 1 {
+		t.Errorf("Expected 5 errors, the last after a single attempt, got attempts %v", attempts)
+	}
+}
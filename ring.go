@@ -0,0 +1,149 @@
+package alog
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ring is the queue set up by WithRingBuffer: a fixed number of slots that producers claim and the Start loop
+// empties without taking locks. It's Dmitry Vyukov's bounded queue: every slot has a sequence number that says
+// whether it's waiting for a push or for a pop at a given position, so head and tail only need compare-and-swap.
+// More than one goroutine may pop, which DropOldest relies on to make room.
+type ring struct {
+	head  uint64 // position of the next pop
+	_     [56]byte
+	tail  uint64 // position of the next push
+	_     [56]byte
+	mask  uint64
+	slots []ringSlot
+	wake  chan struct{} // has a value after a push, until the Start loop picks it up
+}
+
+type ringSlot struct {
+	seq uint64
+	e   Entry
+}
+
+// newRing returns a ring with room for at least capacity entries, rounded up to a power of two.
+func newRing(capacity int) *ring {
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+	r := &ring{mask: uint64(size - 1), slots: make([]ringSlot, size), wake: make(chan struct{}, 1)}
+	for i := range r.slots {
+		r.slots[i].seq = uint64(i)
+	}
+	return r
+}
+
+// push adds e at the tail and reports whether there was room for it.
+func (r *ring) push(e Entry) bool {
+	pos := atomic.LoadUint64(&r.tail)
+	for {
+		s := &r.slots[pos&r.mask]
+		switch seq := atomic.LoadUint64(&s.seq); {
+		case seq == pos:
+			if atomic.CompareAndSwapUint64(&r.tail, pos, pos+1) {
+				s.e = e
+				atomic.StoreUint64(&s.seq, pos+1)
+				select {
+				case r.wake <- struct{}{}:
+				default: // the loop has a wake-up pending already
+				}
+				return true
+			}
+			pos = atomic.LoadUint64(&r.tail)
+		case int64(seq-pos) < 0:
+			return false // the slot still holds the entry from a lap ago
+		default:
+			pos = atomic.LoadUint64(&r.tail) // another push took the slot
+		}
+	}
+}
+
+// pop removes the entry at the head, if there is one.
+func (r *ring) pop() (Entry, bool) {
+	pos := atomic.LoadUint64(&r.head)
+	for {
+		s := &r.slots[pos&r.mask]
+		switch seq := atomic.LoadUint64(&s.seq); {
+		case seq == pos+1:
+			if atomic.CompareAndSwapUint64(&r.head, pos, pos+1) {
+				e := s.e
+				s.e = Entry{}
+				atomic.StoreUint64(&s.seq, pos+r.mask+1)
+				return e, true
+			}
+			pos = atomic.LoadUint64(&r.head)
+		case int64(seq-(pos+1)) < 0:
+			return Entry{}, false // empty, or the push to the slot hasn't finished
+		default:
+			pos = atomic.LoadUint64(&r.head) // another pop took the slot
+		}
+	}
+}
+
+// len returns the number of entries in the ring, which may be out of date by the time it returns.
+func (r *ring) len() int {
+	n := int64(atomic.LoadUint64(&r.tail) - atomic.LoadUint64(&r.head))
+	if n < 0 {
+		return 0
+	}
+	return int(n)
+}
+
+// pushRing queues e on the ring, following the logger's OverflowPolicy when it's full. Blocked callers yield to
+// other goroutines until there's room, instead of parking on a channel.
+func (al *Alog) pushRing(e Entry) error {
+	for !al.ring.push(e) {
+		switch al.overflow {
+		case DropNewest:
+			al.overflowed()
+			return ErrDropped
+		case DropOldest:
+			if old, ok := al.ring.pop(); ok {
+				al.overflowed()
+				al.consumed(old.walEnd)
+			}
+		default:
+			if atomic.LoadInt32(&al.state) == stateStopped {
+				return al.late(e)
+			}
+			runtime.Gosched()
+		}
+	}
+	return nil
+}
+
+// ringLen returns the number of entries in the ring, or 0 if WithRingBuffer wasn't used.
+func (al *Alog) ringLen() int {
+	if al.ring == nil {
+		return 0
+	}
+	return al.ring.len()
+}
+
+// ringWake returns the channel that says the ring has entries, or nil if WithRingBuffer wasn't used.
+func (al *Alog) ringWake() <-chan struct{} {
+	if al.ring == nil {
+		return nil
+	}
+	return al.ring.wake
+}
+
+// drainRing writes every entry in the ring, including the ones that are pushed while it's at it.
+func (al *Alog) drainRing(wg *sync.WaitGroup) {
+	if al.ring == nil {
+		return
+	}
+	for {
+		e, ok := al.ring.pop()
+		if !ok {
+			return
+		}
+		al.process(e, wg)
+		al.takePriority(wg)
+	}
+}
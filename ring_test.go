@@ -0,0 +1,132 @@
+package alog
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := newRing(3)
+	if len(r.slots) != 4 {
+		t.Fatalf("Expected the capacity to be rounded up to 4, got %d", len(r.slots))
+	}
+	for lap := 0; lap < 3; lap++ {
+		for i := 0; i < 4; i++ {
+			if !r.push(Entry{Message: strconv.Itoa(i)}) {
+				t.Fatalf("Lap %d: push %d failed", lap, i)
+			}
+		}
+		if r.push(Entry{}) {
+			t.Fatalf("Lap %d: pushed to a full ring", lap)
+		}
+		for i := 0; i < 4; i++ {
+			if e, ok := r.pop(); !ok || e.Message != strconv.Itoa(i) {
+				t.Fatalf("Lap %d: pop %d got %q, %v", lap, i, e.Message, ok)
+			}
+		}
+		if _, ok := r.pop(); ok {
+			t.Fatalf("Lap %d: popped from an empty ring", lap)
+		}
+	}
+}
+
+func TestRingBufferProducers(t *testing.T) {
+	const producers, perProducer = 32, 1000
+	lb := &lockedBuffer{}
+	alog := New(lb, WithRingBuffer(256))
+	go alog.Start()
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				alog.Info(strconv.Itoa(p) + " " + strconv.Itoa(i))
+			}
+		}(p)
+	}
+	wg.Wait()
+	alog.Stop()
+
+	msgs := writtenMessages(lb)
+	if len(msgs) != producers*perProducer {
+		t.Fatalf("Expected %d messages, got %d", producers*perProducer, len(msgs))
+	}
+	next := make([]int, producers)
+	for _, msg := range msgs {
+		p, i, _ := strings.Cut(msg, " ")
+		pn, _ := strconv.Atoi(p)
+		if in, _ := strconv.Atoi(i); in != next[pn] {
+			t.Fatalf("Producer %s: got message %d, expected %d", p, in, next[pn])
+		}
+		next[pn]++
+	}
+}
+
+func TestRingBufferOverflow(t *testing.T) {
+	for _, tt := range []struct {
+		policy OverflowPolicy
+		want   []string
+	}{
+		{DropNewest, []string{"m0", "m1", "m2", "m3", "m4"}},
+		{DropOldest, []string{"m0", "m2", "m3", "m4", "m5"}},
+	} {
+		gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+		alog := New(gw, WithRingBuffer(4), WithOverflowPolicy(tt.policy, 0))
+		go alog.Start()
+		errs := fillQueue(t, alog)
+		close(gw.open)
+		alog.Stop()
+
+		if tt.policy == DropNewest && errs[4] != ErrDropped {
+			t.Errorf("DropNewest: expected m5 to be dropped, got %v", errs[4])
+		}
+		if got := writtenMessages(gw.b); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Policy %d: got %q, want %q", tt.policy, got, tt.want)
+		}
+		if alog.Dropped() != 1 {
+			t.Errorf("Policy %d: expected 1 dropped message, got %d", tt.policy, alog.Dropped())
+		}
+	}
+}
+
+func TestRingBufferFlush(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithRingBuffer(1024), WithBatching(64, 0))
+	go alog.Start()
+	defer alog.Stop()
+	for i := 0; i < 500; i++ {
+		alog.Info("m" + strconv.Itoa(i))
+	}
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := writtenMessages(lb); len(msgs) != 500 || msgs[499] != "m499" {
+		t.Errorf("Flush returned before the ring was written, got %d messages", len(msgs))
+	}
+}
+
+func BenchmarkQueue(b *testing.B) {
+	for _, queue := range []string{"channel", "ring"} {
+		b.Run(queue, func(b *testing.B) {
+			opts := []Option{WithBufferSize(4096)}
+			if queue == "ring" {
+				opts = append(opts, WithRingBuffer(4096))
+			}
+			alog := New(io.Discard, opts...)
+			go alog.Start()
+			b.ReportAllocs()
+			b.SetParallelism(8)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					alog.Info("a message of a typical length for a log")
+				}
+			})
+			alog.Stop()
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package alog
+
+import "io"
+
+// levelWriter is a destination added with WithLevelWriter.
+type levelWriter struct {
+	min Level
+	w   io.Writer
+}
+
+// span marks where a message that was formatted into a formatBuffer ends, and its level, so the level writers
+// can be given just the messages they want. The message's text is kept for WithDeadLetters.
+type span struct {
+	end     int
+	level   Level
+	message string
+}
+
+// writeFormatted writes the messages formatted into fb to dest and to the level writers they qualify for. Level
+// writers get the qualifying messages that follow each other in a single call.
+func (al *Alog) writeFormatted(fb *formatBuffer) (int, error) {
+	n, err := al.dest.Write(fb.buf)
+	if len(al.levelWriters) == 0 {
+		return n, err
+	}
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for i, lw := range al.levelWriters {
+		if err := lw.writeSpans(fb.buf, fb.spans); err != nil {
+			errs = append(errs, &DestinationError{Dest: 1 + len(al.extraDests) + i, Writer: lw.w, Err: err})
+		}
+	}
+	return n, joinErrors(errs)
+}
+
+// writeSpans writes the messages in buf that are at lw's level or above, stopping at the first error.
+func (lw levelWriter) writeSpans(buf []byte, spans []span) error {
+	start, run := 0, -1 // run is where the current run of qualifying messages starts
+	for _, s := range spans {
+		if s.level >= lw.min {
+			if run < 0 {
+				run = start
+			}
+		} else if run >= 0 {
+			if _, err := lw.w.Write(buf[run:start]); err != nil {
+				return err
+			}
+			run = -1
+		}
+		start = s.end
+	}
+	if run >= 0 {
+		_, err := lw.w.Write(buf[run:start])
+		return err
+	}
+	return nil
+}
+
+// flushDests flushes dest and the level writers that buffer writes, see flusher, and the sinks. The caller holds
+// the mutex.
+func (al *Alog) flushDests() error {
+	var errs []error
+	if f, ok := al.entryDest.(flusher); ok {
+		if err := f.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if f, ok := al.dest.(flusher); ok {
+		if err := f.Flush(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i, lw := range al.levelWriters {
+		if f, ok := lw.w.(flusher); ok {
+			if err := f.Flush(); err != nil {
+				errs = append(errs, &DestinationError{Dest: 1 + len(al.extraDests) + i, Writer: lw.w, Err: err})
+			}
+		}
+	}
+	errs = append(errs, al.flushSinks())
+	return joinErrors(errs)
+}
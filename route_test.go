@@ -0,0 +1,71 @@
+package alog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLevelWriter(t *testing.T) {
+	all, errs := &lockedBuffer{}, &lockedBuffer{}
+	alog := New(all, WithLevelWriter(LevelWarn, errs), WithBufferSize(10))
+	go alog.Start()
+	alog.Info("info")
+	alog.Error("error")
+	alog.Debug("hidden")
+	alog.Warn("warn")
+	alog.Stop()
+
+	if got := writtenMessages(all); strings.Join(got, ",") != "info,error,warn" {
+		t.Errorf("Main destination got %q", got)
+	}
+	if got := writtenMessages(errs); strings.Join(got, ",") != "error,warn" {
+		t.Errorf("Level writer got %q", got)
+	}
+}
+
+func TestLevelWriterBatch(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	cc := &callCounter{w: &lockedBuffer{}}
+	alog := New(gw, WithLevelWriter(LevelWarn, cc), WithBufferSize(10), WithBatching(10, 0))
+	go alog.Start()
+	alog.Info("m0")
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	alog.Warn("w1")
+	alog.Error("e2")
+	alog.Info("i3")
+	alog.Warn("w4")
+	close(gw.open)
+	alog.Stop()
+
+	if got := writtenMessages(cc.w.(*lockedBuffer)); strings.Join(got, ",") != "w1,e2,w4" {
+		t.Errorf("Level writer got %q", got)
+	}
+	if calls := atomic.LoadInt64(&cc.calls); calls != 2 {
+		t.Errorf("Expected w1 and e2 in one write and w4 in another, got %d writes", calls)
+	}
+}
+
+func TestLevelWriterError(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	fw := &flakyWriter{fail: 1, b: &lockedBuffer{}}
+	alog := New(b, WithAdditionalWriter(bytes.NewBuffer([]byte{})), WithLevelWriter(LevelError, fw))
+	if _, err := alog.Write("info"); err != nil {
+		t.Errorf("Info message was written to the error writer: %v", err)
+	}
+	go alog.Start()
+	defer alog.Stop()
+	alog.Error("error")
+	var de *DestinationError
+	if err := <-alog.ErrorChannel(); !errors.As(err, &de) || de.Dest != 2 || de.Writer != fw {
+		t.Errorf("Expected an error from destination 2, got %v", err)
+	}
+	if !strings.HasSuffix(b.String(), "- error\n") {
+		t.Errorf("Main destination didn't get the message, got %q", b.String())
+	}
+}
@@ -0,0 +1,90 @@
+package alog
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// WithSampling keeps only every nth message below the sampling level, see WithSamplingLevel: the first, the
+// (n+1)th and so on, counted across every goroutine that logs. The rest are dropped before they're formatted and
+// counted by SampledOut. It applies to the level methods and slog.Handlers, not to Write or MessageChannel, and n of
+// 1 or less keeps everything.
+func WithSampling(n int) Option {
+	return func(al *Alog) {
+		if n > 1 {
+			al.sampler.every = uint64(n)
+		}
+	}
+}
+
+// WithSamplingRate keeps each message below the sampling level, see WithSamplingLevel, with probability p, like
+// WithSampling keeps every nth. A p of 1 or more keeps everything.
+func WithSamplingRate(p float64) Option {
+	return func(al *Alog) {
+		al.sampler.rate = p
+	}
+}
+
+// WithSamplingLevel sets the level at and above which messages aren't sampled by WithSampling or WithSamplingRate.
+// The default is LevelError, so errors are always kept.
+func WithSamplingLevel(l Level) Option {
+	return func(al *Alog) {
+		al.sampler.level = l
+	}
+}
+
+// SampledOut returns the number of messages dropped by WithSampling or WithSamplingRate.
+func (al *Alog) SampledOut() uint64 {
+	return atomic.LoadUint64(&al.sampler.out)
+}
+
+// Sample returns a middleware that keeps only every nth message below level, like WithSampling and
+// WithSamplingLevel, for ordering it with other middlewares, see Use. Unlike WithSampling it applies to every
+// message, including those logged with Write, and the messages it drops aren't counted by SampledOut.
+func Sample(n int, level Level) Middleware {
+	s := newSampler()
+	s.level = level
+	if n > 1 {
+		s.every = uint64(n)
+	}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(e *Entry) error {
+			if s.sampleOut(e.Level) {
+				return nil
+			}
+			return next(e)
+		}
+	}
+}
+
+// sampler holds the settings and counts of WithSampling and WithSamplingRate, or of a Sample middleware.
+type sampler struct {
+	count  uint64 // messages seen by WithSampling, accessed atomically
+	out    uint64 // messages dropped, accessed atomically
+	every  uint64
+	rate   float64
+	level  Level
+	random func() float64
+}
+
+func newSampler() *sampler {
+	return &sampler{rate: 1, level: LevelError, random: rand.Float64}
+}
+
+// sampleOut reports whether a message at level l should be dropped by sampling, and counts it if so.
+func (s *sampler) sampleOut(l Level) bool {
+	if l >= s.level {
+		return false
+	}
+	out := false
+	if s.every > 1 {
+		out = (atomic.AddUint64(&s.count, 1)-1)%s.every != 0
+	}
+	if !out && s.rate < 1 {
+		out = s.random() >= s.rate
+	}
+	if out {
+		atomic.AddUint64(&s.out, 1)
+	}
+	return out
+}
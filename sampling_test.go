@@ -0,0 +1,63 @@
+package alog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	d := &Discard{}
+	alog := New(d, WithSampling(10), WithLevel(LevelDebug), WithBufferSize(100))
+	go alog.Start()
+	for i := 0; i < 1000; i++ {
+		alog.Debug("chatty")
+	}
+	alog.Error("kept")
+	alog.Stop()
+	if n := d.Count(); n != 101 {
+		t.Errorf("Expected 100 sampled messages and the error, got %d", n)
+	}
+	if n := alog.SampledOut(); n != 900 {
+		t.Errorf("Expected 900 messages to be sampled out, got %d", n)
+	}
+}
+
+func TestSamplingRate(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithSamplingRate(0.5), WithSamplingLevel(LevelWarn), WithBufferSize(100))
+	draws := []float64{0.1, 0.7, 0.49, 0.5}
+	alog.sampler.random = func() float64 {
+		p := draws[0]
+		draws = draws[1:]
+		return p
+	}
+	go alog.Start()
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		alog.Infof("%s", msg)
+	}
+	alog.Warn("warning")
+	alog.Stop()
+	if got := writtenMessages(lb); strings.Join(got, ",") != "one,three,warning" {
+		t.Errorf("Got %q", got)
+	}
+	if n := alog.SampledOut(); n != 2 {
+		t.Errorf("Expected 2 messages to be sampled out, got %d", n)
+	}
+}
+
+func TestSamplingSkipsFormatting(t *testing.T) {
+	alog := New(&Discard{}, WithSampling(2))
+	formatted := 0
+	arg := stringerFunc(func() string { formatted++; return "" })
+	alog.TryInfof("%v", arg)
+	alog.TryInfof("%v", arg)
+	if formatted != 1 {
+		t.Errorf("Expected only the kept message to be formatted, got %d", formatted)
+	}
+}
+
+type stringerFunc func() string
+
+func (f stringerFunc) String() string {
+	return f()
+}
@@ -0,0 +1,42 @@
+package alog
+
+import (
+	"os"
+	"os/signal"
+)
+
+// StopOnSignal stops the logger when the process receives one of sigs, or os.Interrupt if none are given, and
+// returns a channel that's closed once the logger has stopped, so main can wait for the pending messages to be
+// written before it exits:
+//
+//	<-al.StopOnSignal(syscall.SIGINT, syscall.SIGTERM)
+//
+// The signals are only watched until the first one arrives or the logger is stopped some other way; after that
+// they're no longer delivered to StopOnSignal, but signal.Notify channels registered by the application keep
+// receiving them. If Stop is called first the channel is closed when it returns.
+func (al *Alog) StopOnSignal(sigs ...os.Signal) <-chan struct{} {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt}
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, sigs...)
+	return al.stopOn(sigCh, func() { signal.Stop(sigCh) })
+}
+
+// stopOn stops the logger when a value is received on sigCh and closes the returned channel once it has stopped.
+// unregister is called as soon as either happens.
+func (al *Alog) stopOn(sigCh <-chan os.Signal, unregister func()) <-chan struct{} {
+	_, shutdownCompleteCh := al.runChannels()
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		select {
+		case <-sigCh:
+			unregister()
+			al.Stop()
+		case <-shutdownCompleteCh:
+			unregister()
+		}
+	}()
+	return doneCh
+}
@@ -0,0 +1,76 @@
+package alog
+
+import (
+	"bytes"
+	"os"
+	"os/signal"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStopOnSignal(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(100))
+	go alog.Start()
+	sigCh := make(chan os.Signal, 1)
+	unregistered := make(chan struct{})
+	doneCh := alog.stopOn(sigCh, func() { close(unregistered) })
+	for i := 0; i < 100; i++ {
+		alog.Info("message")
+	}
+	sigCh <- os.Interrupt
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("Logger wasn't stopped by the signal")
+	}
+	if lines := strings.Count(b.String(), "\n"); lines != 100 {
+		t.Errorf("Expected 100 lines to be written before the channel was closed, got %d", lines)
+	}
+	select {
+	case <-unregistered:
+	default:
+		t.Error("Signal handler wasn't unregistered")
+	}
+}
+
+func TestStopOnSignalAfterStop(t *testing.T) {
+	alog := New(bytes.NewBuffer([]byte{}))
+	go alog.Start()
+	unregistered := make(chan struct{})
+	doneCh := alog.stopOn(make(chan os.Signal), func() { close(unregistered) })
+	alog.Stop()
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("Channel wasn't closed after Stop")
+	}
+	<-unregistered
+}
+
+func TestStopOnSignalSharesSignal(t *testing.T) {
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	appCh := make(chan os.Signal, 1)
+	signal.Notify(appCh, os.Interrupt)
+	defer signal.Stop(appCh)
+	alog := New(bytes.NewBuffer([]byte{}))
+	go alog.Start()
+	doneCh := alog.StopOnSignal(os.Interrupt)
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("Can't send os.Interrupt: %v", err)
+	}
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("Logger wasn't stopped by the signal")
+	}
+	select {
+	case <-appCh:
+	case <-time.After(time.Second):
+		t.Error("Application didn't receive the signal")
+	}
+}
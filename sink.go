@@ -0,0 +1,105 @@
+package alog
+
+import "io"
+
+// Sink is a destination that gets the logger's entries rather than formatted lines and has a lifecycle: a logger
+// that writes to it flushes it when the logger is flushed, and flushes and closes it, once, when the logger is
+// stopped. Sinks are added with WithSink, and NewWriterSink turns an io.Writer into one with a formatter of its own.
+// The entry passed to WriteEntry must not be retained, and WriteEntry may be called concurrently if WithWorkers is
+// used.
+type Sink interface {
+	EntryWriter
+	Flush() error
+	Close() error
+}
+
+// WithSink adds a sink that every message is written to, after the writer passed to New and the other
+// destinations. Its errors are sent on the error channel as a *DestinationError; sinks are numbered after the level
+// writers, in the order they were added. A restarted logger keeps writing to its sinks, so only sinks that can be
+// used again after Close should be used with Restart.
+func WithSink(s Sink) Option {
+	return func(al *Alog) {
+		al.sinks = append(al.sinks, s)
+	}
+}
+
+// NewWriterSink returns a Sink that writes entries to w formatted by f, or by a TextFormatter with the default
+// timestamp layout if f is nil. Flush and Close are passed on to w if it has those methods.
+func NewWriterSink(w io.Writer, f Formatter) Sink {
+	if f == nil {
+		f = TextFormatter{Layout: defaultTimestampFormat}
+	}
+	return &writerSink{w: w, f: f}
+}
+
+type writerSink struct {
+	w io.Writer
+	f Formatter
+}
+
+func (ws *writerSink) WriteEntry(e *Entry) error {
+	fb := getFormatBuffer()
+	defer putFormatBuffer(fb)
+	fb.buf = ws.f.Format(fb.buf, e)
+	_, err := ws.w.Write(fb.buf)
+	return err
+}
+
+func (ws *writerSink) Flush() error {
+	if f, ok := ws.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (ws *writerSink) Close() error {
+	if c, ok := ws.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// sinkError attributes err to the i-th sink.
+func (al *Alog) sinkError(i int, err error) error {
+	return &DestinationError{Dest: 1 + len(al.extraDests) + len(al.levelWriters) + i, Sink: al.sinks[i], Err: err}
+}
+
+// writeSinks writes e to every sink. The caller holds the mutex if there's a single writer.
+func (al *Alog) writeSinks(e *Entry) error {
+	var errs []error
+	for i, s := range al.sinks {
+		if err := s.WriteEntry(e); err != nil {
+			errs = append(errs, al.sinkError(i, err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+// flushSinks flushes every sink. The caller holds the mutex.
+func (al *Alog) flushSinks() error {
+	var errs []error
+	for i, s := range al.sinks {
+		if err := s.Flush(); err != nil {
+			errs = append(errs, al.sinkError(i, err))
+		}
+	}
+	return joinErrors(errs)
+}
+
+// closeSinks flushes and closes every sink and reports the errors.
+func (al *Alog) closeSinks() {
+	if len(al.sinks) == 0 {
+		return
+	}
+	al.m.Lock()
+	errs := []error{al.flushSinks()}
+	for i, s := range al.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, al.sinkError(i, err))
+		}
+	}
+	al.m.Unlock()
+	if err := joinErrors(errs); err != nil {
+		al.reportError(err)
+	}
+}
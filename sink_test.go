@@ -0,0 +1,104 @@
+package alog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingSink records the calls made to it.
+type recordingSink struct {
+	m     sync.Mutex
+	calls []string
+	err   error // returned by WriteEntry
+}
+
+func (rs *recordingSink) record(call string) {
+	rs.m.Lock()
+	defer rs.m.Unlock()
+	rs.calls = append(rs.calls, call)
+}
+
+func (rs *recordingSink) WriteEntry(e *Entry) error {
+	rs.record("write:" + e.Message)
+	return rs.err
+}
+
+func (rs *recordingSink) Flush() error {
+	rs.record("flush")
+	return nil
+}
+
+func (rs *recordingSink) Close() error {
+	rs.record("close")
+	return nil
+}
+
+func (rs *recordingSink) sequence() string {
+	rs.m.Lock()
+	defer rs.m.Unlock()
+	return strings.Join(rs.calls, ",")
+}
+
+func TestSinkLifecycle(t *testing.T) {
+	b := &lockedBuffer{}
+	rs := &recordingSink{}
+	alog := New(b, WithSink(rs), WithBufferSize(10))
+	go alog.Start()
+	alog.Info("one")
+	alog.Warn("two")
+	alog.Stop()
+	alog.Stop()
+
+	if got, want := rs.sequence(), "write:one,write:two,flush,close"; got != want {
+		t.Errorf("Got calls %q, want %q", got, want)
+	}
+	if got := writtenMessages(b); strings.Join(got, ",") != "one,two" {
+		t.Errorf("Expected the writer passed to New to get every line, got %q", got)
+	}
+}
+
+func TestSinkBatching(t *testing.T) {
+	rs := &recordingSink{}
+	alog := New(bytes.NewBuffer([]byte{}), WithSink(rs), WithBatching(10, 0), WithBufferSize(10))
+	alog.Info("one")
+	alog.Info("two")
+	go alog.Start()
+	alog.Stop()
+
+	if got, want := rs.sequence(), "write:one,write:two,flush,close"; got != want {
+		t.Errorf("Got calls %q, want %q", got, want)
+	}
+}
+
+func TestWriterSinkFormatter(t *testing.T) {
+	text, js := bytes.NewBuffer([]byte{}), bytes.NewBuffer([]byte{})
+	alog := New(text, WithSink(NewWriterSink(js, JSONFormatter{})))
+	if _, err := alog.Write("hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(text.String(), "- hello\n") {
+		t.Errorf("Expected a text line, got %q", text)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(js.Bytes(), &m); err != nil || m["msg"] != "hello" {
+		t.Errorf("Expected a JSON line from the sink, got %q (%v)", js, err)
+	}
+}
+
+func TestSinkError(t *testing.T) {
+	rs := &recordingSink{err: errors.New("rejected")}
+	alog := New(bytes.NewBuffer([]byte{}), WithAdditionalWriter(bytes.NewBuffer([]byte{})), WithSink(rs))
+	_, err := alog.Write("sync")
+	var de *DestinationError
+	if !errors.As(err, &de) || de.Dest != 2 || de.Sink != rs {
+		t.Fatalf("Expected an error from destination 2, got %v", err)
+	}
+	if err.Error() != "alog: destination 2: rejected" {
+		t.Errorf("Got %q", err)
+	}
+}
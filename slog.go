@@ -0,0 +1,153 @@
+package alog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+)
+
+// NewSlogHandler returns a slog.Handler that logs through al, so the logger can be used with slog.New:
+//
+//	logger := slog.New(alog.NewSlogHandler(al, nil))
+//
+// slog levels map directly onto Alog's levels and records are filtered by both al's level and opts.Level, if set.
+// Attributes are rendered by al's formatter, with groups nested as described on Field. Handle queues the record for
+// the Start loop like the level methods do, so it never waits for the destination, and Stop writes records that were
+// already handled before it returns. Handle returns the error the level methods would, such as ErrDropped when the
+// queue is full. Records without a time are written without a timestamp.
+//
+// opts may be nil. If opts.AddSource is set the caller's file and line are attached as a "source" field, and
+// opts.ReplaceAttr is called for every attribute except the built-in time, level and message.
+func NewSlogHandler(al *Alog, opts *slog.HandlerOptions) slog.Handler {
+	h := &slogHandler{al: al}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+type slogHandler struct {
+	al   *Alog
+	opts slog.HandlerOptions
+	goas []groupOrFields // set by WithGroup and WithAttrs, outermost first
+}
+
+// groupOrFields is either a group opened with WithGroup or the already converted attributes from WithAttrs.
+type groupOrFields struct {
+	group  string
+	fields []Field
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(_ context.Context, l slog.Level) bool {
+	if h.opts.Level != nil && l < h.opts.Level.Level() {
+		return false
+	}
+	return h.al.enabled(Level(l))
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	if h.al.sampler.sampleOut(Level(r.Level)) {
+		return nil
+	}
+	e := h.al.newEntry(Level(r.Level), r.Message)
+	e.Time = r.Time
+	e.noTime = r.Time.IsZero()
+
+	groups := h.groups()
+	var fields []Field
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		fields = h.appendAttr(fields, nil, slog.String(slog.SourceKey, frame.File+":"+strconv.Itoa(frame.Line)))
+	}
+	var current []Field
+	r.Attrs(func(a slog.Attr) bool {
+		current = h.appendAttr(current, groups, a)
+		return true
+	})
+	// Wrap the record's attributes in the open groups from the innermost outwards, leaving out empty groups.
+	for i := len(h.goas) - 1; i >= 0; i-- {
+		goa := h.goas[i]
+		if goa.group == "" {
+			current = append(goa.fields[:len(goa.fields):len(goa.fields)], current...)
+		} else if len(current) > 0 {
+			current = []Field{{Key: goa.group, Value: current}}
+		}
+	}
+	fields = append(fields, current...)
+	if len(fields) > 0 {
+		e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], fields...)
+	}
+	return h.al.enqueue(e)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	groups := h.groups()
+	var fields []Field
+	for _, a := range attrs {
+		fields = h.appendAttr(fields, groups, a)
+	}
+	return h.with(groupOrFields{fields: fields})
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrFields{group: name})
+}
+
+func (h *slogHandler) with(goa groupOrFields) *slogHandler {
+	h2 := *h
+	h2.goas = append(h.goas[:len(h.goas):len(h.goas)], goa)
+	return &h2
+}
+
+// groups returns the names of the groups opened with WithGroup, outermost first.
+func (h *slogHandler) groups() []string {
+	var groups []string
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			groups = append(groups, goa.group)
+		}
+	}
+	return groups
+}
+
+// appendAttr converts a to a Field and appends it to fields. Empty attributes and empty groups are left out, and the
+// attributes of a group without a key are inlined.
+func (h *slogHandler) appendAttr(fields []Field, groups []string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return append(fields, Field{Key: a.Key, Value: a.Value.Any()})
+	}
+	attrs := a.Value.Group()
+	if a.Key == "" {
+		for _, ga := range attrs {
+			fields = h.appendAttr(fields, groups, ga)
+		}
+		return fields
+	}
+	var sub []Field
+	for _, ga := range attrs {
+		sub = h.appendAttr(sub, append(groups[:len(groups):len(groups)], a.Key), ga)
+	}
+	if len(sub) == 0 {
+		return fields
+	}
+	return append(fields, Field{Key: a.Key, Value: sub})
+}
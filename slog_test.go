@@ -0,0 +1,64 @@
+package alog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat(), WithBufferSize(10))
+	go alog.Start()
+	err := slogtest.TestHandler(NewSlogHandler(alog, nil), func() []map[string]any {
+		alog.Stop()
+		return decodeJSONLines(t, b.String())
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlogHandlerText(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	alog.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Now
+	go alog.Start()
+	logger := slog.New(NewSlogHandler(alog, nil)).With("app", "demo").WithGroup("req")
+	logger.Warn("slow request", "id", 7, slog.Group("db", "ms", 250))
+	logger.Debug("hidden")
+	alog.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 line, got %q", b.String())
+	}
+	if want := "[WARN] - slow request app=demo req.id=7 req.db.ms=250"; !strings.HasSuffix(lines[0], want) {
+		t.Errorf("Got %q, want it to end with %q", lines[0], want)
+	}
+}
+
+func TestSlogHandlerErrors(t *testing.T) {
+	alog := New(&lockedBuffer{})
+	alog.Stop()
+	err := NewSlogHandler(alog, nil).Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "late", 0))
+	if err != ErrLoggerStopped {
+		t.Errorf("Expected ErrLoggerStopped from a stopped logger, got %v", err)
+	}
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	alog := New(nil, WithLevel(LevelWarn))
+	h := NewSlogHandler(alog, nil)
+	if h.Enabled(context.Background(), slog.LevelInfo) || !h.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("Handler doesn't follow the logger's level")
+	}
+	h = NewSlogHandler(alog, &slog.HandlerOptions{Level: slog.LevelError})
+	if h.Enabled(context.Background(), slog.LevelWarn) || !h.Enabled(context.Background(), slog.LevelError) {
+		t.Error("Handler doesn't follow HandlerOptions.Level")
+	}
+}
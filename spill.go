@@ -0,0 +1,319 @@
+package alog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSpillBytes = 64 << 20
+	spillChunk        = 64 << 10 // how much of the spill file the Start loop reads back at a time
+)
+
+// WithSpill sets where the Spill overflow policy, see WithOverflowPolicy, keeps the messages that don't fit in the
+// queue: a temporary file in dir, or in os.TempDir() if dir is empty, of at most maxBytes, or 64MB if maxBytes is 0
+// or less. Once a message is spilled, the ones logged after it are spilled too until the Start loop has written
+// the file out, which it does after the messages that were queued before it, so messages are written in the order
+// they were logged. A message that doesn't fit in the file is dropped and counted by Dropped, and so is a message
+// that can't be written to it, with the error sent on the error channel. Spilled messages keep the values of their
+// fields as JSON, or as text for errors and fmt.Stringers. Flush and Stop write the spilled messages out too, and
+// Stop removes the file.
+func WithSpill(dir string, maxBytes int64) Option {
+	return func(al *Alog) {
+		al.spillDir = dir
+		al.spillMaxBytes = maxBytes
+	}
+}
+
+// spillFile holds the messages spilled by the Spill overflow policy.
+type spillFile struct {
+	pending int64 // spilled messages that haven't been read back, accessed atomically
+	dir     string
+	max     int64
+	wake    chan struct{} // has a value when there are messages to read back, like ring.wake
+
+	m        sync.Mutex
+	f        *os.File
+	spilling bool  // logged messages are spilled until the file has been read back
+	size     int64 // of f
+	read     int64 // the part of f that has been read back
+}
+
+func newSpillFile(dir string, max int64) *spillFile {
+	if max <= 0 {
+		max = defaultSpillBytes
+	}
+	return &spillFile{dir: dir, max: max, wake: make(chan struct{}, 1)}
+}
+
+// spillWake returns the channel that says there are spilled messages, or nil if the Spill policy isn't used.
+func (al *Alog) spillWake() <-chan struct{} {
+	if al.spill == nil {
+		return nil
+	}
+	return al.spill.wake
+}
+
+// spillLen returns the number of spilled messages that haven't been read back.
+func (al *Alog) spillLen() int {
+	if al.spill == nil {
+		return 0
+	}
+	return int(atomic.LoadInt64(&al.spill.pending))
+}
+
+// spillOrQueue queues e, or spills it if the queue is full or messages are already spilled.
+func (al *Alog) spillOrQueue(e Entry) error {
+	err, spillErr := al.spillEntry(e)
+	if spillErr != nil {
+		al.reportError(spillErr) // without holding spill.m, in case the error handler logs
+	}
+	return err
+}
+
+// spillEntry does the work of spillOrQueue, also returning the error to report if e couldn't be spilled.
+func (al *Alog) spillEntry(e Entry) (err, spillErr error) {
+	s := al.spill
+	s.m.Lock()
+	defer s.m.Unlock()
+	if atomic.LoadInt32(&al.state) >= stateStopping {
+		// Stop may already have read the file back for the last time.
+		return al.late(e), nil
+	}
+	if !s.spilling {
+		select {
+		case al.entryCh <- e:
+			return nil, nil
+		default:
+		}
+	}
+	line := encodeSpilled(&e)
+	if s.size+int64(len(line)) > s.max {
+		al.overflowed()
+		return ErrDropped, nil
+	}
+	if s.f == nil {
+		f, err := os.CreateTemp(s.dir, "alog-spill-*")
+		if err != nil {
+			al.overflowed()
+			return ErrDropped, fmt.Errorf("alog: spilling message: %w", err)
+		}
+		s.f = f
+	}
+	if _, err := s.f.WriteAt(line, s.size); err != nil {
+		al.overflowed()
+		return ErrDropped, fmt.Errorf("alog: spilling message: %w", err)
+	}
+	s.size += int64(len(line))
+	s.spilling = true
+	atomic.AddInt64(&s.pending, 1)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil, nil
+}
+
+// replaySpill writes the entries that were queued before the spilled messages, and then a chunk of the spilled
+// messages.
+func (al *Alog) replaySpill(wg *sync.WaitGroup) {
+	// Nothing but flushes is queued while messages are spilled, so this ends.
+	for len(al.entryCh) > 0 {
+		al.handleEntry(<-al.entryCh, wg)
+	}
+	for _, e := range al.readSpilled() {
+		al.process(e, wg)
+		al.takePriority(wg)
+	}
+}
+
+// drainSpill writes every spilled message.
+func (al *Alog) drainSpill(wg *sync.WaitGroup) {
+	for al.spillLen() > 0 {
+		al.replaySpill(wg)
+	}
+}
+
+// readSpilled reads the next chunk of spilled messages back. Once all of them have been read the file is emptied
+// and messages are queued again.
+func (al *Alog) readSpilled() []Entry {
+	entries, errs := al.readSpilledChunk()
+	for _, err := range errs {
+		al.reportError(err)
+	}
+	return entries
+}
+
+// readSpilledChunk does the work of readSpilled, returning the errors to report.
+func (al *Alog) readSpilledChunk() ([]Entry, []error) {
+	s := al.spill
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.f == nil || s.read == s.size {
+		return nil, nil
+	}
+	buf := make([]byte, min(s.size-s.read, spillChunk))
+	_, err := s.f.ReadAt(buf, s.read)
+	end := bytes.LastIndexByte(buf, '\n') + 1
+	if err == nil && end == 0 { // a message longer than the chunk
+		buf = make([]byte, s.size-s.read)
+		_, err = s.f.ReadAt(buf, s.read)
+		end = bytes.LastIndexByte(buf, '\n') + 1
+	}
+	if err != nil {
+		lost := atomic.SwapInt64(&s.pending, 0)
+		atomic.AddUint64(&al.dropped, uint64(lost))
+		s.reset()
+		return nil, []error{fmt.Errorf("alog: reading spilled messages, %d lost: %w", lost, err)}
+	}
+	var entries []Entry
+	var errs []error
+	for _, line := range bytes.SplitAfter(buf[:end], []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		atomic.AddInt64(&s.pending, -1)
+		e, err := decodeSpilled(line)
+		if err != nil {
+			atomic.AddUint64(&al.dropped, 1)
+			errs = append(errs, fmt.Errorf("alog: reading spilled message: %w", err))
+			continue
+		}
+		entries = append(entries, e)
+	}
+	s.read += int64(end)
+	if s.read == s.size {
+		s.reset()
+	} else {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return entries, errs
+}
+
+// reset empties the file, so messages are queued again. The caller holds m.
+func (s *spillFile) reset() {
+	if s.f != nil {
+		_ = s.f.Truncate(0) // the file is written at offsets, so a failure only wastes space
+	}
+	s.size, s.read, s.spilling = 0, 0, false
+}
+
+// closeSpill removes the spill file when the logger is stopped.
+func (al *Alog) closeSpill() {
+	if al.spill == nil {
+		return
+	}
+	s := al.spill
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.f != nil {
+		s.f.Close()
+		os.Remove(s.f.Name())
+		s.f = nil
+	}
+	s.reset()
+}
+
+// spilledEntry is how an entry is kept in the spill file, one JSON object per line.
+type spilledEntry struct {
+	Time     time.Time      `json:"t"`
+	NoTime   bool           `json:"nt,omitempty"`
+	Level    Level          `json:"l"`
+	Message  string         `json:"m"`
+	Prefix   string         `json:"p,omitempty"`
+	Name     string         `json:"n,omitempty"`
+	Caller   string         `json:"c,omitempty"`
+	Fields   []spilledField `json:"f,omitempty"`
+	Stack    string         `json:"s,omitempty"`
+	Seq      uint64         `json:"q,omitempty"`
+	Implicit bool           `json:"i,omitempty"`
+	WALEnd   int64          `json:"w,omitempty"`
+}
+
+type spilledField struct {
+	Key   string          `json:"k"`
+	Value json.RawMessage `json:"v,omitempty"`
+	Group []spilledField  `json:"g,omitempty"`
+}
+
+func encodeSpilled(e *Entry) []byte {
+	line, _ := json.Marshal(spilledEntry{
+		Time: e.Time, NoTime: e.noTime, Level: e.Level, Message: e.text(), Prefix: e.Prefix, Name: e.Name,
+		Caller: e.Caller, Fields: spilledFields(e.Fields), Stack: e.Stack, Seq: e.Seq, Implicit: e.implicit,
+		WALEnd: e.walEnd,
+	}) // can't fail, every field value is valid JSON
+	return append(line, '\n')
+}
+
+func spilledFields(fields []Field) []spilledField {
+	if len(fields) == 0 {
+		return nil
+	}
+	sfs := make([]spilledField, len(fields))
+	for i, f := range fields {
+		sfs[i].Key = f.Key
+		if group, ok := f.Value.([]Field); ok {
+			sfs[i].Group = spilledFields(group)
+			continue
+		}
+		v := f.Value
+		switch x := v.(type) {
+		case error:
+			v = x.Error()
+		case fmt.Stringer:
+			v = x.String()
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			raw, _ = json.Marshal(fmt.Sprint(v))
+		}
+		sfs[i].Value = raw
+	}
+	return sfs
+}
+
+func decodeSpilled(line []byte) (Entry, error) {
+	var se spilledEntry
+	if err := json.Unmarshal(line, &se); err != nil {
+		return Entry{}, err
+	}
+	fields, err := unspilledFields(se.Fields)
+	e := Entry{
+		Time: se.Time, Level: se.Level, Message: se.Message, Prefix: se.Prefix, Name: se.Name, Caller: se.Caller,
+		Fields: fields, Stack: se.Stack, Seq: se.Seq, implicit: se.Implicit, noTime: se.NoTime,
+		walEnd: se.WALEnd,
+	}
+	return e, err
+}
+
+func unspilledFields(sfs []spilledField) ([]Field, error) {
+	if len(sfs) == 0 {
+		return nil, nil
+	}
+	fields := make([]Field, len(sfs))
+	for i, sf := range sfs {
+		fields[i].Key = sf.Key
+		if sf.Value == nil {
+			group, err := unspilledFields(sf.Group)
+			if err != nil {
+				return nil, err
+			}
+			fields[i].Value = group
+			continue
+		}
+		d := json.NewDecoder(bytes.NewReader(sf.Value))
+		d.UseNumber() // so integers are rendered as they were
+		if err := d.Decode(&fields[i].Value); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
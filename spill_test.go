@@ -0,0 +1,140 @@
+package alog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpill(t *testing.T) {
+	dir := t.TempDir()
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Spill, 2), WithSpill(dir, 0))
+	go alog.Start()
+	queueBehind(t, alog, 2) // m0 is being written, m1 and m2 are queued
+	for i := 3; i < 50; i++ {
+		if err := alog.Info("m" + strconv.Itoa(i)); err != nil {
+			t.Fatalf("Message %d: %v", i, err)
+		}
+	}
+	if n := alog.Pending(); n != 50 {
+		t.Errorf("Expected 50 pending messages, got %d", n)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "alog-spill-*"))
+	if len(files) != 1 {
+		t.Fatalf("Expected a spill file, got %q", files)
+	}
+	close(gw.open)
+	alog.Stop()
+
+	got := writtenMessages(gw.b)
+	if len(got) != 50 {
+		t.Fatalf("Expected 50 messages, got %d: %q", len(got), got)
+	}
+	for i, msg := range got {
+		if msg != "m"+strconv.Itoa(i) {
+			t.Fatalf("Message %d is %q", i, msg)
+		}
+	}
+	if alog.Dropped() != 0 {
+		t.Errorf("Expected no dropped messages, got %d", alog.Dropped())
+	}
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) {
+		t.Errorf("Expected Stop to remove the spill file, got %v", err)
+	}
+}
+
+func TestSpillKeepsEntries(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Spill, 0), WithSpill(t.TempDir(), 0), WithPrefix("api"))
+	alog.now = newFakeClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)).Now
+	go alog.Start()
+	alog.Info("first")
+	for alog.Pending() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	alog.Named("db").WithFields(map[string]any{"req": 7}).WarnKV("spilled", "err", errors.New("timeout"),
+		"big", uint64(1)<<60, "ratio", 0.5, "ok", true, "request", []Field{{"id", "r-1"}})
+	close(gw.open)
+	alog.Stop()
+
+	want := "[2024-05-01 12:00:00] [INFO] [api] - first\n" +
+		"[2024-05-01 12:00:00] [WARN] [api] [db] - spilled req=7 err=timeout big=1152921504606846976 ratio=0.5 ok=true " +
+		"request.id=r-1\n"
+	if got := gw.b.String(); got != want {
+		t.Errorf("Got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSpillFlush(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Spill, 1), WithSpill(t.TempDir(), 0))
+	go alog.Start()
+	queueBehind(t, alog, 1)
+	alog.Info("spilled")
+	close(gw.open)
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := writtenMessages(gw.b); strings.Join(got, ",") != "m0,m1,spilled" {
+		t.Errorf("Got %q", got)
+	}
+	alog.Info("queued again")
+	alog.Stop()
+	if got := writtenMessages(gw.b); len(got) != 4 || got[3] != "queued again" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestSpillLimit(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Spill, 0), WithSpill(t.TempDir(), 300))
+	go alog.Start()
+	queueBehind(t, alog, 0)
+	var dropped int
+	for i := 1; i < 20; i++ {
+		if err := alog.Info("m" + strconv.Itoa(i)); err == ErrDropped {
+			dropped++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(gw.open)
+	alog.Stop()
+	if dropped == 0 || uint64(dropped) != alog.Dropped() {
+		t.Errorf("Expected messages beyond the limit to be dropped, got %d, Dropped %d", dropped, alog.Dropped())
+	}
+	if got := writtenMessages(gw.b); len(got) != 20-dropped {
+		t.Errorf("Expected %d messages, got %q", 20-dropped, got)
+	}
+}
+
+func TestSpillError(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithOverflowPolicy(Spill, 1), WithSpill(filepath.Join(t.TempDir(), "missing"), 0),
+		WithErrorBuffer(10))
+	errs := alog.ErrorChannel()
+	go alog.Start()
+	queueBehind(t, alog, 1)
+	if err := alog.Info("lost"); err != ErrDropped {
+		t.Errorf("Got %v", err)
+	}
+	close(gw.open)
+	alog.Stop()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, os.ErrNotExist) || !strings.Contains(err.Error(), "spilling") {
+			t.Errorf("Got %v", err)
+		}
+	default:
+		t.Error("Expected an error on the error channel")
+	}
+	if alog.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped message, got %d", alog.Dropped())
+	}
+}
@@ -0,0 +1,22 @@
+package alog
+
+import "runtime"
+
+// initialStackSize is the size of the first buffer runtime.Stack is given. It's doubled until the stack fits.
+const initialStackSize = 4 << 10
+
+// stack returns the stack trace of the calling goroutine if WithStacktrace applies to messages at level l, and ""
+// otherwise.
+func (al *Alog) stack(l Level) string {
+	if !al.stacktrace || l < al.stackLevel {
+		return ""
+	}
+	buf := make([]byte, initialStackSize)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
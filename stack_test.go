@@ -0,0 +1,68 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStacktrace(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithStacktrace(LevelError))
+	go alog.Start()
+	alog.Info("no stack")
+	alog.Error("with stack")
+	alog.Stop()
+
+	info, stack, _ := strings.Cut(b.String(), "\n")
+	if !strings.HasSuffix(info, " - no stack") {
+		t.Errorf("Info message has extra output: %q", b.String())
+	}
+	if !strings.HasPrefix(stack, "[") || !strings.Contains(stack, "\talog.TestStacktrace(") {
+		t.Errorf("Error message doesn't carry the test's stack: %q", stack)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(stack, "\n"), "\n")[1:] {
+		if !strings.HasPrefix(line, "\t") {
+			t.Errorf("Stack line isn't indented: %q", line)
+		}
+	}
+}
+
+func TestStacktraceJSON(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat(), WithStacktrace(LevelWarn))
+	go alog.Start()
+	alog.Info("no stack")
+	alog.Warn("with stack")
+	alog.Stop()
+
+	records := decodeJSONLines(t, b.String())
+	if _, ok := records[0]["stack"]; ok {
+		t.Error("Info message has a stack")
+	}
+	if stack, _ := records[1]["stack"].(string); !strings.Contains(stack, "alog.TestStacktraceJSON(") {
+		t.Errorf("Warn message doesn't carry the test's stack: %q", stack)
+	}
+}
+
+func deepStack(al *Alog, depth int) {
+	if depth > 0 {
+		deepStack(al, depth-1)
+		return
+	}
+	al.Error("deep")
+}
+
+func TestStacktraceNotTruncated(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithStacktrace(LevelError))
+	go alog.Start()
+	deepStack(alog, 100)
+	alog.Stop()
+	if b.Len() <= initialStackSize {
+		t.Fatalf("Expected a stack larger than %d bytes, got %d bytes", initialStackSize, b.Len())
+	}
+	if !strings.Contains(b.String(), "alog.TestStacktraceNotTruncated(") {
+		t.Error("Stack was truncated before the test's frame")
+	}
+}
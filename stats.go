@@ -0,0 +1,89 @@
+package alog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of a logger's counters, see Alog.Stats.
+type Stats struct {
+	MessagesWritten uint64    // messages written to the writer passed to New
+	BytesWritten    uint64    // bytes of those messages, as the writer reported them
+	MessagesDropped uint64    // messages logged but not written on purpose, see Dropped, Filtered, SampledOut and Expired
+	WriteErrors     uint64    // messages that failed to write, each counted once however many destinations failed
+	Fallbacks       uint64    // messages the fallback writer took instead, see WithFallbackWriter
+	QueueDepth      int       // messages waiting to be written, see Pending
+	HighWaterMark   int       // the most messages that were waiting at once, see HighWaterMark
+	LastErrorTime   time.Time // when the last error was reported, see ErrorCount, zero if there hasn't been one
+}
+
+// Stats returns the logger's counters. Each one is read atomically, but not all of them at once, so while messages
+// are being logged they can disagree by the few messages that were in flight. Stats is cheap enough to call often,
+// such as on every scrape of a metrics endpoint.
+func (al *Alog) Stats() Stats {
+	s := Stats{
+		MessagesWritten: atomic.LoadUint64(&al.written),
+		BytesWritten:    atomic.LoadUint64(&al.bytesWritten),
+		MessagesDropped: al.Dropped() + al.Filtered() + al.SampledOut() + al.Expired(),
+		WriteErrors:     atomic.LoadUint64(&al.writeErrors),
+		Fallbacks:       atomic.LoadUint64(&al.fallbacks),
+		QueueDepth:      al.Pending(),
+		HighWaterMark:   al.HighWaterMark(),
+	}
+	al.errM.Lock()
+	s.LastErrorTime = al.lastErrorTime
+	al.errM.Unlock()
+	return s
+}
+
+// Pending returns the number of messages that were accepted, by the level methods or on the channel returned by
+// MessageChannel, but haven't been written yet, including those in a batch that's being put together. It's 0 once
+// Flush or Stop has returned, unless more messages were logged in the meantime. Pending is safe to call from any
+// goroutine and cheap enough to call for every message, so callers can leave out optional messages when the
+// logger is falling behind, see Capacity.
+func (al *Alog) Pending() int {
+	return len(al.msgCh) + len(al.sentCh) + len(al.entryCh) + len(al.priorityCh) + al.ringLen() +
+		al.spillLen() + int(atomic.LoadInt32(&al.busy)) + int(atomic.LoadInt32(&al.batching))
+}
+
+// Capacity returns the number of messages the level methods can queue without waiting or dropping any, as set
+// with WithBufferSize, WithOverflowPolicy or WithRingBuffer, which rounds it up to a power of two, so
+// float64(al.Pending())/float64(al.Capacity()) is how full the logger is. It's 0 for an unbuffered logger, which
+// only accepts a message once it's ready to write it.
+func (al *Alog) Capacity() int {
+	if al.ring != nil {
+		return len(al.ring.slots)
+	}
+	return cap(al.entryCh)
+}
+
+// HighWaterMark returns the most messages that were pending at once, see Pending, since the logger was created or
+// since ResetHighWaterMark was last called. It's updated when the level methods queue a message and when the Start
+// loop receives one from MessageChannel, the only times Pending goes up.
+func (al *Alog) HighWaterMark() int {
+	return int(atomic.LoadInt64(&al.highWater))
+}
+
+// ResetHighWaterMark starts measuring the high-water mark again from the current number of pending messages, so
+// that calling it at the end of every interval gives the peak of each one, and returns the previous mark.
+func (al *Alog) ResetHighWaterMark() int {
+	return int(atomic.SwapInt64(&al.highWater, int64(al.Pending())))
+}
+
+// markHighWater raises the high-water mark to the number of pending messages if that's higher. It's a CAS loop
+// rather than a plain store so a producer that saw fewer messages can't lower the mark set by another.
+func (al *Alog) markHighWater() {
+	n := int64(al.Pending())
+	for {
+		hw := atomic.LoadInt64(&al.highWater)
+		if n <= hw || atomic.CompareAndSwapInt64(&al.highWater, hw, n) {
+			return
+		}
+	}
+}
+
+// countWritten counts messages that were written in n bytes.
+func (al *Alog) countWritten(messages, n int) {
+	atomic.AddUint64(&al.written, uint64(messages))
+	atomic.AddUint64(&al.bytesWritten, uint64(max(n, 0)))
+}
@@ -0,0 +1,195 @@
+package alog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// alternatingWriter fails every other write, starting with the second.
+type alternatingWriter struct {
+	n int32
+}
+
+func (aw *alternatingWriter) Write(data []byte) (int, error) {
+	if atomic.AddInt32(&aw.n, 1)%2 == 0 {
+		return 0, errors.New("flaky")
+	}
+	return len(data), nil
+}
+
+func TestStats(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alog := New(&alternatingWriter{}, WithBufferSize(20), WithErrorBuffer(20), WithTimestampFormat(""),
+		WithFilter(func(e *Entry) bool { return e.Message != "secret" }))
+	alog.now = newFakeClock(now).Now
+	if s := alog.Stats(); s != (Stats{}) {
+		t.Errorf("Expected a new logger to have no stats, got %+v", s)
+	}
+	for i := 0; i < 6; i++ {
+		alog.Info("abc")
+	}
+	alog.Info("secret")
+	if s := alog.Stats(); s.QueueDepth != 6 {
+		t.Errorf("Expected 6 queued messages before Start, got %d", s.QueueDepth)
+	}
+	go alog.Start()
+	alog.Stop()
+	alog.Info("late")
+
+	s := alog.Stats()
+	line := len("[INFO] - abc\n")
+	want := Stats{
+		MessagesWritten: 3,
+		BytesWritten:    uint64(3 * line),
+		MessagesDropped: 2,
+		WriteErrors:     3,
+		HighWaterMark:   6,
+		LastErrorTime:   now,
+	}
+	if s != want {
+		t.Errorf("Got %+v, want %+v", s, want)
+	}
+}
+
+func TestStatsBatch(t *testing.T) {
+	fw := failingWriter{make(chan struct{})}
+	alog := New(fw, WithBufferSize(100), WithBatching(50, 0), WithErrorBuffer(10))
+	go alog.Start()
+	queueBehind(t, alog, 3)
+	close(fw.open)
+	alog.Stop()
+
+	if s := alog.Stats(); s.WriteErrors != 4 || s.MessagesWritten != 0 || s.QueueDepth != 0 {
+		t.Errorf("Got %+v", s)
+	}
+}
+
+func TestPending(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithBufferSize(10))
+	if n := alog.Capacity(); n != 10 {
+		t.Errorf("Expected a capacity of 10, got %d", n)
+	}
+	go alog.Start()
+	defer alog.Stop()
+	queueBehind(t, alog, 0)
+	for i := 2; i <= 5; i++ {
+		alog.Info("m")
+		if n := alog.Pending(); n != i {
+			t.Errorf("Expected %d pending messages, got %d", i, n)
+		}
+	}
+	close(gw.open)
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := alog.Pending(); n != 0 {
+		t.Errorf("Expected no pending messages after Flush, got %d", n)
+	}
+}
+
+func TestPendingBatch(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithBufferSize(10), WithBatching(50, 0))
+	go alog.Start()
+	queueBehind(t, alog, 3)
+	if n := alog.Pending(); n != 4 {
+		t.Errorf("Expected 4 pending messages, got %d", n)
+	}
+	close(gw.open)
+	alog.Stop()
+	if n := alog.Pending(); n != 0 {
+		t.Errorf("Expected no pending messages after Stop, got %d", n)
+	}
+}
+
+// TestPendingWithWorkers calls Pending while the workers are started and stopped, for the race detector.
+func TestPendingWithWorkers(t *testing.T) {
+	alog := New(&lockedBuffer{}, WithWorkers(4), WithBufferSize(10))
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				alog.Pending()
+				alog.Info("message")
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if err := alog.Restart(); err != nil {
+			t.Fatal(err)
+		}
+		go alog.Start()
+		time.Sleep(time.Millisecond)
+		alog.Stop()
+	}
+	close(done)
+	wg.Wait()
+	if n := alog.Pending(); n != 0 {
+		t.Errorf("Expected no pending messages once the logger is stopped, got %d", n)
+	}
+}
+
+func TestCapacityRingBuffer(t *testing.T) {
+	if n := New(&lockedBuffer{}, WithRingBuffer(100)).Capacity(); n != 128 {
+		t.Errorf("Expected the ring's 128 slots, got %d", n)
+	}
+}
+
+func TestHighWaterMark(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithBufferSize(500))
+	go alog.Start()
+	defer alog.Stop()
+	queueBehind(t, alog, 499)
+	close(gw.open)
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 200; i++ {
+		alog.Info("more")
+	}
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := alog.HighWaterMark(); n != 500 {
+		t.Errorf("Expected a high-water mark of 500, got %d", n)
+	}
+	if n := alog.Stats().HighWaterMark; n != 500 {
+		t.Errorf("Expected Stats to report a high-water mark of 500, got %d", n)
+	}
+	if n := alog.ResetHighWaterMark(); n != 500 {
+		t.Errorf("Expected ResetHighWaterMark to return 500, got %d", n)
+	}
+	if n := alog.HighWaterMark(); n != 0 {
+		t.Errorf("Expected the reset mark to be 0, got %d", n)
+	}
+}
+
+func TestHighWaterMarkConcurrent(t *testing.T) {
+	alog := New(&lockedBuffer{}, WithBufferSize(1000))
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				alog.Info("m")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := alog.HighWaterMark(); n != 1000 {
+		t.Errorf("Expected a high-water mark of 1000, got %d", n)
+	}
+}
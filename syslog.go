@@ -0,0 +1,96 @@
+//go:build !windows && !plan9
+
+package alog
+
+import (
+	"log/syslog"
+	"sync"
+	"time"
+)
+
+// SyslogWriter sends messages to a syslog server. It's an EntryWriter, so it gets the logger's entries rather than
+// formatted lines: each message is sent with the severity that matches its level and without a timestamp, which
+// syslog adds itself. The connection is made on the first message and made again after it fails; connection and
+// write errors are returned, so a logger sends them on its error channel. SyslogWriter is safe for concurrent use.
+type SyslogWriter struct {
+	m        sync.Mutex
+	network  string
+	addr     string
+	tag      string
+	facility syslog.Priority
+	w        *syslog.Writer
+	buf      []byte
+}
+
+// NewSyslogWriter returns a SyslogWriter that connects to addr over network, or to the local syslog server if
+// network is empty, like syslog.Dial. Messages are sent with tag and facility, for example syslog.LOG_DAEMON. Call
+// Close once the logger has been stopped.
+func NewSyslogWriter(network, addr, tag string, facility syslog.Priority) *SyslogWriter {
+	return &SyslogWriter{network: network, addr: addr, tag: tag, facility: facility & facilityMask}
+}
+
+// facilityMask leaves the facility of a syslog.Priority.
+const facilityMask = 0xf8
+
+// WriteEntry implements EntryWriter.
+func (sw *SyslogWriter) WriteEntry(e *Entry) error {
+	raw := *e
+	raw.Time = time.Time{}
+	raw.implicit = true // the level is in the severity
+	sw.m.Lock()
+	defer sw.m.Unlock()
+	sw.buf = TextFormatter{}.Format(sw.buf[:0], &raw)
+	return sw.send(raw.Level, string(sw.buf))
+}
+
+// Write sends data at LevelInfo, for messages that didn't come from an Alog.
+func (sw *SyslogWriter) Write(data []byte) (int, error) {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+	if err := sw.send(LevelInfo, string(data)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Close closes the connection, if there is one. A later message opens a new one.
+func (sw *SyslogWriter) Close() error {
+	sw.m.Lock()
+	defer sw.m.Unlock()
+	if sw.w == nil {
+		return nil
+	}
+	err := sw.w.Close()
+	sw.w = nil
+	return err
+}
+
+// send sends msg with the severity for l, connecting first if needed. The caller holds m.
+func (sw *SyslogWriter) send(l Level, msg string) error {
+	if sw.w == nil {
+		w, err := syslog.Dial(sw.network, sw.addr, sw.facility|syslog.LOG_INFO, sw.tag)
+		if err != nil {
+			return err
+		}
+		sw.w = w
+	}
+	var err error
+	switch {
+	case l >= LevelPanic:
+		err = sw.w.Crit(msg)
+	case l >= LevelError:
+		err = sw.w.Err(msg)
+	case l >= LevelWarn:
+		err = sw.w.Warning(msg)
+	case l >= LevelInfo:
+		err = sw.w.Info(msg)
+	default:
+		err = sw.w.Debug(msg)
+	}
+	if err != nil {
+		// syslog.Writer has already tried to reconnect once, so start over with the next message.
+		sw.w.Close()
+		sw.w = nil
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+//go:build !windows && !plan9
+
+package alog
+
+import (
+	"bytes"
+	"log/syslog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readPacket reads a message from the fake syslog server.
+func readPacket(t *testing.T, pc net.PacketConn) string {
+	t.Helper()
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogWriter(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	sw := NewSyslogWriter("udp", pc.LocalAddr().String(), "app", syslog.LOG_LOCAL0)
+	defer sw.Close()
+	b := bytes.NewBuffer([]byte{})
+	alog := New(sw, WithAdditionalWriter(b), WithPrefix("web"))
+	go alog.Start()
+	alog.Info("hello")
+	alog.ErrorKV("failed", "code", 7)
+	alog.Debug("hidden")
+	alog.Stop()
+
+	for _, want := range []struct{ pri, msg string }{
+		{"<134>", "[web] - hello\n"}, // LOG_LOCAL0 is 16<<3 and LOG_INFO is 6
+		{"<131>", "[web] - failed code=7\n"},
+	} {
+		packet := readPacket(t, pc)
+		if !strings.HasPrefix(packet, want.pri) || !strings.Contains(packet, " app[") || !strings.HasSuffix(packet, "]: "+want.msg) {
+			t.Errorf("Got %q, want priority %s and message %q", packet, want.pri, want.msg)
+		}
+	}
+	if !strings.Contains(b.String(), "[INFO] [web] - hello") {
+		t.Errorf("The other destination didn't get formatted lines, got %q", b.String())
+	}
+}
+
+func TestSyslogWriterReconnects(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	sw := NewSyslogWriter("tcp", addr, "app", syslog.LOG_DAEMON)
+	defer sw.Close()
+	alog := New(sw)
+	go alog.Start()
+	defer alog.Stop()
+
+	alog.Info("lost")
+	select {
+	case err := <-alog.ErrorChannel():
+		if !strings.Contains(err.Error(), "connect") {
+			t.Errorf("Expected a connection error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The connection error wasn't reported")
+	}
+
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("Couldn't listen on %s again: %v", addr, err)
+	}
+	defer l.Close()
+	alog.Info("delivered")
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); !strings.HasSuffix(got, "]: delivered\n") {
+		t.Errorf("Got %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package alog
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// timestampCache remembers the last timestamp a TextFormatter rendered, so the messages logged within the same
+// second reuse it instead of formatting the time again.
+type timestampCache struct {
+	last atomic.Pointer[cachedTimestamp]
+}
+
+type cachedTimestamp struct {
+	sec  int64 // Unix time
+	loc  *time.Location
+	text []byte
+}
+
+// WithPrecision adds fractional seconds down to d to the timestamps of the default TextFormatter, e.g. with
+// time.Millisecond the default layout becomes "2006-01-02 15:04:05.000". It applies to the layout set with
+// WithTimestampFormat too, whatever order the options come in, unless that layout has no seconds or already has
+// fractional seconds. A d of a second or more leaves the layout as it is, which is the default.
+func WithPrecision(d time.Duration) Option {
+	return func(al *Alog) {
+		al.precision = d
+	}
+}
+
+// withPrecision returns layout with fractional seconds down to d after its seconds, see WithPrecision.
+func withPrecision(layout string, d time.Duration) string {
+	i := strings.Index(layout, "05")
+	if d <= 0 || d >= time.Second || i < 0 || hasFraction(layout) {
+		return layout
+	}
+	frac := "."
+	for p := time.Second; p > d && len(frac) < 10; p /= 10 {
+		frac += "0"
+	}
+	return layout[:i+2] + frac + layout[i+2:]
+}
+
+// hasFraction reports whether layout has fractional seconds, a "." or "," and a 0 or 9 right after its seconds,
+// "05". A dot elsewhere, as in "2006.01.02", isn't a fraction.
+func hasFraction(layout string) bool {
+	for i := strings.Index(layout, "05"); i >= 0; {
+		frac := layout[i+2:]
+		if len(frac) >= 2 && (frac[0] == '.' || frac[0] == ',') && (frac[1] == '0' || frac[1] == '9') {
+			return true
+		}
+		j := strings.Index(frac, "05")
+		if j < 0 {
+			break
+		}
+		i += 2 + j
+	}
+	return false
+}
+
+// newTimestampCache returns a cache for layout, or nil if layout has fractional seconds, which would make every
+// timestamp different.
+func newTimestampCache(layout string) *timestampCache {
+	if hasFraction(layout) {
+		return nil
+	}
+	return &timestampCache{}
+}
+
+// appendFormat appends t formatted with layout to buf, like t.AppendFormat. layout must be the one the cache was
+// created for.
+func (c *timestampCache) appendFormat(buf []byte, t time.Time, layout string) []byte {
+	sec := t.Unix()
+	if ts := c.last.Load(); ts != nil && ts.sec == sec && ts.loc == t.Location() {
+		return append(buf, ts.text...)
+	}
+	start := len(buf)
+	buf = t.AppendFormat(buf, layout)
+	c.last.Store(&cachedTimestamp{sec: sec, loc: t.Location(), text: append([]byte(nil), buf[start:]...)})
+	return buf
+}
@@ -0,0 +1,122 @@
+package alog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampCacheRollover(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithTimestampFormat("15:04:05"))
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alog.now = newFakeClock(
+		base.Add(100*time.Millisecond),
+		base.Add(900*time.Millisecond),
+		base.Add(1000*time.Millisecond),
+		base.Add(1500*time.Millisecond),
+	).Now
+	go alog.Start()
+	for i := 0; i < 4; i++ {
+		alog.Info("m")
+	}
+	alog.Stop()
+
+	want := "[12:00:00] [INFO] - m\n[12:00:00] [INFO] - m\n[12:00:01] [INFO] - m\n[12:00:01] [INFO] - m\n"
+	if b.String() != want {
+		t.Errorf("Got %q, want %q", b.String(), want)
+	}
+}
+
+func TestTimestampCacheLocation(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	f := TextFormatter{Layout: "15:04", timestamps: newTimestampCache("15:04")}
+	var buf []byte
+	buf = f.Format(buf, &Entry{Time: ts, Message: "utc"})
+	buf = f.Format(buf, &Entry{Time: ts.In(time.FixedZone("UTC+2", 2*60*60)), Message: "utc+2"})
+	if want := "[12:00] [INFO] - utc\n[14:00] [INFO] - utc+2\n"; string(buf) != want {
+		t.Errorf("Got %q, want %q", buf, want)
+	}
+}
+
+func TestTimestampCacheFractionalSeconds(t *testing.T) {
+	for _, layout := range []string{"15:04:05.000", "15:04:05.999999", time.RFC3339Nano, "15:04:05,000"} {
+		if newTimestampCache(layout) != nil {
+			t.Errorf("Timestamps with the layout %q were cached", layout)
+		}
+	}
+	if newTimestampCache("2006.01.02 15:04:05") == nil {
+		t.Error("Expected timestamps with dots in the date to be cached")
+	}
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithTimestampFormat("05.000"))
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alog.now = newFakeClock(base.Add(100*time.Millisecond), base.Add(200*time.Millisecond)).Now
+	go alog.Start()
+	alog.Info("m")
+	alog.Info("m")
+	alog.Stop()
+	if lines := strings.Split(b.String(), "\n"); lines[0] != "[00.100] [INFO] - m" || lines[1] != "[00.200] [INFO] - m" {
+		t.Errorf("Got %q", b.String())
+	}
+}
+
+func BenchmarkTimestamp(b *testing.B) {
+	ts := time.Now()
+	for _, cached := range []bool{false, true} {
+		name := "cached=false"
+		f := TextFormatter{Layout: defaultTimestampFormat}
+		if cached {
+			name = "cached=true"
+			f.timestamps = newTimestampCache(defaultTimestampFormat)
+		}
+		b.Run(name, func(b *testing.B) {
+			buf := make([]byte, 0, 64)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf = f.Format(buf[:0], &Entry{Time: ts, implicit: true})
+			}
+		})
+	}
+}
+
+func TestWithPrecisionLayouts(t *testing.T) {
+	tests := []struct {
+		layout string
+		d      time.Duration
+		want   string
+	}{
+		{defaultTimestampFormat, time.Millisecond, "2006-01-02 15:04:05.000"},
+		{defaultTimestampFormat, time.Microsecond, "2006-01-02 15:04:05.000000"},
+		{time.RFC3339, time.Nanosecond, "2006-01-02T15:04:05.000000000Z07:00"},
+		{"15:04:05", 10 * time.Millisecond, "15:04:05.00"},
+		{defaultTimestampFormat, time.Second, defaultTimestampFormat},
+		{"15:04", time.Millisecond, "15:04"},
+		{"15:04:05.999", time.Microsecond, "15:04:05.999"},
+		{"15:04:05,000", time.Microsecond, "15:04:05,000"},
+		{"2006.01.02 15:04:05", time.Millisecond, "2006.01.02 15:04:05.000"},
+		{"2006.01.02 15:04:05.000", time.Microsecond, "2006.01.02 15:04:05.000"},
+		{"02.01.2006 15:04:05", 10 * time.Millisecond, "02.01.2006 15:04:05.00"},
+		{"", time.Millisecond, ""},
+	}
+	for _, tt := range tests {
+		if got := withPrecision(tt.layout, tt.d); got != tt.want {
+			t.Errorf("withPrecision(%q, %v) = %q, want %q", tt.layout, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestWithPrecision(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithPrecision(time.Millisecond), WithTimestampFormat("15:04:05"), WithBufferSize(10))
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+	alog.now = newFakeClock(base.Add(7*time.Millisecond), base.Add(8*time.Millisecond)).Now
+	go alog.Start()
+	alog.Info("first")
+	alog.Info("second")
+	alog.Stop()
+	if got, want := b.String(), "[12:00:00.007] [INFO] - first\n[12:00:00.008] [INFO] - second\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,40 @@
+package alog
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// WithMaxMessageSize truncates messages longer than n bytes to at most n bytes, followed by a marker saying how much
+// was cut, such as "…[truncated 41942384 bytes]". Messages are only cut between runes, so a truncated message is
+// still valid UTF-8 if it was before and every formatter, JSONFormatter included, writes it as usual. Truncated
+// counts the truncated messages, and the first one is also reported on the error channel. Fields aren't truncated.
+func WithMaxMessageSize(n int) Option {
+	return func(al *Alog) {
+		al.maxMessageSize = n
+	}
+}
+
+// Truncated returns the number of messages truncated by WithMaxMessageSize.
+func (al *Alog) Truncated() uint64 {
+	return atomic.LoadUint64(&al.truncated)
+}
+
+// truncate shortens e's message to maxMessageSize if it's longer.
+func (al *Alog) truncate(e *Entry) {
+	if len(e.Message) <= al.maxMessageSize {
+		return
+	}
+	n := al.maxMessageSize
+	for n > 0 && !utf8.RuneStart(e.Message[n]) {
+		n--
+	}
+	cut := len(e.Message) - n
+	e.Message = e.Message[:n] + "…[truncated " + strconv.Itoa(cut) + " bytes]"
+	if atomic.AddUint64(&al.truncated, 1) == 1 {
+		al.reportError(fmt.Errorf("alog: message of %d bytes truncated to %d, later ones are only counted",
+			n+cut, al.maxMessageSize))
+	}
+}
@@ -0,0 +1,65 @@
+package alog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMaxMessageSize(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithMaxMessageSize(10))
+	alog.Write("short")
+	alog.Write(strings.Repeat("x", 50))
+	alog.Write(strings.Repeat("y", 30))
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "- short") {
+		t.Fatalf("Got %q", b.String())
+	}
+	if want := "- xxxxxxxxxx…[truncated 40 bytes]"; !strings.HasSuffix(lines[1], want) {
+		t.Errorf("Got %q, want it to end with %q", lines[1], want)
+	}
+	if n := alog.Truncated(); n != 2 {
+		t.Errorf("Expected 2 truncated messages, got %d", n)
+	}
+	if err := <-alog.ErrorChannel(); err == nil || !strings.Contains(err.Error(), "message of 50 bytes truncated to 10") {
+		t.Errorf("Expected the first truncation to be reported, got %v", err)
+	}
+	select {
+	case err := <-alog.ErrorChannel():
+		t.Errorf("Expected one report, got %v", err)
+	default:
+	}
+}
+
+func TestMaxMessageSizeRuneBoundary(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithMaxMessageSize(5))
+	alog.Write("ab€cd") // € is 3 bytes, from 2 to 5
+	alog.Write("abcd€")
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if want := "- ab€…[truncated 2 bytes]"; !strings.HasSuffix(lines[0], want) {
+		t.Errorf("Got %q, want it to end with %q", lines[0], want)
+	}
+	if want := "- abcd…[truncated 3 bytes]"; !strings.HasSuffix(lines[1], want) {
+		t.Errorf("Got %q, want it to end with %q", lines[1], want)
+	}
+	if !utf8.ValidString(b.String()) {
+		t.Errorf("Truncation split a rune: %q", b.String())
+	}
+}
+
+func TestMaxMessageSizeJSON(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithJSONFormat(), WithMaxMessageSize(8))
+	alog.Write(`{"a":"日本語"}` + strings.Repeat(`\"`, 100))
+	var m map[string]any
+	if err := json.Unmarshal(b.Bytes(), &m); err != nil {
+		t.Fatalf("Truncated message isn't valid JSON: %v in %q", err, b.String())
+	}
+	if msg := m["msg"].(string); !strings.HasPrefix(msg, `{"a":"`) || !strings.Contains(msg, "…[truncated ") {
+		t.Errorf("Got %q", msg)
+	}
+}
@@ -0,0 +1,113 @@
+package alog
+
+import "sync/atomic"
+
+// TryWrite queues msg like a message sent on MessageChannel, but never blocks: if the queue is full or the logger
+// has been stopped the message is dropped, counted in Dropped, and TryWrite returns false. It returns true for
+// messages that were queued or filtered out by the logger's level or sampling, see WithSampling.
+func (al *Alog) TryWrite(msg string) bool {
+	if !al.enabled(LevelInfo) {
+		return true
+	}
+	e := al.newEntry(LevelInfo, msg)
+	e.implicit = true
+	e.Caller = al.caller(1)
+	e.Stack = al.stack(LevelInfo)
+	return al.tryEnqueue(e)
+}
+
+// TryDebugf is like Debugf, but never blocks. It reports whether the message was accepted, like TryWrite.
+func (al *Alog) TryDebugf(format string, args ...any) bool {
+	return al.tryLogf(LevelDebug, format, args...)
+}
+
+// TryInfof is like Infof, but never blocks. It reports whether the message was accepted, like TryWrite.
+func (al *Alog) TryInfof(format string, args ...any) bool {
+	return al.tryLogf(LevelInfo, format, args...)
+}
+
+// TryWarnf is like Warnf, but never blocks. It reports whether the message was accepted, like TryWrite.
+func (al *Alog) TryWarnf(format string, args ...any) bool {
+	return al.tryLogf(LevelWarn, format, args...)
+}
+
+// TryErrorf is like Errorf, but never blocks. It reports whether the message was accepted, like TryWrite.
+func (al *Alog) TryErrorf(format string, args ...any) bool {
+	return al.tryLogf(LevelError, format, args...)
+}
+
+// TryDebugKV is like DebugKV, but never blocks. It reports whether the message was accepted, like TryWrite.
+func (al *Alog) TryDebugKV(msg string, kv ...any) bool {
+	return al.tryLog(LevelDebug, msg, kv)
+}
+
+// TryInfoKV is like InfoKV, but never blocks. It reports whether the message was accepted, like TryWrite.
+func (al *Alog) TryInfoKV(msg string, kv ...any) bool {
+	return al.tryLog(LevelInfo, msg, kv)
+}
+
+// TryWarnKV is like WarnKV, but never blocks. It reports whether the message was accepted, like TryWrite.
+func (al *Alog) TryWarnKV(msg string, kv ...any) bool {
+	return al.tryLog(LevelWarn, msg, kv)
+}
+
+// TryErrorKV is like ErrorKV, but never blocks. It reports whether the message was accepted, like TryWrite.
+func (al *Alog) TryErrorKV(msg string, kv ...any) bool {
+	return al.tryLog(LevelError, msg, kv)
+}
+
+// tryLog must only be called by the Try level methods, so the caller is always the same number of frames up.
+func (al *Alog) tryLog(l Level, msg string, kv []any) bool {
+	if !al.enabled(l) || al.sampler.sampleOut(l) {
+		return true
+	}
+	e := al.newEntry(l, msg)
+	if len(kv) > 0 {
+		e.Fields = mergeFields(e.Fields, kvFields(kv))
+	}
+	e.Caller = al.caller(2)
+	e.Stack = al.stack(l)
+	return al.tryEnqueue(e)
+}
+
+// tryLogf must only be called by the Try level methods, so the caller is always the same number of frames up.
+func (al *Alog) tryLogf(l Level, format string, args ...any) bool {
+	if !al.enabled(l) || al.sampler.sampleOut(l) {
+		return true
+	}
+	e := al.newEntry(l, sprintf(format, args...))
+	e.Caller = al.caller(2)
+	e.Stack = al.stack(l)
+	return al.tryEnqueue(e)
+}
+
+// tryEnqueue numbers e and queues it if that can be done without blocking.
+func (al *Alog) tryEnqueue(e Entry) bool {
+	if len(al.filters) > 0 && al.filteredOut(e) {
+		return true
+	}
+	if al.limiter != nil && !al.limit(e.Level) {
+		return false
+	}
+	e.Seq = al.nextSeq()
+	if atomic.LoadInt32(&al.state) >= stateStopping {
+		atomic.AddUint64(&al.dropped, 1)
+		return false
+	}
+	if al.ring != nil {
+		if al.ring.push(e) {
+			al.markHighWater()
+			return true
+		}
+		al.overflowed()
+		return false
+	}
+	select {
+	case al.entryCh <- e:
+		al.markHighWater()
+		return true
+	default:
+		al.overflowed()
+		return false
+	}
+}
@@ -0,0 +1,72 @@
+package alog
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTryWrite(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithBufferSize(2))
+	go alog.Start()
+	if !alog.TryWrite("first") {
+		t.Fatal("TryWrite rejected a message for an empty queue")
+	}
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if !alog.TryWrite("second") || !alog.TryInfof("third %d", 3) {
+		t.Fatal("TryWrite rejected a message while the queue had room")
+	}
+	start := time.Now()
+	if alog.TryWrite("rejected") || alog.TryErrorf("rejected %d", 2) {
+		t.Error("TryWrite accepted a message for a full queue")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("Rejecting messages took %v", elapsed)
+	}
+	if !alog.TryDebugf("filtered") {
+		t.Error("TryDebugf rejected a message that's filtered out by the level")
+	}
+	close(gw.open)
+	alog.Stop()
+
+	if got, want := strings.Join(writtenMessages(gw.b), ","), "first,second,third 3"; got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+	if alog.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped messages, got %d", alog.Dropped())
+	}
+	if alog.TryWrite("late") {
+		t.Error("TryWrite accepted a message after Stop")
+	}
+}
+
+func TestTryKV(t *testing.T) {
+	gw := gatedWriter{make(chan struct{}), &lockedBuffer{}}
+	alog := New(gw, WithBufferSize(1))
+	go alog.Start()
+	if !alog.TryInfoKV("first", "n", 1) {
+		t.Fatal("TryInfoKV rejected a message for an empty queue")
+	}
+	for atomic.LoadInt32(&alog.busy) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if !alog.TryErrorKV("second", "err", "boom") {
+		t.Fatal("TryErrorKV rejected a message while the queue had room")
+	}
+	if alog.TryWarnKV("rejected", "n", 3) {
+		t.Error("TryWarnKV accepted a message for a full queue")
+	}
+	if !alog.TryDebugKV("filtered", "n", 4) {
+		t.Error("TryDebugKV rejected a message that's filtered out by the level")
+	}
+	close(gw.open)
+	alog.Stop()
+
+	if got, want := strings.Join(writtenMessages(gw.b), ","), "first n=1,second err=boom"; got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package alog
+
+import "sync/atomic"
+
+// WithVerbosity sets the verbosity that V compares against. The default is 0, which only enables V(0).
+func WithVerbosity(v int) Option {
+	return func(al *Alog) {
+		al.verbosity = int32(v)
+	}
+}
+
+// SetVerbosity changes the verbosity that V compares against. Like SetLevel it's safe to call while the logger is
+// running and other goroutines are logging.
+func (al *Alog) SetVerbosity(v int) {
+	atomic.StoreInt32(&al.verbosity, int32(v))
+}
+
+// Verbosity returns the verbosity that V compares against.
+func (al *Alog) Verbosity() int {
+	return int(atomic.LoadInt32(&al.verbosity))
+}
+
+// V returns a handle that logs at LevelInfo if the logger's verbosity is at least v, and does nothing otherwise,
+// like glog's V. Messages logged through it also have to pass the logger's level, like any other at LevelInfo.
+// A disabled handle doesn't format its message, but Go evaluates the arguments of a call anyway, so guard expensive
+// ones with Enabled:
+//
+//	if v := al.V(3); v.Enabled() {
+//		v.Infof("cache: %s", cache.Dump())
+//	}
+func (al *Alog) V(v int) Verbose {
+	if v > al.Verbosity() {
+		return Verbose{}
+	}
+	return Verbose{al}
+}
+
+// Verbose is returned by Alog.V. The zero Verbose is disabled.
+type Verbose struct {
+	al *Alog
+}
+
+// Enabled reports whether messages logged through v are written.
+func (v Verbose) Enabled() bool {
+	return v.al != nil && v.al.enabled(LevelInfo)
+}
+
+// Info queues msg to be written at LevelInfo if v is enabled, like Alog.Info.
+func (v Verbose) Info(msg string) error {
+	if v.al == nil {
+		return nil
+	}
+	return v.al.log(LevelInfo, msg, nil)
+}
+
+// InfoKV queues msg with the alternating keys and values in kv attached to it if v is enabled, like Alog.InfoKV.
+func (v Verbose) InfoKV(msg string, kv ...any) error {
+	if v.al == nil {
+		return nil
+	}
+	return v.al.log(LevelInfo, msg, kv)
+}
+
+// Infof formats the message and queues it if v is enabled, like Alog.Infof.
+func (v Verbose) Infof(format string, args ...any) error {
+	if v.al == nil {
+		return nil
+	}
+	return v.al.logf(LevelInfo, format, args...)
+}
+
+// Write writes msg synchronously if v is enabled, like Alog.Write.
+func (v Verbose) Write(msg string) (int, error) {
+	if v.al == nil || !v.al.enabled(LevelInfo) {
+		return 0, nil
+	}
+	return v.al.writeSync(msg)
+}
+
+// Writef formats the message and writes it synchronously if v is enabled, like Alog.Writef.
+func (v Verbose) Writef(format string, args ...any) (int, error) {
+	if v.al == nil || !v.al.enabled(LevelInfo) {
+		return 0, nil
+	}
+	return v.al.writeSync(sprintf(format, args...))
+}
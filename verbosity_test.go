@@ -0,0 +1,76 @@
+package alog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerbosity(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithVerbosity(1), WithBufferSize(10))
+	go alog.Start()
+	alog.V(1).Info("one")
+	alog.V(2).Info("two, hidden")
+	if _, err := alog.V(3).Writef("three %d, hidden", 3); err != nil {
+		t.Fatal(err)
+	}
+	alog.SetVerbosity(3)
+	if n := alog.Verbosity(); n != 3 {
+		t.Errorf("Expected verbosity 3, got %d", n)
+	}
+	alog.V(2).InfoKV("two", "k", "v")
+	alog.V(3).Infof("three %d", 3)
+	alog.V(4).Infof("four %d, hidden", 4)
+	alog.Stop()
+
+	if got := writtenMessages(lb); strings.Join(got, ",") != "one,two k=v,three 3" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestVerbosityRespectsLevel(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithVerbosity(5), WithLevel(LevelWarn))
+	if v := alog.V(1); v.Enabled() {
+		t.Error("Expected V to be disabled below the logger's level")
+	}
+	if _, err := alog.V(1).Write("hidden"); err != nil || lb.String() != "" {
+		t.Errorf("Got %v and %q", err, lb.String())
+	}
+	alog.SetLevel(LevelInfo)
+	if n, err := alog.V(5).Writef("shown %d", 5); err != nil || n == 0 || !strings.HasSuffix(lb.String(), "shown 5\n") {
+		t.Errorf("Got %d, %v and %q", n, err, lb.String())
+	}
+	if (Verbose{}).Enabled() {
+		t.Error("Expected the zero Verbose to be disabled")
+	}
+}
+
+func TestVerbosityDisabledDoesNotAllocate(t *testing.T) {
+	alog := New(&lockedBuffer{})
+	n := 42
+	allocs := testing.AllocsPerRun(1000, func() {
+		alog.V(2).Writef("value %d of %s", n, "x")
+		alog.V(2).Infof("value %d", n)
+		alog.V(2).Info("message")
+	})
+	if allocs != 0 {
+		t.Errorf("Expected the disabled path not to allocate, got %v allocations", allocs)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	lb := &lockedBuffer{}
+	alog := New(lb, WithLevel(LevelTrace))
+	go alog.Start()
+	alog.Tracef("deep %d", 1)
+	alog.TraceKV("deeper", "n", 2)
+	alog.Stop()
+
+	if got := lb.String(); strings.Count(got, "[TRACE] - ") != 2 || !strings.Contains(got, "deeper n=2") {
+		t.Errorf("Got %q", got)
+	}
+	if err := New(lb).Trace("hidden"); err != nil {
+		t.Errorf("Got %v", err)
+	}
+}
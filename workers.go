@@ -0,0 +1,61 @@
+package alog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// startWorkers starts the worker goroutines requested with WithWorkers. Each entry they write is accounted for in
+// wg.
+func (al *Alog) startWorkers(wg *sync.WaitGroup) {
+	if al.workers <= 1 {
+		return
+	}
+	al.workCh = make(chan Entry)
+	for i := 0; i < al.workers; i++ {
+		go func(workCh <-chan Entry) {
+			for e := range workCh {
+				al.writeBusy(e) // counted by dispatch
+				wg.Done()
+			}
+		}(al.workCh)
+	}
+}
+
+// stopWorkers waits for the workers to finish writing and stops them.
+func (al *Alog) stopWorkers(wg *sync.WaitGroup) {
+	if al.workCh == nil {
+		return
+	}
+	close(al.workCh)
+	wg.Wait()
+	al.workCh = nil
+}
+
+// process writes e, unless it's a repeat held back by WithRepeatSuppression, see dispatch.
+func (al *Alog) process(e Entry, wg *sync.WaitGroup) {
+	if al.maxAge > 0 && al.expire(&e) {
+		return
+	}
+	if al.repeats != nil && al.repeated(e, wg) {
+		al.consumed(e.walEnd) // it's counted in the summary
+		return
+	}
+	al.dispatch(e, wg)
+}
+
+// dispatch writes e on the calling goroutine, batched with the messages queued behind it if WithBatching is used, or
+// hands it to a worker if there are any.
+func (al *Alog) dispatch(e Entry, wg *sync.WaitGroup) {
+	if al.workCh == nil {
+		if al.batchMessages > 1 && al.entryDest == nil && al.repeats == nil {
+			al.writeBatch(e, wg)
+			return
+		}
+		al.writeEntry(e)
+		return
+	}
+	wg.Add(1)
+	atomic.AddInt32(&al.busy, 1) // so Pending counts e while it waits for a worker, without reading workCh
+	al.workCh <- e
+}
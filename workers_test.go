@@ -0,0 +1,75 @@
+package alog
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkers(t *testing.T) {
+	lb := &lockedBuffer{delay: time.Millisecond}
+	alog := New(lb, WithWorkers(8), WithBufferSize(100))
+	go alog.Start()
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		alog.Info(strconv.Itoa(i))
+	}
+	if err := alog.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if lines := strings.Count(lb.String(), "\n"); lines != 100 {
+		t.Errorf("Expected 100 lines when Flush returned, got %d", lines)
+	}
+	if elapsed > 50*time.Millisecond {
+		t.Errorf("100 writes of 1ms each took %v with 8 workers", elapsed)
+	}
+	for i := 0; i < 100; i++ {
+		alog.MessageChannel() <- strconv.Itoa(i)
+	}
+	alog.Stop()
+	if lines := strings.Count(lb.String(), "\n"); lines != 200 {
+		t.Errorf("Expected 200 lines when Stop returned, got %d", lines)
+	}
+}
+
+// BenchmarkBurst logs bursts of 100k messages to io.Discard and reports the highest number of goroutines seen
+// while they're written, on top of the ones that were running before the benchmark.
+func BenchmarkBurst(b *testing.B) {
+	for _, workers := range []int{1, 4} {
+		b.Run("workers="+strconv.Itoa(workers), func(b *testing.B) {
+			b.ReportAllocs()
+			before := int64(runtime.NumGoroutine()) + 1 // including the sampler
+			var peak int64
+			stopCh := make(chan struct{})
+			go func() {
+				for {
+					select {
+					case <-stopCh:
+						return
+					default:
+					}
+					if n := int64(runtime.NumGoroutine()); n > atomic.LoadInt64(&peak) {
+						atomic.StoreInt64(&peak, n)
+					}
+					time.Sleep(100 * time.Microsecond)
+				}
+			}()
+			for i := 0; i < b.N; i++ {
+				alog := New(io.Discard, WithWorkers(workers), WithBufferSize(1000))
+				go alog.Start()
+				for j := 0; j < 100000; j++ {
+					alog.MessageChannel() <- "message"
+				}
+				alog.Stop()
+			}
+			close(stopCh)
+			b.ReportMetric(float64(atomic.LoadInt64(&peak)-before), "peak-goroutines")
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package alog
+
+import (
+	"io"
+	"log"
+	"strings"
+)
+
+// AsWriter returns an io.Writer that queues everything written to it as a message for the Start loop, the same way
+// messages sent on MessageChannel are handled. It lets the logger back anything that writes to an io.Writer, such
+// as the standard library's logger:
+//
+//	log.SetOutput(al.AsWriter())
+//
+// Each call to Write becomes one message. The returned writer is safe for concurrent use and doesn't retain the
+// slices passed to it.
+func (al *Alog) AsWriter() io.Writer {
+	return asyncWriter{al}
+}
+
+// StdLogger returns a standard library logger that queues its output like AsWriter, for APIs that take a
+// *log.Logger such as http.Server.ErrorLog. The trailing newline the standard logger adds is stripped, and the
+// Ldate, Ltime and Lmicroseconds flags are ignored because the logger adds its own timestamp. Output after Stop is
+// dropped.
+func (al *Alog) StdLogger(prefix string, flags int) *log.Logger {
+	return log.New(stdWriter{al}, prefix, flags&^(log.Ldate|log.Ltime|log.Lmicroseconds))
+}
+
+// stdWriter is the destination of the loggers returned by StdLogger. The standard logger calls Write once per
+// message.
+type stdWriter struct {
+	al *Alog
+}
+
+func (w stdWriter) Write(p []byte) (int, error) {
+	if !w.al.enabled(LevelInfo) {
+		return len(p), nil
+	}
+	e := w.al.newEntry(LevelInfo, strings.TrimSuffix(string(p), "\n"))
+	e.implicit = true
+	w.al.enqueue(e)
+	return len(p), nil
+}
+
+type asyncWriter struct {
+	al *Alog
+}
+
+// Write queues p as a message and reports it as written in full, even if the message is dropped because of the
+// logger's level or because the logger has been stopped.
+func (w asyncWriter) Write(p []byte) (int, error) {
+	if !w.al.enabled(LevelInfo) {
+		return len(p), nil
+	}
+	e := w.al.newEntry(LevelInfo, string(p))
+	e.implicit = true
+	w.al.enqueue(e)
+	return len(p), nil
+}
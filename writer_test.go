@@ -0,0 +1,131 @@
+package alog
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsWriterWithStdLogger(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(10))
+	go alog.Start()
+	logger := log.New(alog.AsWriter(), "std: ", 0)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Printf("goroutine %d line %d", i, j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	alog.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("Expected 1000 lines, got %d", len(lines))
+	}
+	linePattern := regexp.MustCompile(messageTimestampPattern + `std: goroutine \d line \d+$`)
+	for _, line := range lines {
+		if !linePattern.MatchString(line) {
+			t.Fatalf("Corrupted line %q", line)
+		}
+	}
+}
+
+func TestAsWriterCopiesInput(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(1))
+	p := []byte("original")
+	if n, err := alog.AsWriter().Write(p); n != len(p) || err != nil {
+		t.Fatalf("Write returned %d, %v", n, err)
+	}
+	copy(p, "mutated!")
+	go alog.Start()
+	alog.Stop()
+	if !strings.HasSuffix(b.String(), "] - original\n") {
+		t.Errorf("Message changed after the caller reused its slice, got %q", b.String())
+	}
+}
+
+func TestAsWriterAfterStop(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	alog.Stop()
+	fmt.Fprintln(alog.AsWriter(), "late")
+	if b.Len() != 0 {
+		t.Errorf("Message written after Stop: %q", b.String())
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	logger := alog.StdLogger("std: ", log.LstdFlags|log.Lmsgprefix)
+	logger.Print("plain")
+	logger.Printf("formatted %d", 2)
+	logger.Println("line")
+	alog.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	want := []string{"std: plain", "std: formatted 2", "std: line"}
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d lines, got %q", len(want), b.String())
+	}
+	for i, line := range lines {
+		if !regexp.MustCompile(messageTimestampPattern + want[i] + "$").MatchString(line) {
+			t.Errorf("Got %q, want a single timestamp followed by %q", line, want[i])
+		}
+	}
+}
+
+func TestStdLoggerAsHTTPErrorLog(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b, WithBufferSize(10))
+	go alog.Start()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+	srv.Config.ErrorLog = alog.StdLogger("", 0)
+	srv.Start()
+	if resp, err := http.Get(srv.URL); err == nil {
+		resp.Body.Close()
+	}
+	srv.Close()
+	alog.Stop()
+	if !regexp.MustCompile(messageTimestampPattern + `http: panic serving .*: handler failed`).MatchString(b.String()) {
+		t.Errorf("Server error wasn't logged, got %q", b.String())
+	}
+}
+
+func TestStdLoggerAfterStop(t *testing.T) {
+	b := bytes.NewBuffer([]byte{})
+	alog := New(b)
+	go alog.Start()
+	alog.Stop()
+	doneCh := make(chan struct{})
+	go func() {
+		alog.StdLogger("", 0).Print("late")
+		close(doneCh)
+	}()
+	select {
+	case <-time.After(1 * time.Second):
+		t.Fatal("StdLogger blocked after Stop")
+	case <-doneCh:
+	}
+	if b.Len() != 0 {
+		t.Errorf("Message written after Stop: %q", b.String())
+	}
+}